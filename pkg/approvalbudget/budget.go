@@ -0,0 +1,337 @@
+package approvalbudget
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	hourly = "hourly"
+	daily  = "daily"
+)
+
+var cachedBuckets = buckets{
+	buckets: map[string]*bucket{},
+}
+
+type buckets struct {
+	sync.Mutex
+	buckets map[string]*bucket
+	// restored is true when the buckets were rebuilt from the approvals of the existing UserSignups
+	restored bool
+}
+
+// bucket is a token bucket that holds up to capacity tokens and is continuously refilled, so it gets full again after the given period
+type bucket struct {
+	capacity int
+	period   time.Duration
+	tokens   float64
+	updated  time.Time
+}
+
+func newBucket(capacity int, period time.Duration, now time.Time) *bucket {
+	return &bucket{
+		capacity: capacity,
+		period:   period,
+		tokens:   float64(capacity),
+		updated:  now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += float64(b.capacity) * elapsed.Seconds() / b.period.Seconds()
+		if b.tokens > float64(b.capacity) {
+			b.tokens = float64(b.capacity)
+		}
+		b.updated = now
+	}
+}
+
+// remaining returns the number of whole tokens available at the given time
+func (b *bucket) remaining(now time.Time) int {
+	b.refill(now)
+	return int(b.tokens)
+}
+
+func (b *bucket) take(now time.Time) {
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+	}
+}
+
+// Limit contains the remaining and the total approval budget
+type Limit struct {
+	Remaining int
+	Capacity  int
+}
+
+// Remaining contains the remaining approval budgets. Nil values mean that the budget is not limited.
+type Remaining struct {
+	Hourly *Limit
+	Daily  *Limit
+	// PerMemberCluster contains the hourly budgets mapped by the member cluster names
+	PerMemberCluster map[string]Limit
+}
+
+// IsConfigured returns true if there is any approval budget configured
+func (r Remaining) IsConfigured() bool {
+	return r.Hourly != nil || r.Daily != nil || len(r.PerMemberCluster) > 0
+}
+
+// IsExhausted returns true if the overall hourly or daily budget is exhausted, ie. no user can be approved automatically to any cluster
+func (r Remaining) IsExhausted() bool {
+	return (r.Hourly != nil && r.Hourly.Remaining == 0) || (r.Daily != nil && r.Daily.Remaining == 0)
+}
+
+// String returns a human readable description of the remaining budgets, eg. "hourly: 7/10, daily: 95/100, member-1: 3/5"
+func (r Remaining) String() string {
+	var values []string
+	if r.Hourly != nil {
+		values = append(values, fmt.Sprintf("%s: %d/%d", hourly, r.Hourly.Remaining, r.Hourly.Capacity))
+	}
+	if r.Daily != nil {
+		values = append(values, fmt.Sprintf("%s: %d/%d", daily, r.Daily.Remaining, r.Daily.Capacity))
+	}
+	clusterNames := make([]string, 0, len(r.PerMemberCluster))
+	for clusterName := range r.PerMemberCluster {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+	for _, clusterName := range clusterNames {
+		values = append(values, fmt.Sprintf("%s: %d/%d", clusterName, r.PerMemberCluster[clusterName].Remaining, r.PerMemberCluster[clusterName].Capacity))
+	}
+	return strings.Join(values, ", ")
+}
+
+// HasRemaining returns true if a user can be approved automatically to the given member cluster at the given time
+// without exceeding any of the configured approval budgets
+func HasRemaining(crtConfig *crtCfg.Config, clusterName string, now time.Time) bool {
+	cachedBuckets.Lock()
+	defer cachedBuckets.Unlock()
+	for _, b := range cachedBuckets.applicable(crtConfig, clusterName, now) {
+		if b.remaining(now) < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Consume charges one approval of a user provisioned to the given member clusters: it takes one approval from the overall
+// hourly and daily budgets (regardless of the number of the member clusters) and one from the budget of each of the member clusters
+func Consume(crtConfig *crtCfg.Config, now time.Time, clusterNames ...string) {
+	cachedBuckets.Lock()
+	defer cachedBuckets.Unlock()
+	for _, b := range cachedBuckets.overall(crtConfig, now) {
+		b.take(now)
+	}
+	for _, clusterName := range clusterNames {
+		if b := cachedBuckets.perMemberCluster(crtConfig, clusterName, now); b != nil {
+			b.take(now)
+		}
+	}
+}
+
+// GetRemaining returns the remaining approval budgets at the given time
+func GetRemaining(crtConfig *crtCfg.Config, now time.Time) Remaining {
+	cachedBuckets.Lock()
+	defer cachedBuckets.Unlock()
+	remaining := Remaining{
+		PerMemberCluster: map[string]Limit{},
+	}
+	if b := cachedBuckets.get(hourly, crtConfig.GetAutomaticApprovalBudgetHourly(), time.Hour, now); b != nil {
+		remaining.Hourly = &Limit{Remaining: b.remaining(now), Capacity: b.capacity}
+	}
+	if b := cachedBuckets.get(daily, crtConfig.GetAutomaticApprovalBudgetDaily(), 24*time.Hour, now); b != nil {
+		remaining.Daily = &Limit{Remaining: b.remaining(now), Capacity: b.capacity}
+	}
+	for clusterName, capacity := range crtConfig.GetAutomaticApprovalBudgetPerMemberCluster() {
+		if b := cachedBuckets.get(hourly+"/"+clusterName, capacity, time.Hour, now); b != nil {
+			remaining.PerMemberCluster[clusterName] = Limit{Remaining: b.remaining(now), Capacity: b.capacity}
+		}
+	}
+	return remaining
+}
+
+// Reset removes all the budgets so they are full again (and rebuilt on the next call of Restore) - is supposed to be used only in tests
+func Reset() {
+	cachedBuckets.Lock()
+	defer cachedBuckets.Unlock()
+	cachedBuckets.buckets = map[string]*bucket{}
+	cachedBuckets.restored = false
+}
+
+// approval is an automatic approval of a user that was charged to the approval budgets
+type approval struct {
+	time         time.Time
+	clusterNames []string
+}
+
+// Restore rebuilds the approval budgets from the automatic approvals of the existing UserSignups, so the budgets are not full again
+// after the operator restarts. It is done only once, the following calls do nothing.
+// The time of an approval is the last transition time of the Approved condition of the UserSignup and the member clusters
+// are the target clusters of the UserAccounts of its MasterUserRecord. Only the UserSignups that are still approved are taken into account.
+// The resources are read using the given reader, which is supposed to be backed by the informer cache.
+func Restore(cl client.Reader, crtConfig *crtCfg.Config, namespace string, now time.Time) error {
+	cachedBuckets.Lock()
+	restored := cachedBuckets.restored
+	cachedBuckets.Unlock()
+	if restored {
+		return nil
+	}
+	approvals, err := listAutomaticApprovalsSince(cl, namespace, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	cachedBuckets.Lock()
+	defer cachedBuckets.Unlock()
+	if cachedBuckets.restored {
+		return nil
+	}
+	cachedBuckets.buckets = map[string]*bucket{}
+	// every bucket is replayed from the beginning of its period, when it had to be full at the latest
+	for _, b := range []struct {
+		key      string
+		capacity int
+		period   time.Duration
+	}{
+		{key: hourly, capacity: crtConfig.GetAutomaticApprovalBudgetHourly(), period: time.Hour},
+		{key: daily, capacity: crtConfig.GetAutomaticApprovalBudgetDaily(), period: 24 * time.Hour},
+	} {
+		if replayed := replay(b.capacity, b.period, approvals, nil, now); replayed != nil {
+			cachedBuckets.buckets[b.key] = replayed
+		}
+	}
+	for clusterName, capacity := range crtConfig.GetAutomaticApprovalBudgetPerMemberCluster() {
+		clusterName := clusterName
+		if replayed := replay(capacity, time.Hour, approvals, func(a approval) bool {
+			return contains(a.clusterNames, clusterName)
+		}, now); replayed != nil {
+			cachedBuckets.buckets[hourly+"/"+clusterName] = replayed
+		}
+	}
+	cachedBuckets.restored = true
+	return nil
+}
+
+// replay creates a bucket that is full at the beginning of the given period before now and takes a token for every given approval
+// (that matches the filter, if any) made since then. If the capacity is zero (ie. the budget is not limited), then it returns nil.
+func replay(capacity int, period time.Duration, approvals []approval, filter func(approval) bool, now time.Time) *bucket {
+	if capacity <= 0 {
+		return nil
+	}
+	since := now.Add(-period)
+	b := newBucket(capacity, period, since)
+	for _, a := range approvals {
+		if a.time.Before(since) || (filter != nil && !filter(a)) {
+			continue
+		}
+		b.take(a.time)
+	}
+	b.refill(now)
+	return b
+}
+
+// listAutomaticApprovalsSince returns the automatic approvals of the approved UserSignups made at or after the given time, ordered by time
+func listAutomaticApprovalsSince(cl client.Reader, namespace string, since time.Time) ([]approval, error) {
+	userSignupList := &toolchainv1alpha1.UserSignupList{}
+	if err := cl.List(context.TODO(), userSignupList, client.InNamespace(namespace),
+		client.MatchingLabels{toolchainv1alpha1.UserSignupStateLabelKey: toolchainv1alpha1.UserSignupStateLabelValueApproved}); err != nil {
+		return nil, errors.Wrapf(err, "unable to list approved UserSignup resources")
+	}
+	var approvals []approval
+	for _, userSignup := range userSignupList.Items {
+		approved, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupApproved)
+		if !found || approved.Status != corev1.ConditionTrue || approved.Reason != toolchainv1alpha1.UserSignupApprovedAutomaticallyReason ||
+			approved.LastTransitionTime.Time.Before(since.Truncate(time.Second)) {
+			continue
+		}
+		a := approval{time: approved.LastTransitionTime.Time}
+		if userSignup.Status.CompliantUsername != "" {
+			mur := &toolchainv1alpha1.MasterUserRecord{}
+			if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: userSignup.Status.CompliantUsername}, mur); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return nil, errors.Wrapf(err, "unable to get the MasterUserRecord '%s'", userSignup.Status.CompliantUsername)
+				}
+			}
+			for _, account := range mur.Spec.UserAccounts {
+				a.clusterNames = append(a.clusterNames, account.TargetCluster)
+			}
+		}
+		approvals = append(approvals, a)
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].time.Before(approvals[j].time)
+	})
+	return approvals, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// applicable returns the buckets of all the budgets that are configured for the given member cluster
+func (c *buckets) applicable(crtConfig *crtCfg.Config, clusterName string, now time.Time) []*bucket {
+	applicable := c.overall(crtConfig, now)
+	if b := c.perMemberCluster(crtConfig, clusterName, now); b != nil {
+		applicable = append(applicable, b)
+	}
+	return applicable
+}
+
+// overall returns the buckets of the overall hourly and daily budgets (if configured)
+func (c *buckets) overall(crtConfig *crtCfg.Config, now time.Time) []*bucket {
+	var overall []*bucket
+	if b := c.get(hourly, crtConfig.GetAutomaticApprovalBudgetHourly(), time.Hour, now); b != nil {
+		overall = append(overall, b)
+	}
+	if b := c.get(daily, crtConfig.GetAutomaticApprovalBudgetDaily(), 24*time.Hour, now); b != nil {
+		overall = append(overall, b)
+	}
+	return overall
+}
+
+// perMemberCluster returns the bucket of the hourly budget of the given member cluster, or nil if there is no such budget configured
+func (c *buckets) perMemberCluster(crtConfig *crtCfg.Config, clusterName string, now time.Time) *bucket {
+	capacity, found := crtConfig.GetAutomaticApprovalBudgetPerMemberCluster()[clusterName]
+	if !found {
+		return nil
+	}
+	return c.get(hourly+"/"+clusterName, capacity, time.Hour, now)
+}
+
+// get returns the bucket stored under the given key. If there is no such a bucket or if its capacity has changed, then it creates a new (full) one.
+// If the capacity is zero (ie. the budget is not limited), then it returns nil.
+func (c *buckets) get(key string, capacity int, period time.Duration, now time.Time) *bucket {
+	if capacity <= 0 {
+		delete(c.buckets, key)
+		return nil
+	}
+	b, found := c.buckets[key]
+	if !found || b.capacity != capacity {
+		b = newBucket(capacity, period, now)
+		c.buckets[key] = b
+	}
+	return b
+}
@@ -0,0 +1,260 @@
+package approvalbudget
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBucket(t *testing.T) {
+	// given
+	now := time.Now()
+	b := newBucket(4, time.Hour, now)
+
+	t.Run("new bucket is full", func(t *testing.T) {
+		assert.Equal(t, 4, b.remaining(now))
+	})
+
+	t.Run("take reduces the remaining tokens", func(t *testing.T) {
+		// when
+		for i := 0; i < 5; i++ {
+			b.take(now)
+		}
+
+		// then
+		assert.Equal(t, 0, b.remaining(now))
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		assert.Equal(t, 0, b.remaining(now.Add(14*time.Minute)))
+		assert.Equal(t, 1, b.remaining(now.Add(15*time.Minute)))
+		assert.Equal(t, 2, b.remaining(now.Add(30*time.Minute)))
+	})
+
+	t.Run("never exceeds the capacity", func(t *testing.T) {
+		assert.Equal(t, 4, b.remaining(now.Add(10*time.Hour)))
+	})
+}
+
+func TestBudget(t *testing.T) {
+	// given
+	Reset()
+	defer Reset()
+	restore := test.SetEnvVarsAndRestore(t,
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "3"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_DAILY", "5"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_PERMEMBERCLUSTER", "member1=1"))
+	defer restore()
+	config, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+	now := time.Now()
+
+	t.Run("all budgets are full", func(t *testing.T) {
+		// when
+		remaining := GetRemaining(config, now)
+
+		// then
+		assert.True(t, remaining.IsConfigured())
+		assert.False(t, remaining.IsExhausted())
+		assert.Equal(t, "hourly: 3/3, daily: 5/5, member1: 1/1", remaining.String())
+		assert.True(t, HasRemaining(config, "member1", now))
+		assert.True(t, HasRemaining(config, "member2", now))
+	})
+
+	t.Run("per cluster budget is exhausted", func(t *testing.T) {
+		// when
+		Consume(config, now, "member1")
+
+		// then
+		assert.Equal(t, "hourly: 2/3, daily: 4/5, member1: 0/1", GetRemaining(config, now).String())
+		assert.False(t, HasRemaining(config, "member1", now))
+		assert.True(t, HasRemaining(config, "member2", now))
+	})
+
+	t.Run("hourly budget is exhausted", func(t *testing.T) {
+		// when
+		Consume(config, now, "member2")
+		Consume(config, now, "member2")
+
+		// then
+		remaining := GetRemaining(config, now)
+		assert.True(t, remaining.IsExhausted())
+		assert.Equal(t, "hourly: 0/3, daily: 2/5, member1: 0/1", remaining.String())
+		assert.False(t, HasRemaining(config, "member2", now))
+	})
+
+	t.Run("hourly budgets are refilled after an hour", func(t *testing.T) {
+		// when
+		remaining := GetRemaining(config, now.Add(time.Hour))
+
+		// then
+		assert.False(t, remaining.IsExhausted())
+		assert.Equal(t, "hourly: 3/3, daily: 2/5, member1: 1/1", remaining.String())
+		assert.True(t, HasRemaining(config, "member1", now.Add(time.Hour)))
+	})
+}
+
+func TestConsumeForMultipleMemberClusters(t *testing.T) {
+	// given
+	Reset()
+	defer Reset()
+	restore := test.SetEnvVarsAndRestore(t,
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "3"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_DAILY", "5"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_PERMEMBERCLUSTER", "member1=2,member2=2"))
+	defer restore()
+	config, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+	now := time.Now()
+
+	// when
+	Consume(config, now, "member1", "member2", "member3")
+
+	// then
+	assert.Equal(t, "hourly: 2/3, daily: 4/5, member1: 1/2, member2: 1/2", GetRemaining(config, now).String())
+}
+
+func TestBudgetNotConfigured(t *testing.T) {
+	// given
+	Reset()
+	config, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+
+	// when
+	Consume(config, time.Now(), "member1")
+
+	// then
+	remaining := GetRemaining(config, time.Now())
+	assert.False(t, remaining.IsConfigured())
+	assert.False(t, remaining.IsExhausted())
+	assert.Empty(t, remaining.String())
+	assert.True(t, HasRemaining(config, "member1", time.Now()))
+}
+
+func TestRestore(t *testing.T) {
+	// given
+	restore := test.SetEnvVarsAndRestore(t,
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "3"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_DAILY", "5"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_PERMEMBERCLUSTER", "member1=2"))
+	defer restore()
+	config, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+	now := time.Now()
+
+	t.Run("rebuilt from the automatic approvals of the existing UserSignups", func(t *testing.T) {
+		// given
+		Reset()
+		defer Reset()
+		var objs []runtime.Object
+		// charged to the hourly, daily and member1 budgets
+		objs = append(objs, approvedUserSignup("john", now.Add(-time.Minute), "member1")...)
+		// charged to the hourly and daily budgets only
+		objs = append(objs, approvedUserSignup("jane", now.Add(-2*time.Minute), "member2")...)
+		// charged to the daily budget only
+		objs = append(objs, approvedUserSignup("jack", now.Add(-3*time.Hour), "member1")...)
+		// not charged at all
+		objs = append(objs, approvedUserSignup("jill", now.Add(-25*time.Hour), "member1")...)
+		objs = append(objs,
+			NewUserSignup(Approved(), WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueApproved)),
+			NewUserSignup(WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValuePending)))
+		cl := test.NewFakeClient(t, objs...)
+
+		// when
+		err := Restore(cl, config, test.HostOperatorNs, now)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "hourly: 1/3, daily: 2/5, member1: 1/2", GetRemaining(config, now).String())
+		assert.True(t, HasRemaining(config, "member1", now))
+
+		t.Run("restored only once", func(t *testing.T) {
+			// given
+			Consume(config, now, "member1")
+			// would be charged if restored again
+			for _, obj := range approvedUserSignup("joe", now.Add(-time.Minute), "member2") {
+				require.NoError(t, cl.Create(context.TODO(), obj))
+			}
+
+			// when
+			err := Restore(cl, config, test.HostOperatorNs, now)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "hourly: 0/3, daily: 1/5, member1: 0/2", GetRemaining(config, now).String())
+		})
+	})
+
+	t.Run("MasterUserRecord not found", func(t *testing.T) {
+		// given
+		Reset()
+		defer Reset()
+		userSignup := approvedUserSignup("john", now.Add(-10*time.Minute), "member1")[0]
+		cl := test.NewFakeClient(t, userSignup)
+
+		// when
+		err := Restore(cl, config, test.HostOperatorNs, now)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "hourly: 2/3, daily: 4/5, member1: 2/2", GetRemaining(config, now).String())
+	})
+
+	t.Run("failed to list the UserSignups", func(t *testing.T) {
+		// given
+		Reset()
+		defer Reset()
+		cl := test.NewFakeClient(t, approvedUserSignup("john", now.Add(-time.Minute), "member1")...)
+		cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			return fmt.Errorf("some error")
+		}
+
+		// when
+		err := Restore(cl, config, test.HostOperatorNs, now)
+
+		// then
+		require.EqualError(t, err, "unable to list approved UserSignup resources: some error")
+		assert.Equal(t, "hourly: 3/3, daily: 5/5, member1: 2/2", GetRemaining(config, now).String())
+
+		t.Run("restored on the next call", func(t *testing.T) {
+			// given
+			cl.MockList = nil
+
+			// when
+			err := Restore(cl, config, test.HostOperatorNs, now)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "hourly: 2/3, daily: 4/5, member1: 1/2", GetRemaining(config, now).String())
+		})
+	})
+}
+
+// approvedUserSignup returns a UserSignup approved automatically at the given time together with its MasterUserRecord
+// that has a UserAccount in the given member cluster
+func approvedUserSignup(username string, approvedAt time.Time, targetCluster string) []runtime.Object {
+	userSignup := NewUserSignup(ApprovedAutomaticallyAt(approvedAt))
+	userSignup.Status.CompliantUsername = username
+	mur := &toolchainv1alpha1.MasterUserRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      username,
+			Namespace: test.HostOperatorNs,
+		},
+		Spec: toolchainv1alpha1.MasterUserRecordSpec{
+			UserAccounts: []toolchainv1alpha1.UserAccountEmbedded{{TargetCluster: targetCluster}},
+		},
+	}
+	return []runtime.Object{userSignup, mur}
+}
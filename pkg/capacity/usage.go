@@ -0,0 +1,249 @@
+package capacity
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("capacity_usage")
+
+const (
+	// nodeRoleLabelPrefix is the prefix of the labels that define the roles of a node, eg. node-role.kubernetes.io/worker
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+	// staleAfterPeriods is the number of collection periods after which the collected usage is not used anymore
+	// (eg. when the member cluster is not reachable for some time)
+	staleAfterPeriods = 3
+)
+
+var cachedUsage = cache{
+	usage: map[string]collectedUsage{},
+}
+
+type cache struct {
+	sync.RWMutex
+	usage map[string]collectedUsage
+}
+
+// collectedUsage is the usage of a member cluster together with the time when it was collected
+type collectedUsage struct {
+	usage     Usage
+	collected time.Time
+}
+
+// Usage contains the usage of the CPU and storage resources of a member cluster.
+// The memory usage is not included as it is reported by the member operator in the MemberStatus resource.
+type Usage struct {
+	// How many percent of the allocatable CPU is requested by the pods per node role (eg. worker, master)
+	CPURequestsPerNodeRole map[string]int
+	// How many percent of the allocatable ephemeral storage is requested by the pods per node role (eg. worker, master)
+	EphemeralStorageRequestsPerNodeRole map[string]int
+	// The total storage requested by all PersistentVolumeClaims
+	PVCStorage resource.Quantity
+}
+
+// IsCollectionEnabled returns true if any of the CPU, ephemeral storage or PVC thresholds is configured,
+// so the usage of these resources needs to be collected from the member clusters
+func IsCollectionEnabled(crtConfig *crtCfg.Config) bool {
+	return crtConfig.GetCPUCapacityThreshold().IsDefined() ||
+		crtConfig.GetEphemeralStorageCapacityThreshold().IsDefined() ||
+		crtConfig.GetPVCCapacityThreshold() > 0
+}
+
+// Collect computes the usage of the CPU and storage resources of the member cluster accessible via the given client
+func Collect(cl client.Client) (Usage, error) {
+	nodes := &corev1.NodeList{}
+	if err := cl.List(context.TODO(), nodes); err != nil {
+		return Usage{}, errors.Wrap(err, "unable to list the nodes")
+	}
+	pods := &corev1.PodList{}
+	if err := cl.List(context.TODO(), pods); err != nil {
+		return Usage{}, errors.Wrap(err, "unable to list the pods")
+	}
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := cl.List(context.TODO(), claims); err != nil {
+		return Usage{}, errors.Wrap(err, "unable to list the persistent volume claims")
+	}
+
+	requestsPerNode := map[string]corev1.ResourceList{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		requests, found := requestsPerNode[pod.Spec.NodeName]
+		if !found {
+			requests = corev1.ResourceList{}
+			requestsPerNode[pod.Spec.NodeName] = requests
+		}
+		for name, quantity := range podRequests(pod) {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+
+	cpu := newUsagePerNodeRole()
+	ephemeralStorage := newUsagePerNodeRole()
+	for _, node := range nodes.Items {
+		requests := requestsPerNode[node.Name]
+		for _, role := range getNodeRoles(node) {
+			cpu.add(role, requests[corev1.ResourceCPU], node.Status.Allocatable[corev1.ResourceCPU])
+			ephemeralStorage.add(role, requests[corev1.ResourceEphemeralStorage], node.Status.Allocatable[corev1.ResourceEphemeralStorage])
+		}
+	}
+
+	pvcStorage := resource.Quantity{}
+	for _, claim := range claims.Items {
+		pvcStorage.Add(claim.Spec.Resources.Requests[corev1.ResourceStorage])
+	}
+
+	return Usage{
+		CPURequestsPerNodeRole:              cpu.percentages(),
+		EphemeralStorageRequestsPerNodeRole: ephemeralStorage.percentages(),
+		PVCStorage:                          pvcStorage,
+	}, nil
+}
+
+// CollectPeriodically collects the usage of all the member clusters right away and then periodically, as configured
+// by the collection period, until the stop channel is closed. The usage is collected in a separate loop
+// (and not in every reconcile of ToolchainStatus) because listing all the nodes, pods and PVCs of the member clusters is expensive.
+func CollectPeriodically(stop <-chan struct{}, crtConfig *crtCfg.Config, getMemberClusters cluster.GetMemberClustersFunc) {
+	ticker := time.NewTicker(crtConfig.GetResourceCapacityUsageCollectionPeriod())
+	defer ticker.Stop()
+	for {
+		CollectAll(getMemberClusters, time.Now())
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CollectAll collects and stores the usage of all the member clusters. The clusters whose usage cannot be collected are skipped
+// (their previous usage is kept until it gets stale).
+func CollectAll(getMemberClusters cluster.GetMemberClustersFunc, now time.Time) {
+	for _, memberCluster := range getMemberClusters() {
+		usage, err := Collect(memberCluster.Client)
+		if err != nil {
+			log.Error(err, "unable to collect the resource usage", "cluster_name", memberCluster.Name)
+			continue
+		}
+		Set(memberCluster.Name, usage, now)
+	}
+}
+
+// Set stores the usage of the given member cluster collected at the given time
+func Set(clusterName string, usage Usage, collected time.Time) {
+	cachedUsage.Lock()
+	defer cachedUsage.Unlock()
+	cachedUsage.usage[clusterName] = collectedUsage{
+		usage:     usage,
+		collected: collected,
+	}
+}
+
+// Get returns the last collected usage of the given member cluster. The second value is false if the usage hasn't been collected yet
+// or if it was collected more than staleAfterPeriods collection periods before the given time.
+func Get(crtConfig *crtCfg.Config, clusterName string, now time.Time) (Usage, bool) {
+	cachedUsage.RLock()
+	defer cachedUsage.RUnlock()
+	collected, found := cachedUsage.usage[clusterName]
+	if !found || now.Sub(collected.collected) > staleAfterPeriods*crtConfig.GetResourceCapacityUsageCollectionPeriod() {
+		return Usage{}, false
+	}
+	return collected.usage, true
+}
+
+// Reset removes all the collected usage - is supposed to be used only in tests
+func Reset() {
+	cachedUsage.Lock()
+	defer cachedUsage.Unlock()
+	cachedUsage.usage = map[string]collectedUsage{}
+}
+
+// podRequests returns the resources requested by the pod, that is the maximum of the sum of the requests of all the containers
+// and the requests of any of the init containers (which run one by one before the containers)
+func podRequests(pod corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if total, found := requests[name]; !found || quantity.Cmp(total) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
+
+// getNodeRoles returns the roles of the node defined by the node-role.kubernetes.io/<role> labels
+func getNodeRoles(node corev1.Node) []string {
+	var roles []string
+	for label := range node.Labels {
+		if strings.HasPrefix(label, nodeRoleLabelPrefix) {
+			roles = append(roles, strings.TrimPrefix(label, nodeRoleLabelPrefix))
+		}
+	}
+	return roles
+}
+
+// usagePerNodeRole sums the requested and allocatable amount of a resource per node role
+type usagePerNodeRole struct {
+	requested   map[string]*resource.Quantity
+	allocatable map[string]*resource.Quantity
+}
+
+func newUsagePerNodeRole() usagePerNodeRole {
+	return usagePerNodeRole{
+		requested:   map[string]*resource.Quantity{},
+		allocatable: map[string]*resource.Quantity{},
+	}
+}
+
+func (u usagePerNodeRole) add(role string, requested, allocatable resource.Quantity) {
+	if _, found := u.requested[role]; !found {
+		u.requested[role] = &resource.Quantity{}
+		u.allocatable[role] = &resource.Quantity{}
+	}
+	u.requested[role].Add(requested)
+	u.allocatable[role].Add(allocatable)
+}
+
+// percentages returns how many percent of the allocatable amount is requested per node role.
+// The roles without any allocatable amount of the resource are omitted.
+func (u usagePerNodeRole) percentages() map[string]int {
+	percentages := map[string]int{}
+	for role, allocatable := range u.allocatable {
+		if allocatable.IsZero() {
+			continue
+		}
+		percentages[role] = Percentage(*u.requested[role], *allocatable)
+	}
+	return percentages
+}
+
+// Percentage returns how many percent of the total is the given value
+func Percentage(value, total resource.Quantity) int {
+	if total.IsZero() {
+		return 0
+	}
+	return int(float64(value.MilliValue()) * 100 / float64(total.MilliValue()))
+}
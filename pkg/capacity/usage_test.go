@@ -0,0 +1,235 @@
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCollect(t *testing.T) {
+	// given
+	objects := []runtime.Object{
+		newNode("worker-1", "4", "100Gi", "worker"),
+		newNode("worker-2", "4", "100Gi", "worker"),
+		newNode("master-1", "2", "50Gi", "master", "worker"),
+		newNode("no-role", "8", "100Gi"),
+		newPod("app-1", "worker-1", corev1.PodRunning, requests("1", "10Gi"), requests("500m", "")),
+		newPod("app-2", "worker-2", corev1.PodPending, requests("2", "")),
+		withInitContainer(newPod("with-init", "master-1", corev1.PodRunning, requests("500m", "5Gi")), requests("1", "1Gi")),
+		newPod("completed", "worker-1", corev1.PodSucceeded, requests("4", "100Gi")),
+		newPod("unscheduled", "", corev1.PodPending, requests("4", "100Gi")),
+		newPVC("data-1", "10Gi"),
+		newPVC("data-2", "5Gi"),
+	}
+
+	t.Run("usage of the resources", func(t *testing.T) {
+		// when
+		usage, err := Collect(test.NewFakeClient(t, objects...))
+
+		// then
+		require.NoError(t, err)
+		// worker: (1.5 + 2 + 1) / (4 + 4 + 2), master: 1 / 2
+		assert.Equal(t, map[string]int{"worker": 45, "master": 50}, usage.CPURequestsPerNodeRole)
+		// worker: (10 + 5) / (100 + 100 + 50), master: 5 / 50
+		assert.Equal(t, map[string]int{"worker": 6, "master": 10}, usage.EphemeralStorageRequestsPerNodeRole)
+		assert.Equal(t, "15Gi", usage.PVCStorage.String())
+	})
+
+	t.Run("fails when list fails", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, objects...)
+		cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			if _, ok := list.(*corev1.PodList); ok {
+				return fmt.Errorf("some error")
+			}
+			return cl.Client.List(ctx, list, opts...)
+		}
+
+		// when
+		_, err := Collect(cl)
+
+		// then
+		require.EqualError(t, err, "unable to list the pods: some error")
+	})
+}
+
+func TestCollectAll(t *testing.T) {
+	// given
+	Reset()
+	defer Reset()
+	config, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+	now := time.Now()
+	member1Client := test.NewFakeClient(t, newNode("worker-1", "4", "100Gi", "worker"), newPod("app-1", "worker-1", corev1.PodRunning, requests("1", "10Gi")))
+	member2Client := test.NewFakeClient(t)
+	member2Client.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+		return fmt.Errorf("some error")
+	}
+	Set("member2", Usage{CPURequestsPerNodeRole: map[string]int{"worker": 10}}, now.Add(-time.Minute))
+	getMemberClusters := func(conditions ...cluster.Condition) []*cluster.CachedToolchainCluster {
+		return []*cluster.CachedToolchainCluster{
+			{Name: "member1", Client: member1Client},
+			{Name: "member2", Client: member2Client},
+		}
+	}
+
+	// when
+	CollectAll(getMemberClusters, now)
+
+	// then
+	usage, found := Get(config, "member1", now)
+	require.True(t, found)
+	assert.Equal(t, map[string]int{"worker": 25}, usage.CPURequestsPerNodeRole)
+	// the previous usage is kept when the collection fails
+	usage, found = Get(config, "member2", now)
+	require.True(t, found)
+	assert.Equal(t, map[string]int{"worker": 10}, usage.CPURequestsPerNodeRole)
+}
+
+func TestCache(t *testing.T) {
+	// given
+	Reset()
+	defer Reset()
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_USAGE_COLLECTION_PERIOD", "1m")
+	defer restore()
+	config, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+	now := time.Now()
+	usage := Usage{CPURequestsPerNodeRole: map[string]int{"worker": 10}}
+
+	// when
+	Set("member1", usage, now)
+
+	// then
+	stored, found := Get(config, "member1", now)
+	assert.True(t, found)
+	assert.Equal(t, usage, stored)
+	stored, found = Get(config, "member1", now.Add(3*time.Minute))
+	assert.True(t, found)
+	assert.Equal(t, usage, stored)
+	_, found = Get(config, "member1", now.Add(3*time.Minute+time.Second))
+	assert.False(t, found, "the usage collected more than three collection periods ago should be ignored")
+	_, found = Get(config, "member2", now)
+	assert.False(t, found)
+
+	Reset()
+	_, found = Get(config, "member1", now)
+	assert.False(t, found)
+}
+
+func TestIsCollectionEnabled(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(test.NewFakeClient(t))
+		require.NoError(t, err)
+
+		// then
+		assert.False(t, IsCollectionEnabled(config))
+	})
+
+	for _, key := range []string{
+		"HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_CPU_THRESHOLD",
+		"HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_EPHEMERALSTORAGE_THRESHOLD",
+		"HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_PVC_THRESHOLD",
+	} {
+		t.Run(key, func(t *testing.T) {
+			// given
+			restore := test.SetEnvVarAndRestore(t, key, "80")
+			defer restore()
+			config, err := configuration.LoadConfig(test.NewFakeClient(t))
+			require.NoError(t, err)
+
+			// then
+			assert.True(t, IsCollectionEnabled(config))
+		})
+	}
+}
+
+func TestPercentage(t *testing.T) {
+	assert.Equal(t, 50, Percentage(resource.MustParse("500m"), resource.MustParse("1")))
+	assert.Equal(t, 33, Percentage(resource.MustParse("1Ti"), resource.MustParse("3Ti")))
+	assert.Equal(t, 0, Percentage(resource.MustParse("1"), resource.Quantity{}))
+}
+
+func newNode(name, cpu, ephemeralStorage string, roles ...string) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{},
+		},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse(cpu),
+				corev1.ResourceEphemeralStorage: resource.MustParse(ephemeralStorage),
+			},
+		},
+	}
+	for _, role := range roles {
+		node.Labels[nodeRoleLabelPrefix+role] = ""
+	}
+	return node
+}
+
+func newPod(name, nodeName string, phase corev1.PodPhase, containerRequests ...corev1.ResourceList) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "user-dev",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+		},
+	}
+	for _, r := range containerRequests {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Resources: corev1.ResourceRequirements{Requests: r}})
+	}
+	return pod
+}
+
+func withInitContainer(pod *corev1.Pod, containerRequests corev1.ResourceList) *corev1.Pod {
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{Resources: corev1.ResourceRequirements{Requests: containerRequests}})
+	return pod
+}
+
+func requests(cpu, ephemeralStorage string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	if cpu != "" {
+		list[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if ephemeralStorage != "" {
+		list[corev1.ResourceEphemeralStorage] = resource.MustParse(ephemeralStorage)
+	}
+	return list
+}
+
+func newPVC(name, storage string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "user-dev",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(storage),
+				},
+			},
+		},
+	}
+}
@@ -3,13 +3,17 @@
 package configuration
 
 import (
+	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -87,6 +91,60 @@ const (
 	// varMasterUserRecordUpdateFailureThreshold specifies the number allowed failures before stopping trying to update a MasterUserRecord
 	varMasterUserRecordUpdateFailureThreshold = "masteruserrecord.update.failure.threshold"
 
+	// varMasterUserRecordMigrationTimeout specifies how long to wait for the UserAccount to become ready in the destination member cluster
+	// when migrating a MasterUserRecord. If the UserAccount isn't ready in time, then the migration is rolled back.
+	varMasterUserRecordMigrationTimeout = "masteruserrecord.migration.timeout"
+
+	// defaultMasterUserRecordMigrationTimeout is the default value of varMasterUserRecordMigrationTimeout
+	defaultMasterUserRecordMigrationTimeout = "10m"
+
+	// varMasterUserRecordResyncInterval specifies how often every MasterUserRecord is reconciled again, so the UserAccounts
+	// that were changed or deleted in the member clusters are detected and brought back in sync. A zero value disables the resync.
+	varMasterUserRecordResyncInterval = "masteruserrecord.resync.interval"
+
+	// defaultMasterUserRecordResyncInterval is the default value of varMasterUserRecordResyncInterval
+	defaultMasterUserRecordResyncInterval = "1h"
+
+	// varMasterUserRecordOrphanGracePeriod specifies how long a member cluster can be unavailable while a MasterUserRecord is being deleted
+	// before the UserAccount in this member cluster is orphaned, so the deletion of the MasterUserRecord can complete
+	varMasterUserRecordOrphanGracePeriod = "masteruserrecord.orphan.graceperiod"
+
+	// defaultMasterUserRecordOrphanGracePeriod is the default value of varMasterUserRecordOrphanGracePeriod
+	defaultMasterUserRecordOrphanGracePeriod = "24h"
+
+	// varUserAccountDeletionRequeueInitial specifies how long to wait before checking for the first time whether the deletion
+	// of a UserAccount in a member cluster has completed. The waiting time then doubles with every check.
+	varUserAccountDeletionRequeueInitial = "useraccount.deletion.requeue.initial"
+
+	// defaultUserAccountDeletionRequeueInitial is the default value of varUserAccountDeletionRequeueInitial
+	defaultUserAccountDeletionRequeueInitial = "10s"
+
+	// varUserAccountDeletionRequeueMax specifies the longest time to wait between two checks of the deletion of a UserAccount in a member cluster
+	varUserAccountDeletionRequeueMax = "useraccount.deletion.requeue.max"
+
+	// defaultUserAccountDeletionRequeueMax is the default value of varUserAccountDeletionRequeueMax
+	defaultUserAccountDeletionRequeueMax = "5m"
+
+	// varUserAccountDeletionRequeueJitter specifies the maximum factor of the random time added to the waiting time between two checks
+	// of the deletion of a UserAccount in a member cluster (eg, 0.1 adds up to 10%). A zero value disables the jitter.
+	varUserAccountDeletionRequeueJitter = "useraccount.deletion.requeue.jitter"
+
+	// defaultUserAccountDeletionRequeueJitter is the default value of varUserAccountDeletionRequeueJitter
+	defaultUserAccountDeletionRequeueJitter = 0.1
+
+	// varUserAccountDeletionTimeout specifies how long the deletion of a UserAccount in a member cluster can take before it's considered as stuck
+	varUserAccountDeletionTimeout = "useraccount.deletion.timeout"
+
+	// defaultUserAccountDeletionTimeout is the default value of varUserAccountDeletionTimeout
+	defaultUserAccountDeletionTimeout = "1m"
+
+	// varUserAccountRecreationDelay specifies how long to wait after the deletion timestamp of a UserAccount that was deleted
+	// in a member cluster (while its MasterUserRecord was not) before the UserAccount is created again
+	varUserAccountRecreationDelay = "useraccount.recreation.delay"
+
+	// defaultUserAccountRecreationDelay is the default value of varUserAccountRecreationDelay
+	defaultUserAccountRecreationDelay = "3s"
+
 	// varToolchainStatusRefreshTime specifies how often the ToolchainStatus should load and refresh the current hosted-toolchain status
 	varToolchainStatusRefreshTime = "toolchainstatus.refresh.time"
 
@@ -109,6 +167,40 @@ const (
 
 	DefaultForbiddenUsernameSuffixes = "admin"
 
+	// varForbiddenUsernames is a string of comma-separated reserved names that a username may not be equal to when signing up.
+	// If a username is reserved, then the username compliance prefix is added to the username
+	varForbiddenUsernames = "username.forbidden.names"
+
+	// varForbiddenUsernamePatterns is a string of comma-separated regular expressions that a username may not match when signing up.
+	// If a username matches any of them, then the username compliance prefix is added to the username
+	varForbiddenUsernamePatterns = "username.forbidden.patterns"
+
+	// varUsernameMaxLength defines the maximum length of a compliant username. Longer usernames are truncated and a short hash
+	// of the original username is appended to keep them unique. If it is 0, then the usernames are not truncated.
+	varUsernameMaxLength = "username.max.length"
+
+	// varUsernameCollisionStrategy defines how a vacant username is found when the compliant username is already taken
+	varUsernameCollisionStrategy = "username.collision.strategy"
+
+	// UsernameCollisionStrategySequential appends the sequential suffixes -2 up to -100 to the username (the default strategy)
+	UsernameCollisionStrategySequential = "sequential"
+
+	// UsernameCollisionStrategyHashed appends a short hash computed from the UserSignup name and the attempt number to the username
+	UsernameCollisionStrategyHashed = "hashed"
+
+	// varReservedUsernamesConfigMapName specifies the name of the ConfigMap containing the reserved usernames that cannot be used by new users
+	varReservedUsernamesConfigMapName = "username.reserved.configmap"
+
+	// defaultReservedUsernamesConfigMapName is the default value of varReservedUsernamesConfigMapName
+	defaultReservedUsernamesConfigMapName = "reserved-usernames"
+
+	// varReservedUsernamesCooldown specifies for how long the username of a deleted UserSignup is reserved so it cannot be used by a new user.
+	// If it is 0, then the usernames of the deleted UserSignups are not reserved.
+	varReservedUsernamesCooldown = "username.reserved.cooldown"
+
+	// defaultReservedUsernamesCooldown is the default value of varReservedUsernamesCooldown - the usernames are not reserved unless it is configured
+	defaultReservedUsernamesCooldown = "0s"
+
 	// varUserSignupUnverifiedRetentionDays is used to configure how many days we should keep unverified (i.e. the user
 	// hasn't completed the user verification process via the registration service) UserSignup resources before deleting
 	// them.  It is intended for this parameter to define an aggressive cleanup schedule for unverified user signups,
@@ -123,13 +215,279 @@ const (
 	varUserSignupDeactivatedRetentionDays = "usersignup.deactivated.retention.days"
 
 	defaultUserSignupDeactivatedRetentionDays = 180
+
+	// varUserSignupReactivationVerificationAfter specifies how long a UserSignup has to be deactivated so the user is required to go
+	// through the verification again when the UserSignup is reactivated. If it is 0, then the period of the deactivation is not checked.
+	varUserSignupReactivationVerificationAfter = "usersignup.reactivation.verification.after"
+
+	// varUserSignupReactivationVerificationOnEmailDomainChange specifies if the user is required to go through the verification again
+	// when the UserSignup is reactivated with an email address from a different domain than the one the user had when deactivated
+	varUserSignupReactivationVerificationOnEmailDomainChange = "usersignup.reactivation.verification.emaildomainchange"
+
+	// varUserSignupStateHistoryMaxLength specifies how many transitions of the state label are kept in the history of a UserSignup.
+	// If it is 0, then no history is kept.
+	varUserSignupStateHistoryMaxLength = "usersignup.statehistory.maxlength"
+
+	// defaultUserSignupStateHistoryMaxLength is the default value of varUserSignupStateHistoryMaxLength
+	defaultUserSignupStateHistoryMaxLength = 10
+
+	// varPlacementStrategy specifies the strategy used for selecting the member cluster a new user is provisioned to.
+	// Unlike the settings in the HostOperatorConfig resource (whose spec is defined in the API module and doesn't have any field for it),
+	// the strategy is read from the environment variable HOST_OPERATOR_PLACEMENT_STRATEGY or from the host operator ConfigMap
+	// when the operator starts, so changing it requires a restart of the operator.
+	varPlacementStrategy = "placement.strategy"
+
+	// PlacementStrategyFirstReady selects the first member cluster which is ready and satisfies all capacity conditions
+	PlacementStrategyFirstReady = "first-ready"
+
+	// PlacementStrategyLeastLoaded selects the member cluster with the lowest number of provisioned UserAccounts
+	PlacementStrategyLeastLoaded = "least-loaded"
+
+	// PlacementStrategyLowestMemoryUsage selects the member cluster with the lowest memory usage (of its most loaded node role)
+	PlacementStrategyLowestMemoryUsage = "lowest-memory-usage"
+
+	// PlacementStrategyWeightedRoundRobin distributes the users between the member clusters in a round-robin fashion
+	// respecting the weights defined via varPlacementWeights
+	PlacementStrategyWeightedRoundRobin = "weighted-round-robin"
+
+	// PlacementStrategyRandom selects a random member cluster using the seed defined via varPlacementRandomSeed
+	PlacementStrategyRandom = "random"
+
+	// varPlacementWeights is a string of comma-separated cluster-name=weight pairs used by the weighted-round-robin strategy
+	// For example: "member-1=3,member-2=1". Clusters that are not listed have the weight 1.
+	varPlacementWeights = "placement.weights"
+
+	// varPlacementRandomSeed specifies the seed used by the random strategy. If it is 0, then the current time is used.
+	varPlacementRandomSeed = "placement.random.seed"
+
+	// varDefaultTier specifies the NSTemplateTier a new user is provisioned with when none of the tier assignment rules matches
+	varDefaultTier = "tier.default"
+
+	// defaultDefaultTier is the default value of varDefaultTier
+	defaultDefaultTier = "base"
+
+	// varTierAssignmentRules is a string of comma-separated rules that map UserSignup attributes to the initial NSTemplateTier.
+	// Every rule has the form <attribute>:<value>:<tier> and the rules are evaluated in the given order - the first matching rule wins.
+	// The supported attributes are emailDomain, company, label (with the value in the form key=value) and approval (automatic or admin).
+	// For example: "emailDomain:redhat.com:team,label:toolchain.dev.openshift.com/partner=true:advanced,approval:admin:advanced"
+	varTierAssignmentRules = "tier.assignment.rules"
+
+	// TierAssignmentAttributeEmailDomain matches the domain of the email address of the user
+	TierAssignmentAttributeEmailDomain = "emailDomain"
+
+	// TierAssignmentAttributeCompany matches the company of the user
+	TierAssignmentAttributeCompany = "company"
+
+	// TierAssignmentAttributeLabel matches a label (key=value) set on the UserSignup
+	TierAssignmentAttributeLabel = "label"
+
+	// TierAssignmentAttributeApproval matches the way the user was approved (automatic or admin)
+	TierAssignmentAttributeApproval = "approval"
+
+	// varTierUserAccounts is a string of comma-separated tier-name=number pairs that define on how many member clusters
+	// the users of the tier get a UserAccount. For example: "base=1,ha=2". Tiers that are not listed have one UserAccount.
+	varTierUserAccounts = "tier.user.accounts"
+
+	// varTierWeights is a string of comma-separated tier-name=weight pairs that define how much of the capacity of a member cluster
+	// a UserAccount of the tier consumes compared to the others. For example: "base=1,advanced=2,team=3".
+	// The weights of the tiers that are not listed are derived from their ClusterResourceQuotas (if varTierWeightMemoryUnit is set) or they are 1.
+	varTierWeights = "tier.weights"
+
+	// varTierWeightMemoryUnit specifies the amount of memory (eg. "7Gi") that corresponds to the weight 1. The weight of a tier that is not
+	// listed in varTierWeights is the sum of the limits.memory of the ClusterResourceQuotas of the tier divided by this unit (rounded up).
+	// If it is empty, then the weights are not derived.
+	varTierWeightMemoryUnit = "tier.weight.memory.unit"
+
+	// varUserAccountsTopologyKey specifies the key of the ToolchainCluster label whose value has to be different for all
+	// member clusters a user with several UserAccounts is provisioned to (eg. "region" to have one UserAccount per region).
+	// If it is empty, then the UserAccounts are only provisioned to different member clusters.
+	varUserAccountsTopologyKey = "user.accounts.topology.key"
+)
+
+// automatic approval constants
+const (
+	// varAutomaticApprovalDomainsAllowed is a string of comma-separated email domain patterns. Users whose email domain matches any of them
+	// are approved automatically even if the automatic approval is disabled in HostOperatorConfig.
+	// A pattern is either an exact domain ("redhat.com"), a wildcard matching any subdomain ("*.redhat.com")
+	// or a regular expression enclosed in slashes ("/^(eu|us)\.redhat\.com$/").
+	varAutomaticApprovalDomainsAllowed = "automaticapproval.domains.allowed"
+
+	// varAutomaticApprovalDomainsDenied is a string of comma-separated email domain patterns (in the same format as for varAutomaticApprovalDomainsAllowed).
+	// Users whose email domain matches any of them are never approved automatically - they can be approved only manually.
+	// The deny list takes precedence over the allow list.
+	varAutomaticApprovalDomainsDenied = "automaticapproval.domains.denied"
+
+	// varAutomaticApprovalBatchSize specifies how many of the oldest UserSignups pending approval should be reconciled
+	// when the ToolchainStatus changes (ie. when there might be new free capacity in the member clusters)
+	varAutomaticApprovalBatchSize = "automaticapproval.batch.size"
+
+	// defaultAutomaticApprovalBatchSize is the default value of varAutomaticApprovalBatchSize
+	defaultAutomaticApprovalBatchSize = 1
+
+	// varAutomaticApprovalQueueRefreshPeriod specifies how often all the UserSignups pending approval are reconciled, so their position
+	// in the approval queue and the estimated wait are refreshed in their status. If it is 0, then the positions are refreshed
+	// only when the UserSignups are reconciled for any other reason.
+	varAutomaticApprovalQueueRefreshPeriod = "automaticapproval.queue.refresh.period"
+
+	// defaultAutomaticApprovalQueueRefreshPeriod is the default value of varAutomaticApprovalQueueRefreshPeriod
+	defaultAutomaticApprovalQueueRefreshPeriod = "5m"
+
+	// varAutomaticApprovalSchedule is a string of comma-separated windows during which the automatic approval is active.
+	// Every window has the form "<weekdays> <from>-<to>" where weekdays is either a single day ("Sat"), a range of days ("Mon-Fri")
+	// or "*" for all days, and from/to is the time of the day in the 24-hour format. If "to" is not after "from", then the window
+	// ends on the next day. For example: "Mon-Fri 08:00-18:00,Sat 10:00-14:00". If no window is defined, then there is no restriction.
+	varAutomaticApprovalSchedule = "automaticapproval.schedule.windows"
+
+	// varAutomaticApprovalScheduleTimezone specifies the IANA time zone the schedule windows are defined in
+	varAutomaticApprovalScheduleTimezone = "automaticapproval.schedule.timezone"
+
+	// defaultAutomaticApprovalScheduleTimezone is the default value of varAutomaticApprovalScheduleTimezone
+	defaultAutomaticApprovalScheduleTimezone = "UTC"
+
+	// varAutomaticApprovalScheduleMaxApprovals specifies the maximum number of users approved automatically during a single window.
+	// If it is 0, then the number is not limited.
+	varAutomaticApprovalScheduleMaxApprovals = "automaticapproval.schedule.maxapprovals"
+
+	// varAutomaticApprovalBudgetHourly specifies the maximum number of users approved automatically per hour. If it is 0, then the number is not limited.
+	varAutomaticApprovalBudgetHourly = "automaticapproval.budget.hourly"
+
+	// varAutomaticApprovalBudgetDaily specifies the maximum number of users approved automatically per day. If it is 0, then the number is not limited.
+	varAutomaticApprovalBudgetDaily = "automaticapproval.budget.daily"
+
+	// varAutomaticApprovalBudgetPerMemberCluster is a string of comma-separated cluster-name=budget pairs that specify the maximum number
+	// of users approved automatically per hour for the given member clusters, eg. "member-1=50,member-2=10"
+	varAutomaticApprovalBudgetPerMemberCluster = "automaticapproval.budget.permembercluster"
+)
+
+// resource capacity constants
+const (
+	// varResourceCapacityIgnoredNodeRoles is a string of comma-separated node roles (eg. "master,infra") that are ignored
+	// by all the resource capacity checks of the automatic approval
+	varResourceCapacityIgnoredNodeRoles = "automaticapproval.resourcecapacity.ignorednoderoles"
+
+	// varResourceCapacityMemoryThresholdPerNodeRole is a string of comma-separated role=threshold pairs that override the memory usage threshold
+	// defined in HostOperatorConfig for the given node roles, eg. "worker=75,infra=90". The threshold 0 means that the role is not checked.
+	varResourceCapacityMemoryThresholdPerNodeRole = "automaticapproval.resourcecapacity.memory.pernoderole"
+
+	// varResourceCapacityCPUThreshold specifies the maximum percentage of the allocatable CPU of the nodes that can be requested by the pods.
+	// If it is 0, then the CPU requests are not checked.
+	varResourceCapacityCPUThreshold = "automaticapproval.resourcecapacity.cpu.threshold"
+
+	// varResourceCapacityCPUThresholdPerNodeRole is a string of comma-separated role=threshold pairs that override varResourceCapacityCPUThreshold
+	// for the given node roles
+	varResourceCapacityCPUThresholdPerNodeRole = "automaticapproval.resourcecapacity.cpu.pernoderole"
+
+	// varResourceCapacityEphemeralStorageThreshold specifies the maximum percentage of the allocatable ephemeral storage of the nodes
+	// that can be requested by the pods. If it is 0, then the ephemeral storage requests are not checked.
+	varResourceCapacityEphemeralStorageThreshold = "automaticapproval.resourcecapacity.ephemeralstorage.threshold"
+
+	// varResourceCapacityEphemeralStorageThresholdPerNodeRole is a string of comma-separated role=threshold pairs that override
+	// varResourceCapacityEphemeralStorageThreshold for the given node roles
+	varResourceCapacityEphemeralStorageThresholdPerNodeRole = "automaticapproval.resourcecapacity.ephemeralstorage.pernoderole"
+
+	// varResourceCapacityPVCThreshold specifies the maximum percentage of the storage capacity of a member cluster that can be requested
+	// by the PersistentVolumeClaims. If it is 0, then the PVC storage is not checked.
+	varResourceCapacityPVCThreshold = "automaticapproval.resourcecapacity.pvc.threshold"
+
+	// varResourceCapacityPVCStoragePerMemberCluster is a string of comma-separated cluster-name=quantity pairs that specify the storage capacity
+	// available for the PersistentVolumeClaims in the given member clusters, eg. "member-1=10Ti,member-2=500Gi".
+	// The PVC storage is not checked for the member clusters that are not listed.
+	varResourceCapacityPVCStoragePerMemberCluster = "automaticapproval.resourcecapacity.pvc.storage"
+
+	// varResourceCapacityUsageCollectionPeriod specifies how often the usage of the CPU and storage resources is collected from the member clusters
+	// (when any of the CPU, ephemeral storage or PVC thresholds is configured). The usage that is older than three periods is not used.
+	// If it is 0, then the usage is not collected at all, so the CPU, ephemeral storage and PVC thresholds are never satisfied.
+	varResourceCapacityUsageCollectionPeriod = "automaticapproval.resourcecapacity.usage.collection.period"
+
+	// defaultResourceCapacityUsageCollectionPeriod is the default value of varResourceCapacityUsageCollectionPeriod
+	defaultResourceCapacityUsageCollectionPeriod = "10m"
+)
+
+// cluster drain constants
+const (
+	// varClusterDrainMigrationMaxPoolSize specifies the maximum number of MasterUserRecords concurrently migrated
+	// from a single member cluster that is being drained
+	varClusterDrainMigrationMaxPoolSize = "cluster.drain.migration.max.poolsize"
+
+	// defaultClusterDrainMigrationMaxPoolSize is the default value of varClusterDrainMigrationMaxPoolSize
+	defaultClusterDrainMigrationMaxPoolSize = 5
 )
 
+// capacity headroom constants
+const (
+	// varCapacityHeadroomApprovalRateWindow specifies the period of time the recent approval rate is computed from
+	// when estimating the time remaining until the member clusters are full
+	varCapacityHeadroomApprovalRateWindow = "capacity.headroom.approval.rate.window"
+
+	// defaultCapacityHeadroomApprovalRateWindow is the default value of varCapacityHeadroomApprovalRateWindow
+	defaultCapacityHeadroomApprovalRateWindow = "24h"
+)
+
+// weekdays maps the abbreviated names of the days used in the schedule windows to the time.Weekday values
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ApprovalWindow is a recurring weekly period of time during which the automatic approval is active
+type ApprovalWindow struct {
+	// Weekdays contains the days the window starts on
+	Weekdays map[time.Weekday]bool
+	// From is the offset from the midnight the window starts at
+	From time.Duration
+	// To is the offset from the midnight the window ends at. If it is not after From, then the window ends on the next day.
+	To time.Duration
+}
+
+// ResourceThreshold is the capacity threshold (in percentage of usage) of a resource with optional overrides for particular node roles
+type ResourceThreshold struct {
+	// Default is the threshold used for the node roles without any override
+	Default int
+	// PerNodeRole contains the thresholds overriding the default one mapped by the node roles
+	PerNodeRole map[string]int
+}
+
+// ForNodeRole returns the threshold for the given node role. The value 0 means that the usage on the nodes with the role is not checked.
+func (t ResourceThreshold) ForNodeRole(role string) int {
+	if threshold, found := t.PerNodeRole[role]; found {
+		return threshold
+	}
+	return t.Default
+}
+
+// IsDefined returns true if the threshold is set for at least one node role
+func (t ResourceThreshold) IsDefined() bool {
+	if t.Default > 0 {
+		return true
+	}
+	for _, threshold := range t.PerNodeRole {
+		if threshold > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TierAssignmentRule maps a UserSignup attribute with the given value to the initial NSTemplateTier
+type TierAssignmentRule struct {
+	Attribute string
+	Value     string
+	TierName  string
+}
+
 // Config encapsulates the Viper configuration registry which stores the
 // configuration data in-memory.
 type Config struct {
 	host         *viper.Viper
 	secretValues map[string]string
+	// the email domain patterns are parsed (and the regular expressions compiled) only once, when the configuration is loaded
+	automaticApprovalDomainsAllowed []EmailDomainPattern
+	automaticApprovalDomainsDenied  []EmailDomainPattern
 }
 
 // initConfig creates an initial, empty configuration.
@@ -159,7 +517,14 @@ func LoadConfig(cl client.Client) (*Config, error) {
 		return nil, err
 	}
 
-	return initConfig(secret), nil
+	c := initConfig(secret)
+	if c.automaticApprovalDomainsAllowed, err = c.parseEmailDomainPatterns(varAutomaticApprovalDomainsAllowed); err != nil {
+		return nil, err
+	}
+	if c.automaticApprovalDomainsDenied, err = c.parseEmailDomainPatterns(varAutomaticApprovalDomainsDenied); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 func getHostEnvVarKey(key string) string {
@@ -190,6 +555,14 @@ func (c *Config) setConfigDefaults() {
 	c.host.SetDefault(varDurationBeforeNotificationDeletion, defaultDurationBeforeNotificationDeletion)
 	c.host.SetDefault(varEnvironment, defaultEnvironment)
 	c.host.SetDefault(varMasterUserRecordUpdateFailureThreshold, 2) // allow 1 failure, try again and then give up if failed again
+	c.host.SetDefault(varMasterUserRecordMigrationTimeout, defaultMasterUserRecordMigrationTimeout)
+	c.host.SetDefault(varMasterUserRecordResyncInterval, defaultMasterUserRecordResyncInterval)
+	c.host.SetDefault(varMasterUserRecordOrphanGracePeriod, defaultMasterUserRecordOrphanGracePeriod)
+	c.host.SetDefault(varUserAccountDeletionRequeueInitial, defaultUserAccountDeletionRequeueInitial)
+	c.host.SetDefault(varUserAccountDeletionRequeueMax, defaultUserAccountDeletionRequeueMax)
+	c.host.SetDefault(varUserAccountDeletionRequeueJitter, defaultUserAccountDeletionRequeueJitter)
+	c.host.SetDefault(varUserAccountDeletionTimeout, defaultUserAccountDeletionTimeout)
+	c.host.SetDefault(varUserAccountRecreationDelay, defaultUserAccountRecreationDelay)
 	c.host.SetDefault(varToolchainStatusRefreshTime, defaultToolchainStatusRefreshTime)
 	c.host.SetDefault(varForbiddenUsernamePrefixes, strings.FieldsFunc(DefaultForbiddenUsernamePrefixes, func(c rune) bool {
 		return c == ','
@@ -197,8 +570,23 @@ func (c *Config) setConfigDefaults() {
 	c.host.SetDefault(varForbiddenUsernameSuffixes, strings.FieldsFunc(DefaultForbiddenUsernameSuffixes, func(c rune) bool {
 		return c == ','
 	}))
+	c.host.SetDefault(varUsernameCollisionStrategy, UsernameCollisionStrategySequential)
+	c.host.SetDefault(varReservedUsernamesConfigMapName, defaultReservedUsernamesConfigMapName)
+	c.host.SetDefault(varReservedUsernamesCooldown, defaultReservedUsernamesCooldown)
 	c.host.SetDefault(varUserSignupUnverifiedRetentionDays, defaultUserSignupUnverifiedRetentionDays)
 	c.host.SetDefault(varUserSignupDeactivatedRetentionDays, defaultUserSignupDeactivatedRetentionDays)
+	c.host.SetDefault(varUserSignupReactivationVerificationAfter, "0s")
+	c.host.SetDefault(varUserSignupReactivationVerificationOnEmailDomainChange, false)
+	c.host.SetDefault(varUserSignupStateHistoryMaxLength, defaultUserSignupStateHistoryMaxLength)
+	c.host.SetDefault(varPlacementStrategy, PlacementStrategyFirstReady)
+	c.host.SetDefault(varPlacementRandomSeed, 0)
+	c.host.SetDefault(varDefaultTier, defaultDefaultTier)
+	c.host.SetDefault(varAutomaticApprovalBatchSize, defaultAutomaticApprovalBatchSize)
+	c.host.SetDefault(varAutomaticApprovalQueueRefreshPeriod, defaultAutomaticApprovalQueueRefreshPeriod)
+	c.host.SetDefault(varAutomaticApprovalScheduleTimezone, defaultAutomaticApprovalScheduleTimezone)
+	c.host.SetDefault(varClusterDrainMigrationMaxPoolSize, defaultClusterDrainMigrationMaxPoolSize)
+	c.host.SetDefault(varCapacityHeadroomApprovalRateWindow, defaultCapacityHeadroomApprovalRateWindow)
+	c.host.SetDefault(varResourceCapacityUsageCollectionPeriod, defaultResourceCapacityUsageCollectionPeriod)
 }
 
 // GetToolchainStatusName returns the configured name of the member status resource
@@ -226,6 +614,16 @@ func (c *Config) GetTemplateUpdateRequestMaxPoolSize() int {
 	return c.host.GetInt(varTemplateUpdateRequestMaxPoolSize)
 }
 
+// GetClusterDrainMigrationMaxPoolSize returns the maximum number of MasterUserRecords concurrently migrated from a single drained member cluster
+func (c *Config) GetClusterDrainMigrationMaxPoolSize() int {
+	return c.host.GetInt(varClusterDrainMigrationMaxPoolSize)
+}
+
+// GetCapacityHeadroomApprovalRateWindow returns the period of time the recent approval rate is computed from
+func (c *Config) GetCapacityHeadroomApprovalRateWindow() time.Duration {
+	return c.host.GetDuration(varCapacityHeadroomApprovalRateWindow)
+}
+
 // GetAdminEmail returns the email address for administrative notifications
 func (c *Config) GetAdminEmail() string {
 	return c.host.GetString(varAdminEmail)
@@ -266,6 +664,52 @@ func (c *Config) GetMasterUserRecordUpdateFailureThreshold() int {
 	return c.host.GetInt(varMasterUserRecordUpdateFailureThreshold)
 }
 
+// GetMasterUserRecordMigrationTimeout returns how long to wait for the UserAccount to become ready in the destination member cluster
+// before the migration of a MasterUserRecord is rolled back
+func (c *Config) GetMasterUserRecordMigrationTimeout() time.Duration {
+	return c.host.GetDuration(varMasterUserRecordMigrationTimeout)
+}
+
+// GetMasterUserRecordResyncInterval returns how often every MasterUserRecord is reconciled again to detect and repair
+// the drift of its UserAccounts in the member clusters (zero if the resync is disabled)
+func (c *Config) GetMasterUserRecordResyncInterval() time.Duration {
+	return c.host.GetDuration(varMasterUserRecordResyncInterval)
+}
+
+// GetMasterUserRecordOrphanGracePeriod returns how long a member cluster can be unavailable while a MasterUserRecord is being deleted
+// before the UserAccount in this member cluster is orphaned
+func (c *Config) GetMasterUserRecordOrphanGracePeriod() time.Duration {
+	return c.host.GetDuration(varMasterUserRecordOrphanGracePeriod)
+}
+
+// GetUserAccountDeletionRequeueInitial returns how long to wait before checking for the first time whether the deletion
+// of a UserAccount in a member cluster has completed
+func (c *Config) GetUserAccountDeletionRequeueInitial() time.Duration {
+	return c.host.GetDuration(varUserAccountDeletionRequeueInitial)
+}
+
+// GetUserAccountDeletionRequeueMax returns the longest time to wait between two checks of the deletion of a UserAccount in a member cluster
+func (c *Config) GetUserAccountDeletionRequeueMax() time.Duration {
+	return c.host.GetDuration(varUserAccountDeletionRequeueMax)
+}
+
+// GetUserAccountDeletionRequeueJitter returns the maximum factor of the random time added to the waiting time between two checks
+// of the deletion of a UserAccount in a member cluster
+func (c *Config) GetUserAccountDeletionRequeueJitter() float64 {
+	return c.host.GetFloat64(varUserAccountDeletionRequeueJitter)
+}
+
+// GetUserAccountDeletionTimeout returns how long the deletion of a UserAccount in a member cluster can take before it's considered as stuck
+func (c *Config) GetUserAccountDeletionTimeout() time.Duration {
+	return c.host.GetDuration(varUserAccountDeletionTimeout)
+}
+
+// GetUserAccountRecreationDelay returns how long to wait after the deletion timestamp of a UserAccount that was deleted in a member cluster
+// before it is created again
+func (c *Config) GetUserAccountRecreationDelay() time.Duration {
+	return c.host.GetDuration(varUserAccountRecreationDelay)
+}
+
 // GetToolchainStatusRefreshTime returns the time how often the ToolchainStatus should load and refresh the current hosted-toolchain status
 func (c *Config) GetToolchainStatusRefreshTime() time.Duration {
 	return c.host.GetDuration(varToolchainStatusRefreshTime)
@@ -302,6 +746,46 @@ func (c *Config) GetForbiddenUsernameSuffixes() []string {
 	return c.host.GetStringSlice(varForbiddenUsernameSuffixes)
 }
 
+// GetForbiddenUsernames returns the reserved names that a username may not be equal to
+func (c *Config) GetForbiddenUsernames() []string {
+	return splitAndTrim(c.host.GetString(varForbiddenUsernames))
+}
+
+// GetForbiddenUsernamePatterns returns the regular expressions that a username may not match.
+// Patterns that cannot be compiled are ignored.
+func (c *Config) GetForbiddenUsernamePatterns() []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, value := range splitAndTrim(c.host.GetString(varForbiddenUsernamePatterns)) {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			log.Error(err, "ignoring invalid forbidden username pattern", "value", value)
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// GetUsernameMaxLength returns the maximum length of a compliant username, or 0 if the length is not limited
+func (c *Config) GetUsernameMaxLength() int {
+	return c.host.GetInt(varUsernameMaxLength)
+}
+
+// GetUsernameCollisionStrategy returns the name of the strategy used for finding a vacant username
+func (c *Config) GetUsernameCollisionStrategy() string {
+	return c.host.GetString(varUsernameCollisionStrategy)
+}
+
+// GetReservedUsernamesConfigMapName returns the name of the ConfigMap containing the reserved usernames
+func (c *Config) GetReservedUsernamesConfigMapName() string {
+	return c.host.GetString(varReservedUsernamesConfigMapName)
+}
+
+// GetReservedUsernamesCooldown returns for how long the username of a deleted UserSignup is reserved
+func (c *Config) GetReservedUsernamesCooldown() time.Duration {
+	return c.host.GetDuration(varReservedUsernamesCooldown)
+}
+
 func (c *Config) GetUserSignupUnverifiedRetentionDays() int {
 	return c.host.GetInt(varUserSignupUnverifiedRetentionDays)
 }
@@ -309,3 +793,348 @@ func (c *Config) GetUserSignupUnverifiedRetentionDays() int {
 func (c *Config) GetUserSignupDeactivatedRetentionDays() int {
 	return c.host.GetInt(varUserSignupDeactivatedRetentionDays)
 }
+
+// GetUserSignupReactivationVerificationAfter returns how long a UserSignup has to be deactivated so the user is required to go
+// through the verification again when reactivated. The value 0 means that the period of the deactivation is not checked.
+func (c *Config) GetUserSignupReactivationVerificationAfter() time.Duration {
+	return c.host.GetDuration(varUserSignupReactivationVerificationAfter)
+}
+
+// GetUserSignupReactivationVerificationOnEmailDomainChange returns true if the user is required to go through the verification again
+// when reactivated with an email address from a different domain than the one the user had when deactivated
+func (c *Config) GetUserSignupReactivationVerificationOnEmailDomainChange() bool {
+	return c.host.GetBool(varUserSignupReactivationVerificationOnEmailDomainChange)
+}
+
+// GetUserSignupStateHistoryMaxLength returns how many transitions of the state label are kept in the history of a UserSignup
+func (c *Config) GetUserSignupStateHistoryMaxLength() int {
+	return c.host.GetInt(varUserSignupStateHistoryMaxLength)
+}
+
+// GetPlacementStrategy returns the name of the strategy used for selecting the member cluster a new user is provisioned to.
+// The value is loaded when the operator starts, it can't be changed at runtime.
+func (c *Config) GetPlacementStrategy() string {
+	return c.host.GetString(varPlacementStrategy)
+}
+
+// GetPlacementWeights returns the weights of the member clusters (mapped by the cluster names) used by the weighted-round-robin strategy.
+// Entries that cannot be parsed are ignored.
+func (c *Config) GetPlacementWeights() map[string]int {
+	return c.getValuesPerName(varPlacementWeights, "placement weight")
+}
+
+// getValuesPerName parses the comma-separated name=value pairs (eg. cluster-name=value or node-role=value) stored under the given key.
+// Entries that cannot be parsed or that contain negative values are ignored.
+func (c *Config) getValuesPerName(key, description string) map[string]int {
+	values := map[string]int{}
+	for _, pair := range strings.FieldsFunc(c.host.GetString(key), func(c rune) bool {
+		return c == ','
+	}) {
+		nameAndValue := strings.SplitN(pair, "=", 2)
+		if len(nameAndValue) != 2 {
+			log.Info("ignoring invalid "+description, "value", pair)
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(nameAndValue[1]))
+		if err != nil || value < 0 {
+			log.Info("ignoring invalid "+description, "value", pair)
+			continue
+		}
+		values[strings.TrimSpace(nameAndValue[0])] = value
+	}
+	return values
+}
+
+// GetPlacementRandomSeed returns the seed used by the random placement strategy
+func (c *Config) GetPlacementRandomSeed() int64 {
+	return c.host.GetInt64(varPlacementRandomSeed)
+}
+
+// GetDefaultTier returns the name of the NSTemplateTier a new user is provisioned with when none of the tier assignment rules matches
+func (c *Config) GetDefaultTier() string {
+	return c.host.GetString(varDefaultTier)
+}
+
+// GetTierAssignmentRules returns the ordered list of rules used for selecting the initial NSTemplateTier of a new user.
+// Rules that cannot be parsed are ignored.
+func (c *Config) GetTierAssignmentRules() []TierAssignmentRule {
+	var rules []TierAssignmentRule
+	for _, rule := range strings.FieldsFunc(c.host.GetString(varTierAssignmentRules), func(c rune) bool {
+		return c == ','
+	}) {
+		attributeIndex := strings.Index(rule, ":")
+		tierIndex := strings.LastIndex(rule, ":")
+		if attributeIndex <= 0 || tierIndex == attributeIndex || tierIndex == len(rule)-1 {
+			log.Info("ignoring invalid tier assignment rule", "value", rule)
+			continue
+		}
+		rules = append(rules, TierAssignmentRule{
+			Attribute: strings.TrimSpace(rule[:attributeIndex]),
+			Value:     strings.TrimSpace(rule[attributeIndex+1 : tierIndex]),
+			TierName:  strings.TrimSpace(rule[tierIndex+1:]),
+		})
+	}
+	return rules
+}
+
+// GetTierWeights returns the explicitly configured weights of the tiers (mapped by the tier names)
+func (c *Config) GetTierWeights() map[string]int {
+	return c.getValuesPerName(varTierWeights, "tier weight")
+}
+
+// GetTierWeightMemoryUnit returns the amount of memory that corresponds to the weight 1 when deriving the weight of a tier
+// from its ClusterResourceQuotas. Returns false as the second value if it is not configured or it cannot be parsed.
+func (c *Config) GetTierWeightMemoryUnit() (resource.Quantity, bool) {
+	value := strings.TrimSpace(c.host.GetString(varTierWeightMemoryUnit))
+	if value == "" {
+		return resource.Quantity{}, false
+	}
+	unit, err := resource.ParseQuantity(value)
+	if err != nil || unit.Sign() <= 0 {
+		log.Info("ignoring invalid tier weight memory unit", "value", value)
+		return resource.Quantity{}, false
+	}
+	return unit, true
+}
+
+// GetUserAccountsPerTier returns the number of UserAccounts (mapped by the tier names) the users of the tiers should have
+func (c *Config) GetUserAccountsPerTier() map[string]int {
+	return c.getValuesPerName(varTierUserAccounts, "number of UserAccounts per tier")
+}
+
+// GetUserAccountsTopologyKey returns the key of the ToolchainCluster label whose value has to be different for all member clusters
+// a user with several UserAccounts is provisioned to
+func (c *Config) GetUserAccountsTopologyKey() string {
+	return c.host.GetString(varUserAccountsTopologyKey)
+}
+
+// GetAutomaticApprovalDomainsAllowed returns the email domain patterns of users who should be approved automatically
+// even if the automatic approval is disabled
+func (c *Config) GetAutomaticApprovalDomainsAllowed() []EmailDomainPattern {
+	return c.automaticApprovalDomainsAllowed
+}
+
+// GetAutomaticApprovalDomainsDenied returns the email domain patterns of users who should never be approved automatically
+func (c *Config) GetAutomaticApprovalDomainsDenied() []EmailDomainPattern {
+	return c.automaticApprovalDomainsDenied
+}
+
+// EmailDomainPattern is a pattern matching the domain of an email address. It is either an exact domain ("redhat.com"),
+// a wildcard matching any subdomain ("*.redhat.com") or a regular expression enclosed in slashes ("/^(eu|us)\.redhat\.com$/").
+type EmailDomainPattern struct {
+	// Value is the pattern as it was configured
+	Value string
+	// Regexp is the compiled regular expression if the pattern is a regular expression, otherwise it is nil
+	Regexp *regexp.Regexp
+}
+
+// ParseEmailDomainPattern parses the given email domain pattern. It returns an error if the pattern is a regular expression
+// that cannot be compiled.
+func ParseEmailDomainPattern(value string) (EmailDomainPattern, error) {
+	pattern := EmailDomainPattern{Value: value}
+	if len(value) > 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		exp, err := regexp.Compile(value[1 : len(value)-1])
+		if err != nil {
+			return EmailDomainPattern{}, err
+		}
+		pattern.Regexp = exp
+	}
+	return pattern, nil
+}
+
+// parseEmailDomainPatterns parses the comma-separated email domain patterns stored under the given key
+func (c *Config) parseEmailDomainPatterns(key string) ([]EmailDomainPattern, error) {
+	var patterns []EmailDomainPattern
+	for _, value := range splitAndTrim(c.host.GetString(key)) {
+		pattern, err := ParseEmailDomainPattern(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email domain pattern '%s' in %s: %s", value, getHostEnvVarKey(key), err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// GetAutomaticApprovalBatchSize returns how many of the oldest UserSignups pending approval should be reconciled when the ToolchainStatus changes
+func (c *Config) GetAutomaticApprovalBatchSize() int {
+	return c.host.GetInt(varAutomaticApprovalBatchSize)
+}
+
+// GetAutomaticApprovalQueueRefreshPeriod returns how often all the UserSignups pending approval are reconciled to refresh their position
+// in the approval queue, or 0 if they shouldn't be reconciled periodically
+func (c *Config) GetAutomaticApprovalQueueRefreshPeriod() time.Duration {
+	return c.host.GetDuration(varAutomaticApprovalQueueRefreshPeriod)
+}
+
+// GetAutomaticApprovalSchedule returns the windows during which the automatic approval is active.
+// Windows that cannot be parsed are ignored.
+func (c *Config) GetAutomaticApprovalSchedule() []ApprovalWindow {
+	var windows []ApprovalWindow
+	for _, value := range splitAndTrim(c.host.GetString(varAutomaticApprovalSchedule)) {
+		window, err := parseApprovalWindow(value)
+		if err != nil {
+			log.Info("ignoring invalid automatic approval schedule window", "value", value, "reason", err.Error())
+			continue
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// GetAutomaticApprovalScheduleLocation returns the time zone the schedule windows are defined in.
+// If the configured time zone cannot be loaded, then UTC is returned.
+func (c *Config) GetAutomaticApprovalScheduleLocation() *time.Location {
+	location, err := time.LoadLocation(c.host.GetString(varAutomaticApprovalScheduleTimezone))
+	if err != nil {
+		log.Error(err, "unable to load the time zone of the automatic approval schedule, using UTC")
+		return time.UTC
+	}
+	return location
+}
+
+// GetAutomaticApprovalScheduleMaxApprovals returns the maximum number of users approved automatically during a single window
+func (c *Config) GetAutomaticApprovalScheduleMaxApprovals() int {
+	return c.host.GetInt(varAutomaticApprovalScheduleMaxApprovals)
+}
+
+// GetAutomaticApprovalBudgetHourly returns the maximum number of users approved automatically per hour
+func (c *Config) GetAutomaticApprovalBudgetHourly() int {
+	return c.host.GetInt(varAutomaticApprovalBudgetHourly)
+}
+
+// GetAutomaticApprovalBudgetDaily returns the maximum number of users approved automatically per day
+func (c *Config) GetAutomaticApprovalBudgetDaily() int {
+	return c.host.GetInt(varAutomaticApprovalBudgetDaily)
+}
+
+// GetAutomaticApprovalBudgetPerMemberCluster returns the maximum number of users approved automatically per hour mapped by the member cluster names.
+// Entries that cannot be parsed are ignored.
+func (c *Config) GetAutomaticApprovalBudgetPerMemberCluster() map[string]int {
+	return c.getValuesPerName(varAutomaticApprovalBudgetPerMemberCluster, "automatic approval budget")
+}
+
+// GetResourceCapacityIgnoredNodeRoles returns the node roles that are ignored by the resource capacity checks
+func (c *Config) GetResourceCapacityIgnoredNodeRoles() []string {
+	return splitAndTrim(c.host.GetString(varResourceCapacityIgnoredNodeRoles))
+}
+
+// GetMemoryCapacityThresholdPerNodeRole returns the memory usage thresholds overriding the ones defined in HostOperatorConfig mapped by the node roles.
+// Entries that cannot be parsed are ignored.
+func (c *Config) GetMemoryCapacityThresholdPerNodeRole() map[string]int {
+	return c.getValuesPerName(varResourceCapacityMemoryThresholdPerNodeRole, "memory capacity threshold")
+}
+
+// GetCPUCapacityThreshold returns the maximum percentage of the allocatable CPU that can be requested by the pods
+func (c *Config) GetCPUCapacityThreshold() ResourceThreshold {
+	return ResourceThreshold{
+		Default:     c.host.GetInt(varResourceCapacityCPUThreshold),
+		PerNodeRole: c.getValuesPerName(varResourceCapacityCPUThresholdPerNodeRole, "CPU capacity threshold"),
+	}
+}
+
+// GetEphemeralStorageCapacityThreshold returns the maximum percentage of the allocatable ephemeral storage that can be requested by the pods
+func (c *Config) GetEphemeralStorageCapacityThreshold() ResourceThreshold {
+	return ResourceThreshold{
+		Default:     c.host.GetInt(varResourceCapacityEphemeralStorageThreshold),
+		PerNodeRole: c.getValuesPerName(varResourceCapacityEphemeralStorageThresholdPerNodeRole, "ephemeral storage capacity threshold"),
+	}
+}
+
+// GetPVCCapacityThreshold returns the maximum percentage of the storage capacity of a member cluster that can be requested by the PersistentVolumeClaims
+func (c *Config) GetPVCCapacityThreshold() int {
+	return c.host.GetInt(varResourceCapacityPVCThreshold)
+}
+
+// GetPVCStoragePerMemberCluster returns the storage capacity available for the PersistentVolumeClaims mapped by the member cluster names.
+// Entries that cannot be parsed are ignored.
+func (c *Config) GetPVCStoragePerMemberCluster() map[string]resource.Quantity {
+	values := map[string]resource.Quantity{}
+	for _, pair := range splitAndTrim(c.host.GetString(varResourceCapacityPVCStoragePerMemberCluster)) {
+		nameAndValue := strings.SplitN(pair, "=", 2)
+		if len(nameAndValue) != 2 {
+			log.Info("ignoring invalid PVC storage capacity", "value", pair)
+			continue
+		}
+		value, err := resource.ParseQuantity(strings.TrimSpace(nameAndValue[1]))
+		if err != nil || value.Sign() <= 0 {
+			log.Info("ignoring invalid PVC storage capacity", "value", pair)
+			continue
+		}
+		values[strings.TrimSpace(nameAndValue[0])] = value
+	}
+	return values
+}
+
+// GetResourceCapacityUsageCollectionPeriod returns how often the usage of the CPU and storage resources is collected from the member clusters
+func (c *Config) GetResourceCapacityUsageCollectionPeriod() time.Duration {
+	return c.host.GetDuration(varResourceCapacityUsageCollectionPeriod)
+}
+
+func parseApprovalWindow(value string) (ApprovalWindow, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return ApprovalWindow{}, fmt.Errorf("expected '<weekdays> <from>-<to>'")
+	}
+	days, err := parseWeekdays(fields[0])
+	if err != nil {
+		return ApprovalWindow{}, err
+	}
+	fromAndTo := strings.SplitN(fields[1], "-", 2)
+	if len(fromAndTo) != 2 {
+		return ApprovalWindow{}, fmt.Errorf("expected '<from>-<to>' time range")
+	}
+	from, err := parseTimeOfDay(fromAndTo[0])
+	if err != nil {
+		return ApprovalWindow{}, err
+	}
+	to, err := parseTimeOfDay(fromAndTo[1])
+	if err != nil {
+		return ApprovalWindow{}, err
+	}
+	return ApprovalWindow{Weekdays: days, From: from, To: to}, nil
+}
+
+func parseWeekdays(value string) (map[time.Weekday]bool, error) {
+	days := map[time.Weekday]bool{}
+	if value == "*" {
+		for _, day := range weekdays {
+			days[day] = true
+		}
+		return days, nil
+	}
+	firstAndLast := strings.SplitN(strings.ToLower(value), "-", 2)
+	first, found := weekdays[firstAndLast[0]]
+	if !found {
+		return nil, fmt.Errorf("unknown weekday '%s'", firstAndLast[0])
+	}
+	last := first
+	if len(firstAndLast) == 2 {
+		if last, found = weekdays[firstAndLast[1]]; !found {
+			return nil, fmt.Errorf("unknown weekday '%s'", firstAndLast[1])
+		}
+	}
+	for day := first; ; day = (day + 1) % 7 {
+		days[day] = true
+		if day == last {
+			return days, nil
+		}
+	}
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of the day '%s'", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func splitAndTrim(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
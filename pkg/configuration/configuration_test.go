@@ -3,6 +3,7 @@ package configuration_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
@@ -310,3 +311,465 @@ func TestForbiddenUsernameSuffixesHaveCorrectDefaults(t *testing.T) {
 	require.Len(t, config.GetForbiddenUsernameSuffixes(), 1)
 	require.Contains(t, config.GetForbiddenUsernameSuffixes(), "admin")
 }
+
+func TestGetPlacementStrategy(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.PlacementStrategyFirstReady, config.GetPlacementStrategy())
+		assert.Empty(t, config.GetPlacementWeights())
+		assert.Equal(t, int64(0), config.GetPlacementRandomSeed())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_PLACEMENT_STRATEGY", configuration.PlacementStrategyWeightedRoundRobin),
+			test.Env("HOST_OPERATOR_PLACEMENT_WEIGHTS", "member-1=3, member-2 = 1,member-3,member-4=abc,member-5=0"),
+			test.Env("HOST_OPERATOR_PLACEMENT_RANDOM_SEED", "42"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.PlacementStrategyWeightedRoundRobin, config.GetPlacementStrategy())
+		assert.Equal(t, map[string]int{"member-1": 3, "member-2": 1, "member-5": 0}, config.GetPlacementWeights())
+		assert.Equal(t, int64(42), config.GetPlacementRandomSeed())
+	})
+}
+
+func TestGetTierAssignmentRules(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, "base", config.GetDefaultTier())
+		assert.Empty(t, config.GetTierAssignmentRules())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_TIER_DEFAULT", "basic"),
+			test.Env("HOST_OPERATOR_TIER_ASSIGNMENT_RULES", "emailDomain:redhat.com:team, label:toolchain.dev.openshift.com/partner=true:advanced,invalid,company:ACME:,approval:admin:advanced"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, "basic", config.GetDefaultTier())
+		assert.Equal(t, []configuration.TierAssignmentRule{
+			{Attribute: configuration.TierAssignmentAttributeEmailDomain, Value: "redhat.com", TierName: "team"},
+			{Attribute: configuration.TierAssignmentAttributeLabel, Value: "toolchain.dev.openshift.com/partner=true", TierName: "advanced"},
+			{Attribute: configuration.TierAssignmentAttributeApproval, Value: "admin", TierName: "advanced"},
+		}, config.GetTierAssignmentRules())
+	})
+}
+
+func TestGetTierWeights(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Empty(t, config.GetTierWeights())
+		_, found := config.GetTierWeightMemoryUnit()
+		assert.False(t, found)
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_TIER_WEIGHTS", "base=1, advanced=2,team=x"),
+			test.Env("HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "7Gi"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, map[string]int{"base": 1, "advanced": 2}, config.GetTierWeights())
+		unit, found := config.GetTierWeightMemoryUnit()
+		assert.True(t, found)
+		assert.Equal(t, "7Gi", unit.String())
+	})
+
+	t.Run("invalid memory unit", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "-1Gi")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		_, found := config.GetTierWeightMemoryUnit()
+		assert.False(t, found)
+	})
+}
+
+func TestGetUserAccountsPerTier(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Empty(t, config.GetUserAccountsPerTier())
+		assert.Empty(t, config.GetUserAccountsTopologyKey())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_TIER_USER_ACCOUNTS", "base=1, ha=2,invalid"),
+			test.Env("HOST_OPERATOR_USER_ACCOUNTS_TOPOLOGY_KEY", "region"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, map[string]int{"base": 1, "ha": 2}, config.GetUserAccountsPerTier())
+		assert.Equal(t, "region", config.GetUserAccountsTopologyKey())
+	})
+}
+
+func TestGetAutomaticApprovalDomains(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Empty(t, config.GetAutomaticApprovalDomainsAllowed())
+		assert.Empty(t, config.GetAutomaticApprovalDomainsDenied())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_ALLOWED", "redhat.com, *.ibm.com,"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_DENIED", "/^mailinator\\.(com|net)$/"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		allowed := config.GetAutomaticApprovalDomainsAllowed()
+		require.Len(t, allowed, 2)
+		assert.Equal(t, "redhat.com", allowed[0].Value)
+		assert.Nil(t, allowed[0].Regexp)
+		assert.Equal(t, "*.ibm.com", allowed[1].Value)
+		assert.Nil(t, allowed[1].Regexp)
+		denied := config.GetAutomaticApprovalDomainsDenied()
+		require.Len(t, denied, 1)
+		assert.Equal(t, "/^mailinator\\.(com|net)$/", denied[0].Value)
+		require.NotNil(t, denied[0].Regexp)
+		assert.Equal(t, "^mailinator\\.(com|net)$", denied[0].Regexp.String())
+	})
+
+	t.Run("invalid regular expression is rejected", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_DENIED", "mailinator.com,/^mailinator\\.(com$/")
+		defer restore()
+
+		_, err := configuration.LoadConfig(test.NewFakeClient(t))
+
+		require.EqualError(t, err, "invalid email domain pattern '/^mailinator\\.(com$/' in HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_DENIED: error parsing regexp: missing closing ): `^mailinator\\.(com$`")
+	})
+}
+
+func TestGetAutomaticApprovalBatchSize(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 1, config.GetAutomaticApprovalBatchSize())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_BATCH_SIZE", "5")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 5, config.GetAutomaticApprovalBatchSize())
+	})
+}
+
+func TestGetAutomaticApprovalQueueRefreshPeriod(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 5*time.Minute, config.GetAutomaticApprovalQueueRefreshPeriod())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_QUEUE_REFRESH_PERIOD", "30s")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 30*time.Second, config.GetAutomaticApprovalQueueRefreshPeriod())
+	})
+}
+
+func TestGetAutomaticApprovalSchedule(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Empty(t, config.GetAutomaticApprovalSchedule())
+		assert.Equal(t, time.UTC, config.GetAutomaticApprovalScheduleLocation())
+		assert.Equal(t, 0, config.GetAutomaticApprovalScheduleMaxApprovals())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_WINDOWS", "Mon-Wed 08:00-18:30, Sat 22:00-02:00,Fri-Mon 10:00-11:00,* 00:00-00:00,Funday 10:00-11:00,Mon 25:00-26:00,Tue"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_TIMEZONE", "Europe/Prague"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_MAXAPPROVALS", "100"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, []configuration.ApprovalWindow{
+			{
+				Weekdays: map[time.Weekday]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true},
+				From:     8 * time.Hour,
+				To:       18*time.Hour + 30*time.Minute,
+			},
+			{
+				Weekdays: map[time.Weekday]bool{time.Saturday: true},
+				From:     22 * time.Hour,
+				To:       2 * time.Hour,
+			},
+			{
+				Weekdays: map[time.Weekday]bool{time.Friday: true, time.Saturday: true, time.Sunday: true, time.Monday: true},
+				From:     10 * time.Hour,
+				To:       11 * time.Hour,
+			},
+			{
+				Weekdays: map[time.Weekday]bool{time.Sunday: true, time.Monday: true, time.Tuesday: true, time.Wednesday: true,
+					time.Thursday: true, time.Friday: true, time.Saturday: true},
+			},
+		}, config.GetAutomaticApprovalSchedule())
+		assert.Equal(t, "Europe/Prague", config.GetAutomaticApprovalScheduleLocation().String())
+		assert.Equal(t, 100, config.GetAutomaticApprovalScheduleMaxApprovals())
+	})
+
+	t.Run("invalid time zone", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_TIMEZONE", "Mars/Olympus")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, time.UTC, config.GetAutomaticApprovalScheduleLocation())
+	})
+}
+
+func TestGetAutomaticApprovalBudget(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 0, config.GetAutomaticApprovalBudgetHourly())
+		assert.Equal(t, 0, config.GetAutomaticApprovalBudgetDaily())
+		assert.Empty(t, config.GetAutomaticApprovalBudgetPerMemberCluster())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "50"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_DAILY", "500"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_PERMEMBERCLUSTER", "member-1=20, member-2=5,member-3=-1,member-4"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 50, config.GetAutomaticApprovalBudgetHourly())
+		assert.Equal(t, 500, config.GetAutomaticApprovalBudgetDaily())
+		assert.Equal(t, map[string]int{"member-1": 20, "member-2": 5}, config.GetAutomaticApprovalBudgetPerMemberCluster())
+	})
+}
+
+func TestGetUsernamePolicy(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Empty(t, config.GetForbiddenUsernames())
+		assert.Empty(t, config.GetForbiddenUsernamePatterns())
+		assert.Equal(t, 0, config.GetUsernameMaxLength())
+		assert.Equal(t, configuration.UsernameCollisionStrategySequential, config.GetUsernameCollisionStrategy())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_USERNAME_FORBIDDEN_NAMES", "root, operator"),
+			test.Env("HOST_OPERATOR_USERNAME_FORBIDDEN_PATTERNS", "^test[0-9]+$,[invalid"),
+			test.Env("HOST_OPERATOR_USERNAME_MAX_LENGTH", "30"),
+			test.Env("HOST_OPERATOR_USERNAME_COLLISION_STRATEGY", configuration.UsernameCollisionStrategyHashed))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, []string{"root", "operator"}, config.GetForbiddenUsernames())
+		patterns := config.GetForbiddenUsernamePatterns()
+		require.Len(t, patterns, 1)
+		assert.Equal(t, "^test[0-9]+$", patterns[0].String())
+		assert.Equal(t, 30, config.GetUsernameMaxLength())
+		assert.Equal(t, configuration.UsernameCollisionStrategyHashed, config.GetUsernameCollisionStrategy())
+	})
+}
+
+func TestGetReservedUsernames(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, "reserved-usernames", config.GetReservedUsernamesConfigMapName())
+		assert.Equal(t, time.Duration(0), config.GetReservedUsernamesCooldown())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_USERNAME_RESERVED_CONFIGMAP", "parked-usernames"),
+			test.Env("HOST_OPERATOR_USERNAME_RESERVED_COOLDOWN", "24h"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, "parked-usernames", config.GetReservedUsernamesConfigMapName())
+		assert.Equal(t, 24*time.Hour, config.GetReservedUsernamesCooldown())
+	})
+}
+
+func TestGetResourceCapacity(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Empty(t, config.GetResourceCapacityIgnoredNodeRoles())
+		assert.Empty(t, config.GetMemoryCapacityThresholdPerNodeRole())
+		assert.False(t, config.GetCPUCapacityThreshold().IsDefined())
+		assert.False(t, config.GetEphemeralStorageCapacityThreshold().IsDefined())
+		assert.Equal(t, 0, config.GetPVCCapacityThreshold())
+		assert.Empty(t, config.GetPVCStoragePerMemberCluster())
+		assert.Equal(t, 10*time.Minute, config.GetResourceCapacityUsageCollectionPeriod())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_IGNOREDNODEROLES", "master, infra"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_MEMORY_PERNODEROLE", "worker=75"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_CPU_THRESHOLD", "80"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_CPU_PERNODEROLE", "worker=70,gpu=0"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_EPHEMERALSTORAGE_PERNODEROLE", "worker=90"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_PVC_THRESHOLD", "85"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_PVC_STORAGE", "member-1=10Ti,member-2=invalid,member-3=-1Gi"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_USAGE_COLLECTION_PERIOD", "30m"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, []string{"master", "infra"}, config.GetResourceCapacityIgnoredNodeRoles())
+		assert.Equal(t, map[string]int{"worker": 75}, config.GetMemoryCapacityThresholdPerNodeRole())
+		cpu := config.GetCPUCapacityThreshold()
+		assert.True(t, cpu.IsDefined())
+		assert.Equal(t, 70, cpu.ForNodeRole("worker"))
+		assert.Equal(t, 0, cpu.ForNodeRole("gpu"))
+		assert.Equal(t, 80, cpu.ForNodeRole("master"))
+		ephemeralStorage := config.GetEphemeralStorageCapacityThreshold()
+		assert.True(t, ephemeralStorage.IsDefined())
+		assert.Equal(t, 90, ephemeralStorage.ForNodeRole("worker"))
+		assert.Equal(t, 0, ephemeralStorage.ForNodeRole("master"))
+		assert.Equal(t, 85, config.GetPVCCapacityThreshold())
+		storage := config.GetPVCStoragePerMemberCluster()
+		require.Len(t, storage, 1)
+		member1Storage := storage["member-1"]
+		assert.Equal(t, "10Ti", member1Storage.String())
+		assert.Equal(t, 30*time.Minute, config.GetResourceCapacityUsageCollectionPeriod())
+	})
+}
+
+func TestGetClusterDrainMigrationMaxPoolSize(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 5, config.GetClusterDrainMigrationMaxPoolSize())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_CLUSTER_DRAIN_MIGRATION_MAX_POOLSIZE", "2")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 2, config.GetClusterDrainMigrationMaxPoolSize())
+	})
+}
+
+func TestGetCapacityHeadroomApprovalRateWindow(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 24*time.Hour, config.GetCapacityHeadroomApprovalRateWindow())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_CAPACITY_HEADROOM_APPROVAL_RATE_WINDOW", "6h")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 6*time.Hour, config.GetCapacityHeadroomApprovalRateWindow())
+	})
+}
+
+func TestGetUserSignupReactivationVerification(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, time.Duration(0), config.GetUserSignupReactivationVerificationAfter())
+		assert.False(t, config.GetUserSignupReactivationVerificationOnEmailDomainChange())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_USERSIGNUP_REACTIVATION_VERIFICATION_AFTER", "720h"),
+			test.Env("HOST_OPERATOR_USERSIGNUP_REACTIVATION_VERIFICATION_EMAILDOMAINCHANGE", "true"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 720*time.Hour, config.GetUserSignupReactivationVerificationAfter())
+		assert.True(t, config.GetUserSignupReactivationVerificationOnEmailDomainChange())
+	})
+}
+
+func TestGetUserSignupStateHistoryMaxLength(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 10, config.GetUserSignupStateHistoryMaxLength())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_USERSIGNUP_STATEHISTORY_MAXLENGTH", "3")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 3, config.GetUserSignupStateHistoryMaxLength())
+	})
+}
+
+func TestGetMasterUserRecordMigrationTimeout(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 10*time.Minute, config.GetMasterUserRecordMigrationTimeout())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_MASTERUSERRECORD_MIGRATION_TIMEOUT", "30s")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 30*time.Second, config.GetMasterUserRecordMigrationTimeout())
+	})
+}
+
+func TestGetMasterUserRecordResyncInterval(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, time.Hour, config.GetMasterUserRecordResyncInterval())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_MASTERUSERRECORD_RESYNC_INTERVAL", "10m")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 10*time.Minute, config.GetMasterUserRecordResyncInterval())
+	})
+}
+
+func TestGetMasterUserRecordOrphanGracePeriod(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 24*time.Hour, config.GetMasterUserRecordOrphanGracePeriod())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_MASTERUSERRECORD_ORPHAN_GRACEPERIOD", "72h")
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 72*time.Hour, config.GetMasterUserRecordOrphanGracePeriod())
+	})
+}
+
+func TestGetUserAccountDeletionSettings(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 10*time.Second, config.GetUserAccountDeletionRequeueInitial())
+		assert.Equal(t, 5*time.Minute, config.GetUserAccountDeletionRequeueMax())
+		assert.Equal(t, 0.1, config.GetUserAccountDeletionRequeueJitter())
+		assert.Equal(t, time.Minute, config.GetUserAccountDeletionTimeout())
+		assert.Equal(t, 3*time.Second, config.GetUserAccountRecreationDelay())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_USERACCOUNT_DELETION_REQUEUE_INITIAL", "5s"),
+			test.Env("HOST_OPERATOR_USERACCOUNT_DELETION_REQUEUE_MAX", "1m"),
+			test.Env("HOST_OPERATOR_USERACCOUNT_DELETION_REQUEUE_JITTER", "0.5"),
+			test.Env("HOST_OPERATOR_USERACCOUNT_DELETION_TIMEOUT", "10m"),
+			test.Env("HOST_OPERATOR_USERACCOUNT_RECREATION_DELAY", "1s"))
+		defer restore()
+
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, 5*time.Second, config.GetUserAccountDeletionRequeueInitial())
+		assert.Equal(t, time.Minute, config.GetUserAccountDeletionRequeueMax())
+		assert.Equal(t, 0.5, config.GetUserAccountDeletionRequeueJitter())
+		assert.Equal(t, 10*time.Minute, config.GetUserAccountDeletionTimeout())
+		assert.Equal(t, time.Second, config.GetUserAccountRecreationDelay())
+	})
+}
@@ -0,0 +1,154 @@
+package clusterdrain
+
+import (
+	"context"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_clusterdrain")
+
+// Add creates a new ClusterDrain Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, config *configuration.Config) error {
+	return add(mgr, newReconciler(mgr, config))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, config *configuration.Config) reconcile.Reconciler {
+	return &ReconcileClusterDrain{
+		client: mgr.GetClient(),
+		config: config,
+	}
+}
+
+// ----------------------------------------------------------------------------------------------------------------------------
+// ClusterDrain Controller Reconciler:
+// . after a ToolchainCluster labeled with `drain=migrate` was created/updated
+// .. labels the first MasterUserRecord with a UserAccount in the drained member cluster with `migration-source=<cluster-name>`,
+// so the UserSignup controller moves the UserAccount to another member cluster and the MasterUserRecord controller
+// deletes the UserAccount from the drained member cluster and removes the label afterwards
+// . after a MasterUserRecord labeled with `migration-source` was created/updated
+// .. labels subsequent MasterUserRecords until the `MaxPoolSize` threshold is reached or all of them were migrated
+// ----------------------------------------------------------------------------------------------------------------------------
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("clusterdrain-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource ToolchainCluster
+	if err := c.Watch(&source.Kind{Type: &toolchainv1alpha1.ToolchainCluster{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+
+	// Watch for changes of the MasterUserRecords being migrated and requeue the ToolchainCluster they are migrated from
+	return c.Watch(&source.Kind{Type: &toolchainv1alpha1.MasterUserRecord{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(mapMasterUserRecordToToolchainCluster),
+	})
+}
+
+// mapMasterUserRecordToToolchainCluster maps a MasterUserRecord being migrated to the ToolchainCluster of the member cluster it is migrated from
+func mapMasterUserRecordToToolchainCluster(obj handler.MapObject) []reconcile.Request {
+	clusterName, found := obj.Meta.GetLabels()[MigrationSourceLabelKey]
+	if !found {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Namespace: obj.Meta.GetNamespace(), Name: clusterName},
+	}}
+}
+
+// blank assignment to verify that ReconcileClusterDrain implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileClusterDrain{}
+
+// ReconcileClusterDrain migrates the users off the member clusters being drained
+type ReconcileClusterDrain struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	config *configuration.Config
+}
+
+// Reconcile labels the MasterUserRecords with a UserAccount in a member cluster that is drained with the `migrate` mode,
+// so they are migrated to other member clusters. The number of concurrently migrated MasterUserRecords is limited by
+// the `cluster.drain.migration.max.poolsize` configuration.
+func (r *ReconcileClusterDrain) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	// fetch the ToolchainCluster
+	toolchainCluster := &toolchainv1alpha1.ToolchainCluster{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, toolchainCluster); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("ToolchainCluster not found")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errs.Wrap(err, "unable to get the current ToolchainCluster")
+	}
+	if toolchainCluster.Labels[DrainLabelKey] != DrainModeMigrate {
+		return reconcile.Result{}, nil
+	}
+
+	done, err := r.ensureMigration(logger, toolchainCluster)
+	if err != nil {
+		logger.Error(err, "unable to migrate the users off the drained member cluster")
+		return reconcile.Result{}, err
+	}
+	if done {
+		logger.Info("all users were migrated off the drained member cluster")
+	}
+	return reconcile.Result{}, nil
+}
+
+// ensureMigration labels the first MasterUserRecord that still has a UserAccount in the drained member cluster and is not being migrated yet,
+// unless the number of MasterUserRecords being migrated from the member cluster has reached the `MaxPoolSize` threshold.
+// The label update triggers another reconcile loop, so subsequent MasterUserRecords are labeled until the threshold is reached (returns `false, nil`)
+// or there is no other MasterUserRecord to migrate (returns `true, nil`).
+func (r *ReconcileClusterDrain) ensureMigration(logger logr.Logger, toolchainCluster *toolchainv1alpha1.ToolchainCluster) (bool, error) {
+	migrating := &toolchainv1alpha1.MasterUserRecordList{}
+	if err := r.client.List(context.TODO(), migrating, client.InNamespace(toolchainCluster.Namespace),
+		client.MatchingLabels{MigrationSourceLabelKey: toolchainCluster.Name}); err != nil {
+		return false, errs.Wrap(err, "unable to list the MasterUserRecords being migrated")
+	}
+	if len(migrating.Items) >= r.config.GetClusterDrainMigrationMaxPoolSize() {
+		logger.Info("the maximum number of MasterUserRecords is being migrated", "count", len(migrating.Items))
+		return false, nil
+	}
+
+	murs := &toolchainv1alpha1.MasterUserRecordList{}
+	if err := r.client.List(context.TODO(), murs, client.InNamespace(toolchainCluster.Namespace)); err != nil {
+		return false, errs.Wrap(err, "unable to list the MasterUserRecords")
+	}
+	for i := range murs.Items {
+		mur := &murs.Items[i]
+		if _, found := mur.Labels[MigrationSourceLabelKey]; found || mur.DeletionTimestamp != nil || !HasUserAccountIn(mur, toolchainCluster.Name) {
+			continue
+		}
+		logger.Info("labeling the MasterUserRecord to migrate it off the drained member cluster", "name", mur.Name)
+		if mur.Labels == nil {
+			mur.Labels = map[string]string{}
+		}
+		mur.Labels[MigrationSourceLabelKey] = toolchainCluster.Name
+		// the controller labels a single MasterUserRecord per reconcile loop,
+		// and the label update triggers another reconcile loop since the controller watches the labeled MasterUserRecords
+		return false, r.client.Update(context.TODO(), mur)
+	}
+	return len(migrating.Items) == 0, nil
+}
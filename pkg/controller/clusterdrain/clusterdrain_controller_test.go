@@ -0,0 +1,197 @@
+package clusterdrain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcile(t *testing.T) {
+	// given
+	restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_CLUSTER_DRAIN_MIGRATION_MAX_POOLSIZE", "2")
+	defer restore()
+	murs := append(
+		murtest.NewMasterUserRecords(t, 3, "john-%d", murtest.TargetCluster("member1")),
+		murtest.NewMasterUserRecord(t, "jane", murtest.TargetCluster("member2")))
+
+	t.Run("labels MasterUserRecords until the pool is full", func(t *testing.T) {
+		// given
+		r, cl := prepareReconcile(t, append(murs, newToolchainCluster("member1", DrainModeMigrate))...)
+
+		// when
+		_, err := r.Reconcile(newRequest("member1"))
+		require.NoError(t, err)
+		_, err = r.Reconcile(newRequest("member1"))
+		require.NoError(t, err)
+		_, err = r.Reconcile(newRequest("member1"))
+		require.NoError(t, err)
+
+		// then
+		assertMigrationSources(t, cl, map[string]string{
+			"john-0": "member1",
+			"john-1": "member1",
+			"john-2": "",
+			"jane":   "",
+		})
+	})
+
+	t.Run("labels next MasterUserRecord when a migration is complete", func(t *testing.T) {
+		// given
+		migrated := murtest.NewMasterUserRecord(t, "john-0", murtest.TargetCluster("member2"))
+		migrating := murtest.NewMasterUserRecord(t, "john-1", murtest.TargetCluster("member1"))
+		migrating.Labels = map[string]string{MigrationSourceLabelKey: "member1"}
+		r, cl := prepareReconcile(t, migrated, migrating,
+			murtest.NewMasterUserRecord(t, "john-2", murtest.TargetCluster("member1")),
+			newToolchainCluster("member1", DrainModeMigrate))
+
+		// when
+		_, err := r.Reconcile(newRequest("member1"))
+
+		// then
+		require.NoError(t, err)
+		assertMigrationSources(t, cl, map[string]string{
+			"john-0": "",
+			"john-1": "member1",
+			"john-2": "member1",
+		})
+	})
+
+	t.Run("nothing to migrate", func(t *testing.T) {
+		// given
+		r, cl := prepareReconcile(t, append(murs, newToolchainCluster("member3", DrainModeMigrate))...)
+
+		// when
+		_, err := r.Reconcile(newRequest("member3"))
+
+		// then
+		require.NoError(t, err)
+		assertMigrationSources(t, cl, map[string]string{
+			"john-0": "",
+			"john-1": "",
+			"john-2": "",
+			"jane":   "",
+		})
+	})
+
+	t.Run("cordoned cluster is not migrated", func(t *testing.T) {
+		// given
+		r, cl := prepareReconcile(t, append(murs, newToolchainCluster("member1", DrainModeCordon))...)
+
+		// when
+		_, err := r.Reconcile(newRequest("member1"))
+
+		// then
+		require.NoError(t, err)
+		assertMigrationSources(t, cl, map[string]string{
+			"john-0": "",
+			"john-1": "",
+			"john-2": "",
+			"jane":   "",
+		})
+	})
+
+	t.Run("ToolchainCluster not found", func(t *testing.T) {
+		// given
+		r, _ := prepareReconcile(t, murs...)
+
+		// when
+		_, err := r.Reconcile(newRequest("member1"))
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("fails when list fails", func(t *testing.T) {
+		// given
+		r, cl := prepareReconcile(t, append(murs, newToolchainCluster("member1", DrainModeMigrate))...)
+		cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			return fmt.Errorf("some error")
+		}
+
+		// when
+		_, err := r.Reconcile(newRequest("member1"))
+
+		// then
+		require.EqualError(t, err, "unable to list the MasterUserRecords being migrated: some error")
+	})
+}
+
+func TestGetDrainedClusters(t *testing.T) {
+	// given
+	undrained := newToolchainCluster("member3", "")
+	delete(undrained.Labels, DrainLabelKey)
+	cl := NewFakeClient(t, newToolchainCluster("member1", DrainModeMigrate), newToolchainCluster("member2", "true"), undrained)
+
+	// when
+	drained, err := GetDrainedClusters(cl, HostOperatorNs)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"member1": DrainModeMigrate, "member2": "true"}, drained)
+}
+
+func TestMapMasterUserRecordToToolchainCluster(t *testing.T) {
+	// given
+	migrating := murtest.NewMasterUserRecord(t, "john", murtest.TargetCluster("member1"))
+	migrating.Labels = map[string]string{MigrationSourceLabelKey: "member1"}
+	other := murtest.NewMasterUserRecord(t, "jane", murtest.TargetCluster("member1"))
+
+	// when
+	requests := mapMasterUserRecordToToolchainCluster(handler.MapObject{Meta: migrating, Object: migrating})
+	noRequests := mapMasterUserRecordToToolchainCluster(handler.MapObject{Meta: other, Object: other})
+
+	// then
+	assert.Equal(t, []reconcile.Request{newRequest("member1")}, requests)
+	assert.Empty(t, noRequests)
+}
+
+func prepareReconcile(t *testing.T, initObjs ...runtime.Object) (*ReconcileClusterDrain, *FakeClient) {
+	cl := NewFakeClient(t, initObjs...)
+	config, err := configuration.LoadConfig(cl)
+	require.NoError(t, err)
+	return &ReconcileClusterDrain{
+		client: cl,
+		config: config,
+	}, cl
+}
+
+func newToolchainCluster(name, drainMode string) *toolchainv1alpha1.ToolchainCluster {
+	return &toolchainv1alpha1.ToolchainCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: HostOperatorNs,
+			Labels: map[string]string{
+				DrainLabelKey: drainMode,
+			},
+		},
+	}
+}
+
+func newRequest(name string) reconcile.Request {
+	return reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: HostOperatorNs, Name: name},
+	}
+}
+
+func assertMigrationSources(t *testing.T, cl client.Client, expected map[string]string) {
+	for name, source := range expected {
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: HostOperatorNs, Name: name}, mur)
+		require.NoError(t, err)
+		assert.Equal(t, source, mur.Labels[MigrationSourceLabelKey], "unexpected migration source of the MasterUserRecord '%s'", name)
+	}
+}
@@ -0,0 +1,52 @@
+package clusterdrain
+
+import (
+	"context"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DrainLabelKey is set on the ToolchainCluster resource of a member cluster that should be taken out of rotation.
+	// No new users are provisioned to the member cluster regardless of the value of the label.
+	// If the value is DrainModeMigrate, then the users already provisioned to the member cluster are migrated to other member clusters too.
+	DrainLabelKey = toolchainv1alpha1.LabelKeyPrefix + "drain"
+
+	// DrainModeCordon is the value of the DrainLabelKey label that only stops placing new users to the member cluster
+	DrainModeCordon = "cordon"
+
+	// DrainModeMigrate is the value of the DrainLabelKey label that stops placing new users to the member cluster
+	// and migrates the existing ones to other member clusters
+	DrainModeMigrate = "migrate"
+
+	// MigrationSourceLabelKey is set on a MasterUserRecord whose UserAccount should be migrated off the member cluster
+	// with the name stored in the value of the label. The label is removed by the MasterUserRecord controller once the migration is over.
+	MigrationSourceLabelKey = toolchainv1alpha1.LabelKeyPrefix + "migration-source"
+)
+
+// GetDrainedClusters returns the drain modes (ie, the values of the DrainLabelKey label) of the member clusters being drained
+// mapped by the names of the member clusters
+func GetDrainedClusters(cl client.Client, namespace string) (map[string]string, error) {
+	toolchainClusters := &toolchainv1alpha1.ToolchainClusterList{}
+	if err := cl.List(context.TODO(), toolchainClusters, client.InNamespace(namespace), client.HasLabels{DrainLabelKey}); err != nil {
+		return nil, errors.Wrap(err, "unable to list the drained ToolchainCluster resources")
+	}
+	drained := make(map[string]string, len(toolchainClusters.Items))
+	for _, toolchainCluster := range toolchainClusters.Items {
+		drained[toolchainCluster.Name] = toolchainCluster.Labels[DrainLabelKey]
+	}
+	return drained, nil
+}
+
+// HasUserAccountIn returns true if the MasterUserRecord contains a UserAccount in the given member cluster
+func HasUserAccountIn(mur *toolchainv1alpha1.MasterUserRecord, clusterName string) bool {
+	for _, ua := range mur.Spec.UserAccounts {
+		if ua.TargetCluster == clusterName {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/changetierrequest"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/deactivation"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/hostoperatorconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/masteruserrecord"
@@ -34,6 +35,7 @@ func init() {
 	addToManagerFuncs = append(addToManagerFuncs, templateupdaterequest.Add)
 	addToManagerFuncs = append(addToManagerFuncs, deactivation.Add)
 	addToManagerFuncs = append(addToManagerFuncs, hostoperatorconfig.Add)
+	addToManagerFuncs = append(addToManagerFuncs, clusterdrain.Add)
 }
 
 // AddToManager adds all Controllers to the Manager
@@ -10,6 +10,7 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	usersignupctrl "github.com/codeready-toolchain/host-operator/pkg/controller/usersignup"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -125,23 +126,28 @@ func (r *ReconcileDeactivation) Reconcile(request reconcile.Request) (reconcile.
 		return reconcile.Result{}, err
 	}
 
-	// The tier is defined as part of its user account, since murs can in theory have multiple user accounts we will only consider the first one
-	account := mur.Spec.UserAccounts[0]
-
-	// Get the tier associated with the user account, we'll observe the deactivation timeout period from the tier spec
-	nsTemplateTier := &toolchainv1alpha1.NSTemplateTier{}
-	tierName := types.NamespacedName{Namespace: request.Namespace, Name: account.Spec.NSTemplateSet.TierName}
-	if err := r.client.Get(context.TODO(), tierName, nsTemplateTier); err != nil {
-		logger.Error(err, "unable to get NSTemplateTier", "name", account.Spec.NSTemplateSet.TierName)
-		return reconcile.Result{}, err
-	}
+	// The tier is defined as part of each user account and the murs can have multiple user accounts (possibly with different tiers),
+	// so the user is deactivated only after the longest deactivation timeout period of all the tiers
+	deactivationTimeoutDays := 0
+	for _, account := range mur.Spec.UserAccounts {
+		// Get the tier associated with the user account, we'll observe the deactivation timeout period from the tier spec
+		nsTemplateTier := &toolchainv1alpha1.NSTemplateTier{}
+		tierName := types.NamespacedName{Namespace: request.Namespace, Name: account.Spec.NSTemplateSet.TierName}
+		if err := r.client.Get(context.TODO(), tierName, nsTemplateTier); err != nil {
+			logger.Error(err, "unable to get NSTemplateTier", "name", account.Spec.NSTemplateSet.TierName)
+			return reconcile.Result{}, err
+		}
 
-	// If the deactivation timeout is 0 then users that belong to this tier should not be automatically deactivated
-	deactivationTimeoutDays := nsTemplateTier.Spec.DeactivationTimeoutDays
-	if deactivationTimeoutDays == 0 {
-		logger.Info("User belongs to a tier that does not have a deactivation timeout. The user will not be automatically deactivated")
-		// Users belonging to this tier will not be auto deactivated, no need to requeue.
-		return reconcile.Result{}, nil
+		// If the deactivation timeout is 0 then users that belong to this tier should not be automatically deactivated
+		if nsTemplateTier.Spec.DeactivationTimeoutDays == 0 {
+			logger.Info("User belongs to a tier that does not have a deactivation timeout. The user will not be automatically deactivated",
+				"tier", nsTemplateTier.Name, "member_cluster", account.TargetCluster)
+			// Users belonging to this tier will not be auto deactivated, no need to requeue.
+			return reconcile.Result{}, nil
+		}
+		if nsTemplateTier.Spec.DeactivationTimeoutDays > deactivationTimeoutDays {
+			deactivationTimeoutDays = nsTemplateTier.Spec.DeactivationTimeoutDays
+		}
 	}
 
 	deactivationTimeout := time.Duration(deactivationTimeoutDays*24) * time.Hour
@@ -162,6 +168,11 @@ func (r *ReconcileDeactivation) Reconcile(request reconcile.Request) (reconcile.
 		return reconcile.Result{}, nil
 	}
 	usersignup.Spec.Deactivated = true
+	// so the transition to the deactivated state is attributed to this controller in the state history of the UserSignup
+	if usersignup.Annotations == nil {
+		usersignup.Annotations = map[string]string{}
+	}
+	usersignup.Annotations[usersignupctrl.UserSignupDeactivatedByAnnotationKey] = usersignupctrl.StateTransitionActorDeactivationController
 
 	logger.Info("deactivating the user")
 	if err := r.client.Update(context.TODO(), usersignup); err != nil {
@@ -11,6 +11,7 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/usersignup"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	. "github.com/codeready-toolchain/host-operator/test"
 	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
@@ -105,6 +106,43 @@ func TestReconcile(t *testing.T) {
 			assertThatUserSignupDeactivated(t, cl, username, false)
 		})
 
+		// the time since the mur was provisioned exceeds the deactivation timeout period for the 'basic' tier, but not for the 'other' tier of another user account
+		t.Run("usersignup should not be deactivated - multiple user accounts with basic tier (30 days) and other tier (60 days)", func(t *testing.T) {
+			// given
+			murProvisionedTime := &metav1.Time{Time: time.Now().Add(-time.Duration(expectedDeactivationTimeoutBasicTier*24) * time.Hour)}
+			mur := murtest.NewMasterUserRecord(t, username, murtest.Account("cluster1", *basicTier), murtest.AdditionalAccount("cluster2", *otherTier),
+				murtest.ProvisionedMur(murProvisionedTime), murtest.UserIDFromUserSignup(userSignupFoobar))
+			mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] = userSignupFoobar.Name
+			r, req, cl := prepareReconcile(t, mur.Name, basicTier, otherTier, mur, userSignupFoobar)
+			// when
+			timeSinceProvisioned := time.Since(murProvisionedTime.Time)
+			res, err := r.Reconcile(req)
+			// then
+			require.NoError(t, err)
+			expectedTime := (time.Duration(expectedDeactivationTimeoutOtherTier*24) * time.Hour) - timeSinceProvisioned
+			actualTime := res.RequeueAfter
+			diff := expectedTime - actualTime
+			require.Truef(t, diff > 0 && diff < 2*time.Second, "expectedTime: '%v' is not within 2 seconds of actualTime: '%v' diff: '%v'", expectedTime, actualTime, diff)
+			assertThatUserSignupDeactivated(t, cl, username, false)
+		})
+
+		// one of the user accounts belongs to a tier without deactivationTimeoutDays, so the user should not be deactivated
+		t.Run("usersignup should not be deactivated - multiple user accounts with basic tier and no deactivation tier", func(t *testing.T) {
+			// given
+			murProvisionedTime := &metav1.Time{Time: time.Now().Add(-time.Duration(expectedDeactivationTimeoutBasicTier*24) * time.Hour)}
+			mur := murtest.NewMasterUserRecord(t, username, murtest.Account("cluster1", *basicTier), murtest.AdditionalAccount("cluster2", *noDeactivationTier),
+				murtest.ProvisionedMur(murProvisionedTime), murtest.UserIDFromUserSignup(userSignupFoobar))
+			mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] = userSignupFoobar.Name
+			r, req, cl := prepareReconcile(t, mur.Name, basicTier, noDeactivationTier, mur, userSignupFoobar)
+			// when
+			res, err := r.Reconcile(req)
+			// then
+			require.NoError(t, err)
+			require.False(t, res.Requeue, "requeue should not be set")
+			require.True(t, res.RequeueAfter == 0, "requeueAfter should not be set")
+			assertThatUserSignupDeactivated(t, cl, username, false)
+		})
+
 		// a mur that has not been provisioned yet
 		t.Run("mur without provisioned time", func(t *testing.T) {
 			// given
@@ -313,4 +351,7 @@ func assertThatUserSignupDeactivated(t *testing.T, cl *test.FakeClient, name str
 	err := cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: operatorNamespace}, userSignup)
 	require.NoError(t, err)
 	require.Equal(t, expected, userSignup.Spec.Deactivated)
+	if expected {
+		require.Equal(t, usersignup.StateTransitionActorDeactivationController, userSignup.Annotations[usersignup.UserSignupDeactivatedByAnnotationKey])
+	}
 }
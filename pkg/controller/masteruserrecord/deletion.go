@@ -0,0 +1,53 @@
+package masteruserrecord
+
+import (
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// MasterUserRecordUserAccountDeletionStuckReason is the reason of the MasterUserRecord condition when the deletion of a UserAccount
+// in a member cluster has not completed in time (eg, because of pending finalizers), as opposed to a failure of a call to the member cluster
+const MasterUserRecordUserAccountDeletionStuckReason = "UserAccountDeletionStuck"
+
+// deletionStuckError is returned when the deletion of a UserAccount in a member cluster has not completed in time
+type deletionStuckError struct {
+	timeout    time.Duration
+	finalizers []string
+}
+
+func (e *deletionStuckError) Error() string {
+	if len(e.finalizers) == 0 {
+		return fmt.Sprintf("UserAccount deletion has not completed in over %s", e.timeout)
+	}
+	return fmt.Sprintf("UserAccount deletion has not completed in over %s, pending finalizers: %v", e.timeout, e.finalizers)
+}
+
+// deletionFailureReason returns the reason of the MasterUserRecord condition for the given error of the deletion of a UserAccount
+func deletionFailureReason(err error) string {
+	if _, ok := err.(*deletionStuckError); ok {
+		return MasterUserRecordUserAccountDeletionStuckReason
+	}
+	return toolchainv1alpha1.MasterUserRecordUnableToDeleteUserAccountsReason
+}
+
+// deletionRequeueTime returns how long to wait before checking again the deletion of a UserAccount that started the given time ago.
+// Starting with the initial requeue time, the total time of the deletion doubles with every check, and the waiting time is limited
+// by the max requeue time. Some jitter is added so that the checks of the UserAccounts deleted at the same time are spread.
+func (r *ReconcileMasterUserRecord) deletionRequeueTime(elapsed time.Duration) time.Duration {
+	requeueTime := r.config.GetUserAccountDeletionRequeueInitial()
+	if elapsed > requeueTime {
+		requeueTime = elapsed
+	}
+	if max := r.config.GetUserAccountDeletionRequeueMax(); requeueTime > max {
+		requeueTime = max
+	}
+	if jitter := r.config.GetUserAccountDeletionRequeueJitter(); jitter > 0 {
+		// note: wait.Jitter uses a factor of 1.0 for a non-positive value, hence the check above
+		requeueTime = wait.Jitter(requeueTime, jitter)
+	}
+	return requeueTime
+}
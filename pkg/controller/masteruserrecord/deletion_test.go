@@ -0,0 +1,147 @@
+package masteruserrecord
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	uatest "github.com/codeready-toolchain/toolchain-common/pkg/test/useraccount"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestDeletionRequeueTime(t *testing.T) {
+	// given
+	s := apiScheme(t)
+
+	t.Run("default", func(t *testing.T) {
+		// given
+		cntrl := newController(t, test.NewFakeClient(t), s, NewGetMemberCluster(true, v1.ConditionTrue))
+
+		for elapsed, expected := range map[time.Duration]time.Duration{
+			0:                10 * time.Second,
+			5 * time.Second:  10 * time.Second,
+			20 * time.Second: 20 * time.Second,
+			40 * time.Second: 40 * time.Second,
+			10 * time.Minute: 5 * time.Minute,
+		} {
+			t.Run(fmt.Sprintf("after %s", elapsed), func(t *testing.T) {
+				// when
+				requeueTime := cntrl.deletionRequeueTime(elapsed)
+
+				// then
+				assertDeletionRequeueAfter(t, expected, requeueTime)
+			})
+		}
+	})
+
+	t.Run("configured without jitter", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_USERACCOUNT_DELETION_REQUEUE_INITIAL", "1s"),
+			test.Env("HOST_OPERATOR_USERACCOUNT_DELETION_REQUEUE_MAX", "30s"),
+			test.Env("HOST_OPERATOR_USERACCOUNT_DELETION_REQUEUE_JITTER", "0"))
+		defer restore()
+		cntrl := newController(t, test.NewFakeClient(t), s, NewGetMemberCluster(true, v1.ConditionTrue))
+
+		// then
+		assert.Equal(t, time.Second, cntrl.deletionRequeueTime(0))
+		assert.Equal(t, 4*time.Second, cntrl.deletionRequeueTime(4*time.Second))
+		assert.Equal(t, 30*time.Second, cntrl.deletionRequeueTime(time.Minute))
+	})
+}
+
+func TestDeletionFailureReason(t *testing.T) {
+	assert.Equal(t, MasterUserRecordUserAccountDeletionStuckReason, deletionFailureReason(&deletionStuckError{timeout: time.Minute}))
+	assert.Equal(t, toolchainv1alpha1.MasterUserRecordUnableToDeleteUserAccountsReason, deletionFailureReason(fmt.Errorf("mock error")))
+}
+
+func TestUserAccountDeletionStuck(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_USERACCOUNT_DELETION_TIMEOUT", "10m")
+	defer restore()
+	mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"), murtest.ToBeDeleted())
+	InitializeCounters(t, NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1))))
+
+	t.Run("still waiting within the timeout", func(t *testing.T) {
+		// given
+		userAcc := uatest.NewUserAccountFromMur(mur)
+		userAcc.DeletionTimestamp = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+		hostClient := test.NewFakeClient(t, mur.DeepCopy())
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, test.NewFakeClient(t, userAcc)))
+
+		// when
+		result, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, result.Requeue)
+		assert.True(t, result.RequeueAfter >= 2*time.Minute && result.RequeueAfter <= 3*time.Minute)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			HasFinalizer()
+	})
+
+	t.Run("stuck on finalizers after the timeout", func(t *testing.T) {
+		// given
+		userAcc := uatest.NewUserAccountFromMur(mur)
+		userAcc.DeletionTimestamp = &metav1.Time{Time: time.Now().Add(-11 * time.Minute)}
+		userAcc.Finalizers = []string{"finalizer.toolchain.dev.openshift.com"}
+		hostClient := test.NewFakeClient(t, mur.DeepCopy())
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, test.NewFakeClient(t, userAcc)))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		msg := "UserAccount deletion has not completed in over 10m0s, pending finalizers: [finalizer.toolchain.dev.openshift.com]"
+		require.EqualError(t, err, "failed to delete UserAccount in the member cluster 'member-cluster': "+msg)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			HasConditions(toBeNotReady(MasterUserRecordUserAccountDeletionStuckReason, msg)).
+			HasFinalizer()
+	})
+}
+
+func TestUserAccountRecreationDelay(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_USERACCOUNT_RECREATION_DELAY", "7s")
+	defer restore()
+	mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"))
+	userAcc := uatest.NewUserAccountFromMur(mur)
+	userAcc.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	InitializeCounters(t, NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1))))
+	cntrl := newController(t, test.NewFakeClient(t, mur), s, NewGetMemberCluster(true, v1.ConditionTrue),
+		ClusterClient(test.MemberClusterName, test.NewFakeClient(t, userAcc)))
+
+	// when
+	result, err := cntrl.Reconcile(newMurRequest(mur))
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, result.Requeue)
+	assert.Equal(t, 7*time.Second, result.RequeueAfter)
+}
+
+// assertDeletionRequeueAfter verifies that the given requeue time is the expected one plus the default jitter of at most 10%
+func assertDeletionRequeueAfter(t *testing.T, expected, actual time.Duration) {
+	assert.GreaterOrEqual(t, int64(actual), int64(expected))
+	assert.LessOrEqual(t, int64(actual), int64(expected+expected/10))
+}
@@ -0,0 +1,72 @@
+package masteruserrecord
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// UserAccountSyncedSpecHashAnnotationKey is set on the UserAccount in the member cluster and contains the hash of the spec
+	// that was copied there from the MasterUserRecord during the last synchronization. It allows to tell whether the UserAccount
+	// was changed by someone else since then.
+	UserAccountSyncedSpecHashAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "synced-spec-hash"
+
+	// UserAccountDriftDetectedReason is the reason of the event emitted when a UserAccount was changed or deleted in a member cluster
+	// while its MasterUserRecord was not changed
+	UserAccountDriftDetectedReason = "UserAccountDriftDetected"
+)
+
+// computeSpecHash returns the hash of the given UserAccount spec
+func computeSpecHash(spec toolchainv1alpha1.UserAccountSpec) (string, error) {
+	m, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	md5hash := md5.New()
+	// Ignore the error, as this implementation cannot return one
+	_, _ = md5hash.Write(m)
+	return hex.EncodeToString(md5hash.Sum(nil)), nil
+}
+
+// setSyncedSpecHash sets the annotation with the hash of the current spec of the given UserAccount. The UserAccount resource is not updated.
+func setSyncedSpecHash(userAccount *toolchainv1alpha1.UserAccount) error {
+	hash, err := computeSpecHash(userAccount.Spec)
+	if err != nil {
+		return err
+	}
+	if userAccount.Annotations == nil {
+		userAccount.Annotations = map[string]string{}
+	}
+	userAccount.Annotations[UserAccountSyncedSpecHashAnnotationKey] = hash
+	return nil
+}
+
+// isModified returns true if the spec of the given UserAccount is not the one that was set during the last synchronization.
+// A UserAccount without the annotation is never considered as modified, since its last synchronized spec is unknown.
+func isModified(userAccount *toolchainv1alpha1.UserAccount) (bool, error) {
+	syncedHash, found := userAccount.Annotations[UserAccountSyncedSpecHashAnnotationKey]
+	if !found {
+		return false, nil
+	}
+	hash, err := computeSpecHash(userAccount.Spec)
+	if err != nil {
+		return false, err
+	}
+	return hash != syncedHash, nil
+}
+
+// recordDrift counts the drift of the UserAccount in the given member cluster and emits a warning event on the MasterUserRecord
+func (r *ReconcileMasterUserRecord) recordDrift(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord, clusterName, change string) {
+	logger.Info("UserAccount drifted from the MasterUserRecord", "member_cluster", clusterName, "change", change)
+	metrics.UserAccountDriftCounterVec.WithLabelValues(clusterName).Inc()
+	r.recorder.Event(mur, corev1.EventTypeWarning, UserAccountDriftDetectedReason,
+		fmt.Sprintf("UserAccount in the member cluster '%s' %s outside of the MasterUserRecord", clusterName, change))
+}
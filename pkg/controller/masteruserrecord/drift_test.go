@@ -0,0 +1,240 @@
+package masteruserrecord
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	uatest "github.com/codeready-toolchain/toolchain-common/pkg/test/useraccount"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestIsModified(t *testing.T) {
+	// given
+	mur := murtest.NewMasterUserRecord(t, "john")
+
+	t.Run("not modified since the last synchronization", func(t *testing.T) {
+		// given
+		userAccount := uatest.NewUserAccountFromMur(mur)
+		require.NoError(t, setSyncedSpecHash(userAccount))
+
+		// when
+		modified, err := isModified(userAccount)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, modified)
+	})
+
+	t.Run("modified since the last synchronization", func(t *testing.T) {
+		// given
+		userAccount := uatest.NewUserAccountFromMur(mur)
+		require.NoError(t, setSyncedSpecHash(userAccount))
+		userAccount.Spec.NSLimit = "unlimited"
+
+		// when
+		modified, err := isModified(userAccount)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, modified)
+	})
+
+	t.Run("never synchronized with the hash", func(t *testing.T) {
+		// given
+		userAccount := uatest.NewUserAccountFromMur(mur)
+		userAccount.Spec.NSLimit = "unlimited"
+
+		// when
+		modified, err := isModified(userAccount)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, modified)
+	})
+}
+
+func TestUserAccountDrift(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1), WithRoutes("https://console.member-cluster/", "", ToBeReady())))
+
+	newMur := func() *toolchainv1alpha1.MasterUserRecord {
+		mur := murtest.NewMasterUserRecord(t, "john",
+			murtest.Finalizer("finalizer.toolchain.dev.openshift.com"),
+			murtest.StatusCondition(toBeNotReady(toolchainv1alpha1.MasterUserRecordProvisioningReason, "")))
+		mur.Status.UserAccounts = []toolchainv1alpha1.UserAccountStatusEmbedded{
+			{
+				Cluster:   toolchainv1alpha1.Cluster{Name: test.MemberClusterName},
+				SyncIndex: mur.Spec.UserAccounts[0].SyncIndex,
+			},
+		}
+		return mur
+	}
+
+	t.Run("modified UserAccount is repaired", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := newMur()
+		userAccount := uatest.NewUserAccountFromMur(mur)
+		require.NoError(t, setSyncedSpecHash(userAccount))
+		userAccount.Spec.NSLimit = "unlimited"
+		memberClient := test.NewFakeClient(t, userAccount)
+		cntrl := newController(t, test.NewFakeClient(t, mur, toolchainStatus), s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		uatest.AssertThatUserAccount(t, "john", memberClient).
+			Exists().
+			MatchMasterUserRecord(mur, mur.Spec.UserAccounts[0].Spec)
+		assertSyncedSpecHash(t, memberClient)
+		AssertMetricsCounterEquals(t, 1, metrics.UserAccountDriftCounterVec.WithLabelValues(test.MemberClusterName))
+		assert.Contains(t, drainEvents(cntrl), "Warning UserAccountDriftDetected UserAccount in the member cluster 'member-cluster' was modified outside of the MasterUserRecord")
+	})
+
+	t.Run("deleted UserAccount is recreated", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := newMur()
+		memberClient := test.NewFakeClient(t)
+		cntrl := newController(t, test.NewFakeClient(t, mur, toolchainStatus), s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		uatest.AssertThatUserAccount(t, "john", memberClient).
+			Exists().
+			MatchMasterUserRecord(mur, mur.Spec.UserAccounts[0].Spec)
+		assertSyncedSpecHash(t, memberClient)
+		AssertMetricsCounterEquals(t, 1, metrics.UserAccountDriftCounterVec.WithLabelValues(test.MemberClusterName))
+		assert.Contains(t, drainEvents(cntrl), "Warning UserAccountDriftDetected UserAccount in the member cluster 'member-cluster' was deleted outside of the MasterUserRecord")
+	})
+
+	t.Run("no drift when the MasterUserRecord was changed", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := newMur()
+		userAccount := uatest.NewUserAccountFromMur(mur)
+		require.NoError(t, setSyncedSpecHash(userAccount))
+		mur.Spec.UserAccounts[0].Spec.NSLimit = "unlimited"
+		memberClient := test.NewFakeClient(t, userAccount)
+		cntrl := newController(t, test.NewFakeClient(t, mur, toolchainStatus), s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		uatest.AssertThatUserAccount(t, "john", memberClient).
+			Exists().
+			MatchMasterUserRecord(mur, mur.Spec.UserAccounts[0].Spec)
+		assertSyncedSpecHash(t, memberClient)
+		AssertMetricsCounterEquals(t, 0, metrics.UserAccountDriftCounterVec.WithLabelValues(test.MemberClusterName))
+		assert.NotContains(t, drainEvents(cntrl), "Warning UserAccountDriftDetected UserAccount in the member cluster 'member-cluster' was modified outside of the MasterUserRecord")
+	})
+
+	t.Run("no drift when the UserAccount is created for the first time", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := newMur()
+		mur.Status.UserAccounts = nil
+		memberClient := test.NewFakeClient(t)
+		cntrl := newController(t, test.NewFakeClient(t, mur, toolchainStatus), s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		uatest.AssertThatUserAccount(t, "john", memberClient).Exists()
+		AssertMetricsCounterEquals(t, 0, metrics.UserAccountDriftCounterVec.WithLabelValues(test.MemberClusterName))
+	})
+}
+
+func TestResync(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"))
+	userAccount := uatest.NewUserAccountFromMur(mur)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1), WithRoutes("https://console.member-cluster/", "", ToBeReady())))
+	InitializeCounters(t, toolchainStatus)
+
+	t.Run("default interval", func(t *testing.T) {
+		// given
+		cntrl := newController(t, test.NewFakeClient(t, mur.DeepCopy(), toolchainStatus), s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, test.NewFakeClient(t, userAccount.DeepCopy())))
+
+		// when
+		result, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, result.RequeueAfter)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_MASTERUSERRECORD_RESYNC_INTERVAL", "0s")
+		defer restore()
+		cntrl := newController(t, test.NewFakeClient(t, mur.DeepCopy(), toolchainStatus), s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, test.NewFakeClient(t, userAccount.DeepCopy())))
+
+		// when
+		result, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.False(t, result.Requeue)
+	})
+}
+
+func assertSyncedSpecHash(t *testing.T, memberClient client.Client) {
+	userAccount := &toolchainv1alpha1.UserAccount{}
+	err := memberClient.Get(context.TODO(), namespacedName("toolchain-member-operator", "john"), userAccount)
+	require.NoError(t, err)
+	modified, err := isModified(userAccount)
+	require.NoError(t, err)
+	assert.False(t, modified)
+	assert.NotEmpty(t, userAccount.Annotations[UserAccountSyncedSpecHashAnnotationKey])
+}
+
+func drainEvents(cntrl ReconcileMasterUserRecord) []string {
+	events := cntrl.recorder.(*record.FakeRecorder).Events
+	var drained []string
+	for len(events) > 0 {
+		drained = append(drained, <-events)
+	}
+	return drained
+}
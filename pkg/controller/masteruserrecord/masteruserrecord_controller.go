@@ -3,16 +3,18 @@ package masteruserrecord
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/tierweight"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
 
 	"github.com/go-logr/logr"
-	"github.com/operator-framework/operator-sdk/pkg/predicate"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	errs "github.com/pkg/errors"
 	"github.com/redhat-cop/operator-utils/pkg/util"
 	coputil "github.com/redhat-cop/operator-utils/pkg/util"
@@ -21,8 +23,11 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -40,7 +45,16 @@ const (
 // Add creates a new MasterUserRecord Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, config *configuration.Config) error {
-	return add(mgr, newReconciler(mgr, config))
+	namespace, err := k8sutil.GetWatchNamespace()
+	if err != nil {
+		return err
+	}
+	// watch the UserAccounts in the member clusters
+	watcher := newMemberWatcher(namespace, cluster.GetMemberClusters, newMemberCache(mgr.GetScheme()))
+	if err := mgr.Add(watcher); err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, config), watcher.events)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -50,11 +64,12 @@ func newReconciler(mgr manager.Manager, config *configuration.Config) reconcile.
 		scheme:                mgr.GetScheme(),
 		retrieveMemberCluster: cluster.GetCachedToolchainCluster,
 		config:                config,
+		recorder:              mgr.GetEventRecorderFor("masteruserrecord-controller"),
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, userAccountEvents <-chan event.GenericEvent) error {
 	// Create a new controller
 	c, err := controller.New("masteruserrecord-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -65,7 +80,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	err = c.Watch(&source.Kind{
 		Type: &toolchainv1alpha1.MasterUserRecord{}},
 		&handler.EnqueueRequestForObject{},
-		predicate.GenerationChangedPredicate{})
+		MasterUserRecordChangedPredicate{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the UserAccounts in the member clusters, which are turned into events of the MasterUserRecords
+	err = c.Watch(&source.Channel{Source: userAccountEvents}, &handler.EnqueueRequestForObject{})
 	if err != nil {
 		return err
 	}
@@ -83,6 +104,7 @@ type ReconcileMasterUserRecord struct {
 	scheme                *runtime.Scheme
 	retrieveMemberCluster func(name string) (*cluster.CachedToolchainCluster, bool)
 	config                *configuration.Config
+	recorder              record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a MasterUserRecord object and makes changes based on the state read
@@ -116,16 +138,28 @@ func (r *ReconcileMasterUserRecord) Reconcile(request reconcile.Request) (reconc
 			logger.Error(err, "unable to add finalizer to MasterUserRecord")
 			return reconcile.Result{}, err
 		}
+		// move the UserAccount to another member cluster if requested
+		migrationRequeueTime, changed, err := r.migrate(logger, mur)
+		if err != nil {
+			logger.Error(err, "unable to migrate the MasterUserRecord")
+			return reconcile.Result{}, err
+		} else if changed {
+			return reconcile.Result{Requeue: migrationRequeueTime > 0, RequeueAfter: migrationRequeueTime}, nil
+		}
 		logger.Info("ensuring user accounts")
-		for _, account := range mur.Spec.UserAccounts {
-			requeueTime, err := r.ensureUserAccount(logger, account, mur)
-			if err != nil {
-				logger.Error(err, "unable to synchronize with member UserAccount")
-				return reconcile.Result{}, err
-			} else if requeueTime > 0 {
-				return reconcile.Result{Requeue: true, RequeueAfter: requeueTime}, err // waiting for a few seconds to give time to the member cluster to finish its deletions
-			}
+		requeueTime, err := r.ensureUserAccounts(logger, mur)
+		if err != nil {
+			logger.Error(err, "unable to synchronize with member UserAccount")
+			return reconcile.Result{}, err
+		} else if requeueTime > 0 {
+			return reconcile.Result{Requeue: true, RequeueAfter: requeueTime}, err // waiting for a few seconds to give time to the member cluster to finish its deletions
 		}
+		if migrationRequeueTime > 0 {
+			// waiting for the UserAccount to become ready in the destination member cluster of the migration
+			return reconcile.Result{Requeue: true, RequeueAfter: migrationRequeueTime}, nil
+		}
+		// reconcile again later, so the UserAccounts that were changed or deleted in the member clusters are brought back in sync
+		return reconcile.Result{RequeueAfter: r.config.GetMasterUserRecordResyncInterval()}, nil
 		// If the UserAccount is being deleted, delete the UserAccounts in members.
 	} else if coputil.HasFinalizer(mur, murFinalizerName) {
 		requeueTime, err := r.manageCleanUp(logger, mur)
@@ -155,6 +189,71 @@ func (r *ReconcileMasterUserRecord) addFinalizer(logger logr.Logger, mur *toolch
 	return nil
 }
 
+// userAccountFailure is the failure of the processing of the UserAccount in a member cluster
+type userAccountFailure struct {
+	targetCluster string
+	err           error
+	// condition is the Ready condition of the MasterUserRecord set because of the failure
+	condition toolchainv1alpha1.Condition
+}
+
+// ensureUserAccounts ensures the UserAccounts in all the target clusters of the given MasterUserRecord. The UserAccounts are processed
+// independently of each other, so a failure or a pending deletion in one member cluster doesn't prevent the UserAccounts in the other
+// member clusters from being synchronized.
+// Returns the shortest non-zero duration of the UserAccounts that need a requeue and the error(s) of the UserAccounts that failed
+func (r *ReconcileMasterUserRecord) ensureUserAccounts(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord) (time.Duration, error) {
+	var requeueTime time.Duration
+	var failures []userAccountFailure
+	for _, account := range mur.Spec.UserAccounts {
+		accountRequeueTime, err := r.ensureUserAccount(logger, account, mur)
+		if err != nil {
+			logger.Error(err, "unable to synchronize with member UserAccount", "target_cluster", account.TargetCluster)
+			ready, _ := condition.FindConditionByType(mur.Status.Conditions, toolchainv1alpha1.ConditionReady)
+			failures = append(failures, userAccountFailure{
+				targetCluster: account.TargetCluster,
+				err:           err,
+				condition:     ready,
+			})
+			continue
+		}
+		requeueTime = shortestRequeueTime(requeueTime, accountRequeueTime)
+	}
+	return requeueTime, r.aggregateFailures(logger, mur, failures)
+}
+
+// aggregateFailures sets the Ready condition of the MasterUserRecord so it reflects the failures in all the member clusters
+// (the condition set by a failure may have been overwritten when the UserAccounts in the other member clusters were synchronized)
+// and returns the error(s) of the failures
+func (r *ReconcileMasterUserRecord) aggregateFailures(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord, failures []userAccountFailure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	ready := failures[0].condition
+	errList := make([]error, len(failures))
+	if len(failures) == 1 {
+		errList[0] = failures[0].err
+	} else {
+		messages := make([]string, len(failures))
+		for i, failure := range failures {
+			messages[i] = fmt.Sprintf("%s: %s", failure.targetCluster, failure.condition.Message)
+			errList[i] = failure.err
+		}
+		ready.Message = strings.Join(messages, "; ")
+	}
+	if err := updateStatusConditions(logger, r.client, mur, ready); err != nil {
+		logger.Error(err, "status update failed")
+	}
+	return utilerrors.NewAggregate(errList)
+}
+
+// shortestRequeueTime returns the shortest of the two given durations, ignoring the zero ones
+func shortestRequeueTime(current, other time.Duration) time.Duration {
+	if current == 0 || (other > 0 && other < current) {
+		return other
+	}
+	return current
+}
+
 // ensureUserAccount ensures that there's a UserAccount resource on the member cluster for the given `murAccount`.
 // If the UserAccount resource already exists, then this latter is synchronized using the given `murAccount` and the associated `mur` status is also updated to reflect
 // the UserAccount specs.
@@ -173,12 +272,22 @@ func (r *ReconcileMasterUserRecord) ensureUserAccount(logger logr.Logger, murAcc
 	if err := memberCluster.Client.Get(context.TODO(), nsdName, userAccount); err != nil {
 		if errors.IsNotFound(err) {
 			// does not exist - should create
+			if _, index := getUserAccountStatus(murAccount.TargetCluster, mur); index >= 0 {
+				// the UserAccount was already provisioned, so it was deleted by someone else
+				r.recordDrift(logger, mur, murAccount.TargetCluster, "was deleted")
+			}
 			userAccount = newUserAccount(nsdName, murAccount.Spec, mur.Spec)
+			weight := r.getWeight(logger, mur.Namespace, murAccount.Spec.NSTemplateSet.TierName)
+			setAppliedWeight(userAccount, weight)
+			if err := setSyncedSpecHash(userAccount); err != nil {
+				return 0, r.wrapErrorWithStatusUpdate(logger, mur, r.setStatusFailed(toolchainv1alpha1.MasterUserRecordUnableToCreateUserAccountReason), err,
+					"failed to compute the hash of the UserAccount spec for the member cluster '%s'", murAccount.TargetCluster)
+			}
 			if err := memberCluster.Client.Create(context.TODO(), userAccount); err != nil {
 				return 0, r.wrapErrorWithStatusUpdate(logger, mur, r.setStatusFailed(toolchainv1alpha1.MasterUserRecordUnableToCreateUserAccountReason), err,
 					"failed to create UserAccount in the member cluster '%s'", murAccount.TargetCluster)
 			}
-			counter.IncrementUserAccountCount(murAccount.TargetCluster)
+			counter.IncrementUserAccountCount(murAccount.TargetCluster, weight)
 			return 0, updateStatusConditions(logger, r.client, mur, toBeNotReady(toolchainv1alpha1.MasterUserRecordProvisioningReason, ""))
 		}
 		// another/unexpected error occurred while trying to fetch the user account on the member cluster
@@ -190,19 +299,20 @@ func (r *ReconcileMasterUserRecord) ensureUserAccount(logger logr.Logger, murAcc
 		logger.Info("UserAccount is being deleted. Waiting until deletion is complete", "member_cluster", memberCluster.Name)
 		deletionTimestamp := userAccount.GetDeletionTimestamp()
 
-		// this code block makes sure that we will requeue but only 3s after the deletion timestamp, and we should not update the counter twice
-		requeueTime := 3 * time.Second
+		// this code block makes sure that we will requeue but only after the recreation delay since the deletion timestamp, and we should not update the counter twice
+		requeueTime := r.config.GetUserAccountRecreationDelay()
 		timeUntilDeletion := time.Until(deletionTimestamp.Time)
-		if timeUntilDeletion+3*time.Second >= 0 {
-			counter.DecrementUserAccountCount(logger, murAccount.TargetCluster)
+		if timeUntilDeletion+requeueTime >= 0 {
+			counter.DecrementUserAccountCount(logger, murAccount.TargetCluster, r.getAppliedWeight(logger, mur.Namespace, userAccount))
 			if timeUntilDeletion > 0 {
-				requeueTime = timeUntilDeletion + 3*time.Second
+				requeueTime += timeUntilDeletion
 			}
 		}
 
 		return requeueTime, updateStatusConditions(logger, r.client, mur, toBeNotReady(toolchainv1alpha1.MasterUserRecordProvisioningReason, "recovering deleted UserAccount"))
 	}
 
+	provisioned := isProvisioned(mur)
 	sync := Synchronizer{
 		record:            mur,
 		hostClient:        r.client,
@@ -213,16 +323,37 @@ func (r *ReconcileMasterUserRecord) ensureUserAccount(logger logr.Logger, murAcc
 		scheme:            r.scheme,
 		config:            r.config,
 	}
+	// the weight of the UserAccount changes if the tier is changed during the synchronization
+	oldWeight, newWeight := 0, 0
+	if !sync.isSynchronized() {
+		modified, err := isModified(userAccount)
+		if err != nil {
+			return 0, r.wrapErrorWithStatusUpdate(logger, mur, r.setStatusFailed(toolchainv1alpha1.MasterUserRecordUnableToSynchronizeUserAccountSpecReason), err,
+				"failed to compute the hash of the UserAccount spec in the cluster '%s'", murAccount.TargetCluster)
+		}
+		if modified {
+			r.recordDrift(logger, mur, murAccount.TargetCluster, "was modified")
+		}
+		oldWeight = r.getAppliedWeight(logger, mur.Namespace, userAccount)
+		newWeight = r.getWeight(logger, mur.Namespace, murAccount.Spec.NSTemplateSet.TierName)
+		setAppliedWeight(userAccount, newWeight)
+	}
 	if err := sync.synchronizeSpec(); err != nil {
 		// note: if we got an error while sync'ing the spec, then we may not be able to update the MUR status it here neither.
 		return 0, r.wrapErrorWithStatusUpdate(logger, mur, r.setStatusFailed(toolchainv1alpha1.MasterUserRecordUnableToSynchronizeUserAccountSpecReason), err,
 			"update of the UserAccount.spec in the cluster '%s' failed", murAccount.TargetCluster)
 	}
+	if oldWeight != newWeight {
+		counter.UpdateUserAccountWeight(murAccount.TargetCluster, oldWeight, newWeight)
+	}
 	if err := sync.synchronizeStatus(); err != nil {
 		err = errs.Wrapf(err, "update of the MasterUserRecord failed while synchronizing with UserAccount status from the cluster '%s'", murAccount.TargetCluster)
 		// note: if we got an error while updating the status, then we probably can't update it here neither.
 		return 0, r.wrapErrorWithStatusUpdate(logger, mur, r.useExistingConditionOfType(toolchainv1alpha1.ConditionReady), err, "")
 	}
+	if !provisioned && isProvisioned(mur) {
+		r.recordProvisionedEvent(logger, mur)
+	}
 	// nothing done and no error occurred
 	logger.Info("user account on member cluster was already in sync", "target_cluster", murAccount.TargetCluster)
 	return 0, nil
@@ -280,15 +411,31 @@ func (r *ReconcileMasterUserRecord) useExistingConditionOfType(condType toolchai
 	}
 }
 
+// manageCleanUp deletes the UserAccounts in all the target clusters of the given MasterUserRecord and removes the finalizer once all of them are gone.
+// The UserAccounts are deleted independently of each other, so a failure in one member cluster doesn't block the deletions in the other member clusters.
+// The UserAccounts in the member clusters that are unavailable for too long are orphaned, see handleUnavailableCluster.
 func (r *ReconcileMasterUserRecord) manageCleanUp(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord) (time.Duration, error) {
+	var requeueTime time.Duration
+	var failures []userAccountFailure
 	for _, ua := range mur.Spec.UserAccounts {
-		requeueTime, err := r.deleteUserAccount(logger, ua.TargetCluster, mur.Name)
+		accountRequeueTime, err := r.deleteOrOrphanUserAccount(logger, ua, mur)
 		if err != nil {
-			return 0, r.wrapErrorWithStatusUpdate(logger, mur, r.setStatusFailed(toolchainv1alpha1.MasterUserRecordUnableToDeleteUserAccountsReason), err,
+			err = r.wrapErrorWithStatusUpdate(logger, mur, r.setStatusFailed(deletionFailureReason(err)), err,
 				"failed to delete UserAccount in the member cluster '%s'", ua.TargetCluster)
-		} else if requeueTime > 0 {
-			return requeueTime, nil
+			ready, _ := condition.FindConditionByType(mur.Status.Conditions, toolchainv1alpha1.ConditionReady)
+			failures = append(failures, userAccountFailure{
+				targetCluster: ua.TargetCluster,
+				err:           err,
+				condition:     ready,
+			})
+			continue
 		}
+		requeueTime = shortestRequeueTime(requeueTime, accountRequeueTime)
+	}
+	if err := r.aggregateFailures(logger, mur, failures); err != nil {
+		return 0, err
+	} else if requeueTime > 0 {
+		return requeueTime, nil
 	}
 	// Remove finalizer from MasterUserRecord
 	coputil.RemoveFinalizer(mur, murFinalizerName)
@@ -301,8 +448,7 @@ func (r *ReconcileMasterUserRecord) manageCleanUp(logger logr.Logger, mur *toolc
 	return 0, nil
 }
 
-func (r *ReconcileMasterUserRecord) deleteUserAccount(logger logr.Logger, targetCluster, name string) (time.Duration, error) {
-	requeueTime := 10 * time.Second
+func (r *ReconcileMasterUserRecord) deleteUserAccount(logger logr.Logger, targetCluster string, mur *toolchainv1alpha1.MasterUserRecord) (time.Duration, error) {
 	// get & check member cluster
 	memberCluster, err := r.getMemberCluster(targetCluster)
 	if err != nil {
@@ -310,7 +456,7 @@ func (r *ReconcileMasterUserRecord) deleteUserAccount(logger logr.Logger, target
 	}
 	// Get the User associated with the UserAccount
 	userAcc := &toolchainv1alpha1.UserAccount{}
-	namespacedName := types.NamespacedName{Namespace: memberCluster.OperatorNamespace, Name: name}
+	namespacedName := types.NamespacedName{Namespace: memberCluster.OperatorNamespace, Name: mur.Name}
 	if err = memberCluster.Client.Get(context.TODO(), namespacedName, userAcc); err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("UserAccount deleted")
@@ -320,20 +466,60 @@ func (r *ReconcileMasterUserRecord) deleteUserAccount(logger logr.Logger, target
 	}
 
 	if coputil.IsBeingDeleted(userAcc) {
-		// if the UserAccount is being deleted, allow up to 1 minute of retries before reporting an error
-		deletionTimestamp := userAcc.GetDeletionTimestamp()
-		if time.Since(deletionTimestamp.Time) > 60*time.Second {
-			return 0, fmt.Errorf("UserAccount deletion has not completed in over 1 minute")
+		// if the UserAccount is being deleted, allow retries until the timeout before reporting that the deletion is stuck
+		elapsed := time.Since(userAcc.GetDeletionTimestamp().Time)
+		if timeout := r.config.GetUserAccountDeletionTimeout(); elapsed > timeout {
+			return 0, &deletionStuckError{
+				timeout:    timeout,
+				finalizers: userAcc.GetFinalizers(),
+			}
 		}
-		return requeueTime, nil
+		return r.deletionRequeueTime(elapsed), nil
 	}
 
 	if err := memberCluster.Client.Delete(context.TODO(), userAcc); err != nil {
 		return 0, err
 	}
-	counter.DecrementUserAccountCount(logger, targetCluster)
+	counter.DecrementUserAccountCount(logger, targetCluster, r.getAppliedWeight(logger, mur.Namespace, userAcc))
+
+	return r.deletionRequeueTime(0), nil
+}
+
+// getWeight returns the weight of the given tier used by the weighted counter of UserAccounts.
+// The weight is only informative, so if it cannot be determined, then the default weight 1 is returned.
+func (r *ReconcileMasterUserRecord) getWeight(logger logr.Logger, namespace, tierName string) int {
+	weight, err := tierweight.Get(r.client, r.config, namespace, tierName)
+	if err != nil {
+		logger.Error(err, "unable to get the weight of the tier, using 1", "tier", tierName)
+	}
+	return weight
+}
+
+// recordProvisionedEvent emits the Provisioned event on the MasterUserRecord as well as on the UserSignup that owns it
+func (r *ReconcileMasterUserRecord) recordProvisionedEvent(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord) {
+	clusters := make([]string, len(mur.Spec.UserAccounts))
+	for i, account := range mur.Spec.UserAccounts {
+		clusters[i] = account.TargetCluster
+	}
+	message := fmt.Sprintf("user provisioned to the member clusters: %s", strings.Join(clusters, ", "))
+	r.recorder.Event(mur, corev1.EventTypeNormal, toolchainv1alpha1.MasterUserRecordProvisionedReason, message)
+
+	owner, found := mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey]
+	if !found {
+		return
+	}
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := r.client.Get(context.TODO(), namespacedName(mur.Namespace, owner), userSignup); err != nil {
+		logger.Error(err, "unable to get the UserSignup to record the Provisioned event", "usersignup", owner)
+		return
+	}
+	r.recorder.Event(userSignup, corev1.EventTypeNormal, toolchainv1alpha1.MasterUserRecordProvisionedReason, message)
+}
 
-	return requeueTime, nil
+// isProvisioned returns true if the Ready condition of the MasterUserRecord is true with the Provisioned reason
+func isProvisioned(mur *toolchainv1alpha1.MasterUserRecord) bool {
+	ready, found := condition.FindConditionByType(mur.Status.Conditions, toolchainv1alpha1.ConditionReady)
+	return found && ready.Status == corev1.ConditionTrue && ready.Reason == toolchainv1alpha1.MasterUserRecordProvisionedReason
 }
 
 func toBeProvisioned() toolchainv1alpha1.Condition {
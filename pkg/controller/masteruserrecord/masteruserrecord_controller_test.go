@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -123,6 +124,35 @@ func TestCreateUserAccountSuccessful(t *testing.T) {
 		HaveUserAccountsForCluster(test.MemberClusterName, 2)
 }
 
+func TestCreateUserAccountWithTierWeight(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	restore := test.SetEnvVarsAndRestore(t, test.Env("HOST_OPERATOR_TIER_WEIGHTS", "basic=3"))
+	defer restore()
+	s := apiScheme(t)
+	mur := murtest.NewMasterUserRecord(t, "john")
+	require.NoError(t, murtest.Modify(mur, murtest.Finalizer("finalizer.toolchain.dev.openshift.com")))
+	memberClient := test.NewFakeClient(t)
+	hostClient := test.NewFakeClient(t, mur)
+	InitializeCounters(t, NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1))))
+
+	cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+		ClusterClient(test.MemberClusterName, memberClient))
+
+	// when
+	_, err := cntrl.Reconcile(newMurRequest(mur))
+
+	// then
+	require.NoError(t, err)
+	uatest.AssertThatUserAccount(t, "john", memberClient).
+		Exists()
+	AssertThatCounters(t).HaveMasterUserRecords(1).
+		HaveUserAccountsForCluster(test.MemberClusterName, 2).
+		HaveWeightedUserAccountsForCluster(test.MemberClusterName, 4)
+}
+
 func TestCreateMultipleUserAccountsSuccessful(t *testing.T) {
 	// given
 	logf.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -159,6 +189,68 @@ func TestCreateMultipleUserAccountsSuccessful(t *testing.T) {
 		HaveUserAccountsForCluster("member2-cluster", 1)
 }
 
+func TestCreateMultipleUserAccountsWithUnavailableMemberCluster(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1), WithRoutes("https://console.member-cluster/", "", ToBeReady())),
+		WithMember("member2-cluster", WithRoutes("https://console.member-cluster/", "", ToBeReady())))
+
+	t.Run("UserAccount created in the available member cluster", func(t *testing.T) {
+		// given
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.AdditionalAccounts("member2-cluster"), murtest.Finalizer("finalizer.toolchain.dev.openshift.com"))
+		memberClient2 := test.NewFakeClient(t)
+		hostClient := test.NewFakeClient(t, mur, toolchainStatus)
+		InitializeCounters(t, toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient("member2-cluster", memberClient2))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		msg := "the member cluster member-cluster not found in the registry"
+		require.EqualError(t, err, "failed to get the member cluster 'member-cluster': "+msg)
+		uatest.AssertThatUserAccount(t, "john", memberClient2).
+			Exists().
+			MatchMasterUserRecord(mur, mur.Spec.UserAccounts[1].Spec)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			HasConditions(toBeNotReady(toolchainv1alpha1.MasterUserRecordTargetClusterNotReadyReason, msg)).
+			HasFinalizer()
+		AssertThatCounters(t).HaveMasterUserRecords(1).
+			HaveUserAccountsForCluster(test.MemberClusterName, 1).
+			HaveUserAccountsForCluster("member2-cluster", 1)
+	})
+
+	t.Run("failures of all member clusters are reported", func(t *testing.T) {
+		// given
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.AdditionalAccounts("member2-cluster"), murtest.Finalizer("finalizer.toolchain.dev.openshift.com"))
+		hostClient := test.NewFakeClient(t, mur, toolchainStatus)
+		InitializeCounters(t, toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.EqualError(t, err, "[failed to get the member cluster 'member-cluster': the member cluster member-cluster not found in the registry, "+
+			"failed to get the member cluster 'member2-cluster': the member cluster member2-cluster not found in the registry]")
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			HasConditions(toBeNotReady(toolchainv1alpha1.MasterUserRecordTargetClusterNotReadyReason,
+				"member-cluster: the member cluster member-cluster not found in the registry; member2-cluster: the member cluster member2-cluster not found in the registry"))
+	})
+}
+
+func TestShortestRequeueTime(t *testing.T) {
+	assert.Equal(t, time.Duration(0), shortestRequeueTime(0, 0))
+	assert.Equal(t, 3*time.Second, shortestRequeueTime(0, 3*time.Second))
+	assert.Equal(t, 3*time.Second, shortestRequeueTime(3*time.Second, 0))
+	assert.Equal(t, 3*time.Second, shortestRequeueTime(10*time.Second, 3*time.Second))
+	assert.Equal(t, 3*time.Second, shortestRequeueTime(3*time.Second, 10*time.Second))
+}
+
 func TestRequeueWhenUserAccountDeleted(t *testing.T) {
 	// given
 	logf.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -502,7 +594,7 @@ func TestCreateSynchronizeOrDeleteUserAccountFailed(t *testing.T) {
 		result1, err1 := cntrl.Reconcile(newMurRequest(mur)) // first reconcile will be requeued to wait for UserAccount deletion
 		require.NoError(t, err1)
 		assert.True(t, result1.Requeue)
-		assert.Equal(t, int64(result1.RequeueAfter), int64(10*time.Second))
+		assertDeletionRequeueAfter(t, 10*time.Second, result1.RequeueAfter)
 
 		result2, err2 := cntrl.Reconcile(newMurRequest(mur)) // second reconcile
 
@@ -776,6 +868,77 @@ func TestSyncMurStatusWithUserAccountStatuses(t *testing.T) {
 	})
 }
 
+func TestProvisionedEvent(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	mur := murtest.NewMasterUserRecord(t, "john",
+		murtest.Finalizer("finalizer.toolchain.dev.openshift.com"),
+		murtest.StatusCondition(toBeNotReady(toolchainv1alpha1.MasterUserRecordProvisioningReason, "")))
+	mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] = "john-signup"
+	userAccount := uatest.NewUserAccountFromMur(mur, uatest.StatusCondition(toBeProvisioned()), uatest.ResourceVersion("123abc"))
+	mur.Status.UserAccounts = []toolchainv1alpha1.UserAccountStatusEmbedded{
+		{
+			Cluster:           toolchainv1alpha1.Cluster{Name: test.MemberClusterName},
+			SyncIndex:         userAccount.ResourceVersion,
+			UserAccountStatus: userAccount.Status,
+		},
+	}
+	userSignup := &toolchainv1alpha1.UserSignup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "john-signup",
+			Namespace: test.HostOperatorNs,
+		},
+	}
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1), WithRoutes("https://console.member-cluster/", "", ToBeReady())))
+	InitializeCounters(t, toolchainStatus)
+
+	t.Run("emitted when provisioned", func(t *testing.T) {
+		// given
+		hostClient := test.NewFakeClient(t, mur.DeepCopy(), userSignup, toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, test.NewFakeClient(t, userAccount)))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			HasConditions(toBeProvisioned(), toBeProvisionedNotificationCreated())
+		events := cntrl.recorder.(*record.FakeRecorder).Events
+		require.Len(t, events, 2)
+		assert.Equal(t, "Normal Provisioned user provisioned to the member clusters: member-cluster", <-events) // on the MasterUserRecord
+		assert.Equal(t, "Normal Provisioned user provisioned to the member clusters: member-cluster", <-events) // on the UserSignup
+
+		t.Run("not emitted again", func(t *testing.T) {
+			// when
+			_, err := cntrl.Reconcile(newMurRequest(mur))
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, events)
+		})
+	})
+
+	t.Run("emitted only on the MasterUserRecord when the UserSignup is missing", func(t *testing.T) {
+		// given
+		hostClient := test.NewFakeClient(t, mur.DeepCopy(), toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, test.NewFakeClient(t, userAccount)))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		events := cntrl.recorder.(*record.FakeRecorder).Events
+		require.Len(t, events, 1)
+	})
+}
+
 func TestDeleteUserAccountViaMasterUserRecordBeingDeleted(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		// given
@@ -798,7 +961,7 @@ func TestDeleteUserAccountViaMasterUserRecordBeingDeleted(t *testing.T) {
 		result1, err1 := cntrl.Reconcile(newMurRequest(mur))
 		require.NoError(t, err1)
 		assert.True(t, result1.Requeue)
-		assert.Equal(t, int64(result1.RequeueAfter), int64(10*time.Second))
+		assertDeletionRequeueAfter(t, 10*time.Second, result1.RequeueAfter)
 
 		result2, err2 := cntrl.Reconcile(newMurRequest(mur))
 
@@ -837,7 +1000,7 @@ func TestDeleteUserAccountViaMasterUserRecordBeingDeleted(t *testing.T) {
 		result1, err := cntrl.Reconcile(newMurRequest(mur))
 		require.NoError(t, err)
 		assert.True(t, result1.Requeue)
-		assert.Equal(t, int64(result1.RequeueAfter), int64(10*time.Second))
+		assertDeletionRequeueAfter(t, 10*time.Second, result1.RequeueAfter)
 
 		err = memberClient.Delete(context.TODO(), userAcc)
 		require.NoError(t, err)
@@ -880,10 +1043,11 @@ func TestDeleteUserAccountViaMasterUserRecordBeingDeleted(t *testing.T) {
 		// then
 		require.Empty(t, result)
 		require.Error(t, err)
-		require.Equal(t, `failed to delete UserAccount in the member cluster 'member-cluster': UserAccount deletion has not completed in over 1 minute`, err.Error())
+		require.Equal(t, `failed to delete UserAccount in the member cluster 'member-cluster': UserAccount deletion has not completed in over 1m0s`, err.Error())
 		uatest.AssertThatUserAccount(t, "john-wait-for-ua", memberClient).
 			Exists()
 		murtest.AssertThatMasterUserRecord(t, "john-wait-for-ua", hostClient).
+			HasConditions(toBeNotReady(MasterUserRecordUserAccountDeletionStuckReason, "UserAccount deletion has not completed in over 1m0s")).
 			HasFinalizer()
 		AssertThatCounters(t).HaveMasterUserRecords(2).
 			HaveUserAccountsForCluster(test.MemberClusterName, 2)
@@ -912,21 +1076,16 @@ func TestDeleteMultipleUserAccountsViaMasterUserRecordBeingDeleted(t *testing.T)
 		ClusterClient(test.MemberClusterName, memberClient), ClusterClient("member2-cluster", memberClient2))
 
 	// when
-	result1, err1 := cntrl.Reconcile(newMurRequest(mur)) // first reconcile will wait for first useraccount to be deleted
+	result1, err1 := cntrl.Reconcile(newMurRequest(mur)) // first reconcile will wait for both useraccounts to be deleted
 	require.NoError(t, err1)
 	assert.True(t, result1.Requeue)
-	assert.Equal(t, int64(result1.RequeueAfter), int64(10*time.Second))
-
-	result2, err2 := cntrl.Reconcile(newMurRequest(mur)) // second reconcile will wait for second useraccount to be deleted
-	require.NoError(t, err2)
-	assert.True(t, result2.Requeue)
-	assert.Equal(t, int64(result2.RequeueAfter), int64(10*time.Second))
+	assertDeletionRequeueAfter(t, 10*time.Second, result1.RequeueAfter)
 
-	result3, err3 := cntrl.Reconcile(newMurRequest(mur))
+	result2, err2 := cntrl.Reconcile(newMurRequest(mur))
 
 	// then
-	require.Empty(t, result3)
-	require.NoError(t, err3)
+	require.Empty(t, result2)
+	require.NoError(t, err2)
 
 	uatest.AssertThatUserAccount(t, "john", memberClient).
 		DoesNotExist()
@@ -940,6 +1099,35 @@ func TestDeleteMultipleUserAccountsViaMasterUserRecordBeingDeleted(t *testing.T)
 		HaveUserAccountsForCluster("member3-cluster", 2)
 }
 
+func TestDeleteMultipleUserAccountsWithUnavailableMemberCluster(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	mur := murtest.NewMasterUserRecord(t, "john",
+		murtest.Finalizer("finalizer.toolchain.dev.openshift.com"),
+		murtest.ToBeDeleted(), murtest.AdditionalAccounts("member2-cluster"))
+	memberClient2 := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+	hostClient := test.NewFakeClient(t, mur)
+	InitializeCounters(t, NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1)),
+		WithMember("member2-cluster", WithUserAccountCount(1))))
+
+	cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+		ClusterClient("member2-cluster", memberClient2))
+
+	// when
+	_, err := cntrl.Reconcile(newMurRequest(mur))
+
+	// then
+	require.EqualError(t, err, "failed to delete UserAccount in the member cluster 'member-cluster': the member cluster member-cluster not found in the registry")
+	uatest.AssertThatUserAccount(t, "john", memberClient2).
+		DoesNotExist()
+	murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+		HasConditions(toBeNotReady(toolchainv1alpha1.MasterUserRecordUnableToDeleteUserAccountsReason, "the member cluster member-cluster not found in the registry")).
+		HasFinalizer()
+}
+
 func TestDisablingMasterUserRecord(t *testing.T) {
 	// given
 	logf.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -961,7 +1149,7 @@ func TestDisablingMasterUserRecord(t *testing.T) {
 	// when
 	res, err := cntrl.Reconcile(newMurRequest(mur))
 	require.NoError(t, err)
-	assert.Equal(t, reconcile.Result{Requeue: false}, res)
+	assert.Equal(t, reconcile.Result{RequeueAfter: time.Hour}, res) // resync
 	userAcc := &toolchainv1alpha1.UserAccount{}
 	err = memberClient.Get(context.TODO(), types.NamespacedName{Name: mur.Name, Namespace: "toolchain-member-operator"}, userAcc)
 	require.NoError(t, err)
@@ -991,5 +1179,6 @@ func newController(t *testing.T, hostCl client.Client, s *runtime.Scheme, getMem
 		scheme:                s,
 		retrieveMemberCluster: getMemberCluster(memberCl...),
 		config:                config,
+		recorder:              record.NewFakeRecorder(100),
 	}
 }
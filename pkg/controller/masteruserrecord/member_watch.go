@@ -0,0 +1,213 @@
+package masteruserrecord
+
+import (
+	"context"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// memberWatchRefreshPeriod is how often the member clusters in the cluster cache are checked, so the UserAccount informers
+// are started for the new member clusters and stopped for the removed ones
+const memberWatchRefreshPeriod = 10 * time.Second
+
+// memberWatchEventsBufferSize is the number of MasterUserRecord events that can be sent by the UserAccount informers
+// before they block until the controller picks the events up
+const memberWatchEventsBufferSize = 1024
+
+// NewCacheFunc creates a cache for the given member cluster
+type NewCacheFunc func(memberCluster *cluster.CachedToolchainCluster) (cache.Cache, error)
+
+// newMemberCache creates a cache of the resources in the operator namespace of the given member cluster
+func newMemberCache(scheme *runtime.Scheme) NewCacheFunc {
+	return func(memberCluster *cluster.CachedToolchainCluster) (cache.Cache, error) {
+		return cache.New(memberCluster.Config, cache.Options{
+			Scheme:    scheme,
+			Namespace: memberCluster.OperatorNamespace,
+		})
+	}
+}
+
+// memberWatcher runs a UserAccount informer for each member cluster in the cluster cache and turns the UserAccount events
+// into generic events of the MasterUserRecords with the same name, so the changes of the UserAccounts are propagated
+// to the MasterUserRecords without waiting for any other change.
+type memberWatcher struct {
+	namespace         string
+	getMemberClusters cluster.GetMemberClustersFunc
+	newCache          NewCacheFunc
+	events            chan event.GenericEvent
+	watches           map[string]memberWatch
+}
+
+// memberWatch is a UserAccount informer running for a member cluster
+type memberWatch struct {
+	key  memberWatchKey
+	stop chan struct{}
+	// failed is closed when the informer fails, so it is restarted on the next refresh
+	failed chan struct{}
+}
+
+func (w memberWatch) hasFailed() bool {
+	select {
+	case <-w.failed:
+		return true
+	default:
+		return false
+	}
+}
+
+// memberWatchKey contains the connection details of the member cluster the informer was started with.
+// The informer is restarted when any of them changes.
+type memberWatchKey struct {
+	apiEndpoint       string
+	operatorNamespace string
+	bearerToken       string
+}
+
+var _ manager.Runnable = &memberWatcher{}
+
+func newMemberWatcher(namespace string, getMemberClusters cluster.GetMemberClustersFunc, newCache NewCacheFunc) *memberWatcher {
+	return &memberWatcher{
+		namespace:         namespace,
+		getMemberClusters: getMemberClusters,
+		newCache:          newCache,
+		events:            make(chan event.GenericEvent, memberWatchEventsBufferSize),
+		watches:           map[string]memberWatch{},
+	}
+}
+
+// Start refreshes the UserAccount informers periodically until the given channel is closed. All the informers are then stopped.
+func (w *memberWatcher) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(memberWatchRefreshPeriod)
+	defer ticker.Stop()
+	for {
+		w.refresh()
+		select {
+		case <-stop:
+			for name := range w.watches {
+				w.stopWatch(name)
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh starts the UserAccount informers for the member clusters that are not watched yet (or whose connection details changed,
+// or whose informer failed) and stops the informers of the member clusters that are no longer in the cluster cache
+func (w *memberWatcher) refresh() {
+	found := map[string]bool{}
+	for _, memberCluster := range w.getMemberClusters() {
+		found[memberCluster.Name] = true
+		key := newMemberWatchKey(memberCluster)
+		if watch, ok := w.watches[memberCluster.Name]; ok {
+			if watch.hasFailed() {
+				log.Info("UserAccount informer failed, restarting it", "member_cluster", memberCluster.Name)
+			} else if watch.key != key {
+				log.Info("connection details of the member cluster changed, restarting the UserAccount informer", "member_cluster", memberCluster.Name)
+			} else {
+				continue
+			}
+			w.stopWatch(memberCluster.Name)
+		}
+		if err := w.startWatch(memberCluster, key); err != nil {
+			log.Error(err, "unable to start the UserAccount informer", "member_cluster", memberCluster.Name)
+		}
+	}
+	for name := range w.watches {
+		if !found[name] {
+			log.Info("member cluster removed, stopping the UserAccount informer", "member_cluster", name)
+			w.stopWatch(name)
+		}
+	}
+}
+
+func (w *memberWatcher) startWatch(memberCluster *cluster.CachedToolchainCluster, key memberWatchKey) error {
+	memberCache, err := w.newCache(memberCluster)
+	if err != nil {
+		return err
+	}
+	// the informer is obtained before the cache is started, so the call doesn't block until the cache is synced
+	informer, err := memberCache.GetInformer(context.TODO(), &toolchainv1alpha1.UserAccount{})
+	if err != nil {
+		return err
+	}
+	stop := make(chan struct{})
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.enqueue(stop, obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			w.enqueue(stop, obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			w.enqueue(stop, obj)
+		},
+	})
+	failed := make(chan struct{})
+	go func() {
+		if err := memberCache.Start(stop); err != nil {
+			log.Error(err, "UserAccount informer failed", "member_cluster", memberCluster.Name)
+			close(failed)
+		}
+	}()
+	w.watches[memberCluster.Name] = memberWatch{
+		key:    key,
+		stop:   stop,
+		failed: failed,
+	}
+	log.Info("started the UserAccount informer", "member_cluster", memberCluster.Name)
+	return nil
+}
+
+func (w *memberWatcher) stopWatch(name string) {
+	if watch, ok := w.watches[name]; ok {
+		close(watch.stop)
+		delete(w.watches, name)
+	}
+}
+
+// enqueue sends an event of the MasterUserRecord with the same name as the given UserAccount.
+// The event is dropped if the informer is stopped while waiting for the controller to pick the event up.
+func (w *memberWatcher) enqueue(stop <-chan struct{}, obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	userAccount, ok := obj.(*toolchainv1alpha1.UserAccount)
+	if !ok {
+		return
+	}
+	mur := &toolchainv1alpha1.MasterUserRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      userAccount.Name,
+			Namespace: w.namespace,
+		},
+	}
+	ev := event.GenericEvent{
+		Meta:   mur,
+		Object: mur,
+	}
+	select {
+	case w.events <- ev:
+	case <-stop:
+	}
+}
+
+func newMemberWatchKey(memberCluster *cluster.CachedToolchainCluster) memberWatchKey {
+	key := memberWatchKey{
+		apiEndpoint:       memberCluster.APIEndpoint,
+		operatorNamespace: memberCluster.OperatorNamespace,
+	}
+	if memberCluster.Config != nil {
+		key.bearerToken = memberCluster.Config.BearerToken
+	}
+	return key
+}
@@ -0,0 +1,242 @@
+package masteruserrecord
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestMemberWatcher(t *testing.T) {
+	// given
+	s := apiScheme(t)
+	member1 := NewMemberCluster(t, "member1", v1.ConditionTrue)
+	member2 := NewMemberCluster(t, "member2", v1.ConditionFalse)
+	userAccount := &toolchainv1alpha1.UserAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "john",
+			Namespace: test.MemberOperatorNs,
+		},
+	}
+
+	newWatcher := func(memberClusters ...*cluster.CachedToolchainCluster) (*memberWatcher, map[string][]*informertest.FakeInformers) {
+		caches := map[string][]*informertest.FakeInformers{}
+		watcher := newMemberWatcher(test.HostOperatorNs, NewGetMemberClusters(memberClusters...),
+			func(memberCluster *cluster.CachedToolchainCluster) (cache.Cache, error) {
+				memberCache := &informertest.FakeInformers{Scheme: s}
+				caches[memberCluster.Name] = append(caches[memberCluster.Name], memberCache)
+				return memberCache, nil
+			})
+		return watcher, caches
+	}
+
+	t.Run("informers started for all member clusters", func(t *testing.T) {
+		// given
+		watcher, caches := newWatcher(member1, member2)
+
+		// when
+		watcher.refresh()
+
+		// then
+		assert.Len(t, watcher.watches, 2)
+		require.Len(t, caches["member1"], 1)
+		require.Len(t, caches["member2"], 1)
+
+		t.Run("not restarted when nothing changed", func(t *testing.T) {
+			// when
+			watcher.refresh()
+
+			// then
+			assert.Len(t, watcher.watches, 2)
+			assert.Len(t, caches["member1"], 1)
+			assert.Len(t, caches["member2"], 1)
+		})
+	})
+
+	t.Run("UserAccount events are turned into MasterUserRecord events", func(t *testing.T) {
+		// given
+		watcher, caches := newWatcher(member1)
+		watcher.refresh()
+		informer := fakeUserAccountInformer(t, caches["member1"][0])
+
+		t.Run("added", func(t *testing.T) {
+			// when
+			go informer.Add(userAccount)
+
+			// then
+			assertMasterUserRecordEvent(t, watcher.events, "john")
+		})
+
+		t.Run("updated", func(t *testing.T) {
+			// when
+			go informer.Update(userAccount, userAccount)
+
+			// then
+			assertMasterUserRecordEvent(t, watcher.events, "john")
+		})
+
+		t.Run("deleted", func(t *testing.T) {
+			// when
+			go watcher.enqueue(watcher.watches["member1"].stop, toolscache.DeletedFinalStateUnknown{Key: "john", Obj: userAccount})
+
+			// then
+			assertMasterUserRecordEvent(t, watcher.events, "john")
+		})
+	})
+
+	t.Run("pending event dropped when the informer is stopped", func(t *testing.T) {
+		// given
+		watcher, _ := newWatcher(member1)
+		watcher.events = make(chan event.GenericEvent) // nobody picks the events up
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			watcher.enqueue(stop, userAccount)
+			close(done)
+		}()
+
+		// when
+		close(stop)
+
+		// then
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			require.FailNow(t, "the informer is blocked by the pending event")
+		}
+	})
+
+	t.Run("informer stopped when the member cluster is removed", func(t *testing.T) {
+		// given
+		watcher, _ := newWatcher(member1, member2)
+		watcher.refresh()
+		stop := watcher.watches["member2"].stop
+		watcher.getMemberClusters = NewGetMemberClusters(member1)
+
+		// when
+		watcher.refresh()
+
+		// then
+		assert.Len(t, watcher.watches, 1)
+		assert.Contains(t, watcher.watches, "member1")
+		assertClosed(t, stop)
+	})
+
+	t.Run("informer restarted when the connection details of the member cluster change", func(t *testing.T) {
+		// given
+		watcher, caches := newWatcher(member1)
+		watcher.refresh()
+		stop := watcher.watches["member1"].stop
+		changed := NewMemberCluster(t, "member1", v1.ConditionTrue)
+		changed.APIEndpoint = "https://api.member1.changed:6443"
+		watcher.getMemberClusters = NewGetMemberClusters(changed)
+
+		// when
+		watcher.refresh()
+
+		// then
+		assert.Len(t, watcher.watches, 1)
+		assert.Len(t, caches["member1"], 2)
+		assertClosed(t, stop)
+	})
+
+	t.Run("informer restarted when it failed", func(t *testing.T) {
+		// given
+		caches := map[string][]*informertest.FakeInformers{}
+		watcher := newMemberWatcher(test.HostOperatorNs, NewGetMemberClusters(member1),
+			func(memberCluster *cluster.CachedToolchainCluster) (cache.Cache, error) {
+				memberCache := &informertest.FakeInformers{Scheme: s}
+				caches[memberCluster.Name] = append(caches[memberCluster.Name], memberCache)
+				if len(caches[memberCluster.Name]) == 1 {
+					return failingCache{memberCache}, nil
+				}
+				return memberCache, nil
+			})
+		watcher.refresh()
+		failed := watcher.watches["member1"]
+		select {
+		case <-failed.failed:
+		case <-time.After(time.Second):
+			require.FailNow(t, "the informer didn't fail")
+		}
+
+		// when
+		watcher.refresh()
+
+		// then
+		assert.Len(t, watcher.watches, 1)
+		assert.Len(t, caches["member1"], 2)
+		assertClosed(t, failed.stop)
+		assert.False(t, watcher.watches["member1"].hasFailed())
+	})
+
+	t.Run("all informers stopped when the watcher is stopped", func(t *testing.T) {
+		// given
+		watcher, _ := newWatcher(member1, member2)
+		stop := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- watcher.Start(stop)
+		}()
+
+		// when
+		close(stop)
+
+		// then
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			require.FailNow(t, "the watcher was not stopped")
+		}
+		assert.Empty(t, watcher.watches)
+	})
+}
+
+// failingCache is a cache that fails to start
+type failingCache struct {
+	*informertest.FakeInformers
+}
+
+func (c failingCache) Start(_ <-chan struct{}) error {
+	return fmt.Errorf("some error")
+}
+
+func fakeUserAccountInformer(t *testing.T, memberCache *informertest.FakeInformers) *controllertest.FakeInformer {
+	informer, err := memberCache.FakeInformerFor(&toolchainv1alpha1.UserAccount{})
+	require.NoError(t, err)
+	return informer
+}
+
+func assertMasterUserRecordEvent(t *testing.T, events <-chan event.GenericEvent, name string) {
+	select {
+	case evt := <-events:
+		require.IsType(t, &toolchainv1alpha1.MasterUserRecord{}, evt.Object)
+		assert.Equal(t, name, evt.Meta.GetName())
+		assert.Equal(t, test.HostOperatorNs, evt.Meta.GetNamespace())
+	case <-time.After(time.Second):
+		require.FailNow(t, "no MasterUserRecord event received")
+	}
+}
+
+func assertClosed(t *testing.T, stop chan struct{}) {
+	select {
+	case <-stop:
+	default:
+		assert.Fail(t, "the informer was not stopped")
+	}
+}
@@ -0,0 +1,214 @@
+package masteruserrecord
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MigrationTargetAnnotationKey requests the migration of the UserAccount of the MasterUserRecord to the member cluster
+	// with the name stored in the value of the annotation. The member cluster the UserAccount is migrated from is defined
+	// by the clusterdrain.MigrationSourceLabelKey label. Both the annotation and the label are removed once the migration is over.
+	//
+	// The migration is requested by the annotation (and its phases are tracked by the MasterUserRecordMigrated condition)
+	// rather than by a dedicated UserMigrationRequest resource modelled on ChangeTierRequest, because the CRDs and their types
+	// are defined in the codeready-toolchain/api module. A UserMigrationRequest controller can create the annotation and
+	// mirror the condition once the resource is added there.
+	MigrationTargetAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "migration-target"
+
+	// MasterUserRecordMigrated is the type of the condition that tracks the phases of the last migration of the MasterUserRecord
+	MasterUserRecordMigrated toolchainv1alpha1.ConditionType = "Migrated"
+
+	// MasterUserRecordMigrationProvisioningReason is used while the UserAccount is being provisioned to the destination member cluster
+	MasterUserRecordMigrationProvisioningReason = "ProvisioningDestination"
+
+	// MasterUserRecordMigrationDeletingSourceReason is used while the UserAccount is being deleted from the source member cluster
+	MasterUserRecordMigrationDeletingSourceReason = "DeletingSource"
+
+	// MasterUserRecordMigratedReason is used when the UserAccount was migrated
+	MasterUserRecordMigratedReason = "Migrated"
+
+	// MasterUserRecordMigrationRollingBackReason is used while the UserAccount is being deleted from the destination member cluster
+	// as it didn't become ready in time
+	MasterUserRecordMigrationRollingBackReason = "RollingBack"
+
+	// MasterUserRecordMigrationRolledBackReason is used when the migration was rolled back
+	MasterUserRecordMigrationRolledBackReason = "RolledBack"
+
+	// MasterUserRecordMigrationInvalidReason is used when the migration request is invalid
+	MasterUserRecordMigrationInvalidReason = "InvalidMigrationRequest"
+)
+
+// migrate moves the UserAccount of the MasterUserRecord from the source to the destination member cluster of the requested migration.
+// The migration consists of these phases:
+// 1. the UserAccount is added to the MasterUserRecord for the destination member cluster (so it is provisioned there)
+// 2. when the UserAccount in the destination member cluster is ready (as synchronized by the Synchronizer), then the UserAccount
+// of the source member cluster is removed from the MasterUserRecord
+// 3. the UserAccount is deleted from the source member cluster and the migration request is removed from the MasterUserRecord
+// If the UserAccount doesn't become ready in the destination member cluster within the configured timeout, then it is removed
+// from the MasterUserRecord and deleted from the destination member cluster instead.
+//
+// Returns true as the second value if the MasterUserRecord was changed, so the current reconcile loop should end.
+// Returns non-zero duration as the first value when the controller should requeue to check the progress of the migration.
+func (r *ReconcileMasterUserRecord) migrate(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord) (time.Duration, bool, error) {
+	destination, found := mur.Annotations[MigrationTargetAnnotationKey]
+	if !found {
+		return 0, false, nil
+	}
+	source := mur.Labels[clusterdrain.MigrationSourceLabelKey]
+	logger = logger.WithValues("source_cluster", source, "destination_cluster", destination)
+	hasSource := clusterdrain.HasUserAccountIn(mur, source)
+	hasDestination := clusterdrain.HasUserAccountIn(mur, destination)
+	migration, _ := condition.FindConditionByType(mur.Status.Conditions, MasterUserRecordMigrated)
+
+	switch {
+	case source == "" || source == destination:
+		return 0, true, r.endMigration(logger, mur, source, toBeNotMigrated(MasterUserRecordMigrationInvalidReason,
+			fmt.Sprintf("the UserAccount can't be migrated from '%s' to '%s'", source, destination)))
+
+	case hasSource && !hasDestination && migration.Reason == MasterUserRecordMigrationRollingBackReason:
+		// phase 2 of the rollback: delete the UserAccount from the destination member cluster
+		return r.deleteMigratedUserAccount(logger, mur, destination, source, toBeNotMigrated(MasterUserRecordMigrationRolledBackReason, migration.Message))
+
+	case hasSource && !hasDestination:
+		// phase 1: provision the UserAccount to the destination member cluster
+		logger.Info("migrating the UserAccount - provisioning the destination member cluster")
+		for _, ua := range mur.Spec.UserAccounts {
+			if ua.TargetCluster == source {
+				mur.Spec.UserAccounts = append(mur.Spec.UserAccounts, toolchainv1alpha1.UserAccountEmbedded{
+					TargetCluster: destination,
+					Spec:          ua.Spec,
+				})
+				break
+			}
+		}
+		if err := r.client.Update(context.TODO(), mur); err != nil {
+			return 0, false, errs.Wrapf(err, "failed to add the UserAccount for the destination member cluster '%s'", destination)
+		}
+		return r.config.GetMasterUserRecordMigrationTimeout(), true, r.setMigrationStarted(logger, mur, destination)
+
+	case hasSource && hasDestination:
+		// phase 2: wait until the UserAccount is ready in the destination member cluster, then remove the source UserAccount from the MasterUserRecord
+		if uaStatus, index := getUserAccountStatus(destination, mur); index >= 0 && condition.IsTrue(uaStatus.Conditions, toolchainv1alpha1.ConditionReady) {
+			logger.Info("migrating the UserAccount - the destination member cluster is ready, removing the source member cluster")
+			removeUserAccount(mur, source)
+			if err := r.client.Update(context.TODO(), mur); err != nil {
+				return 0, false, errs.Wrapf(err, "failed to remove the UserAccount for the source member cluster '%s'", source)
+			}
+			return 0, true, updateStatusConditions(logger, r.client, mur,
+				toBeMigrating(MasterUserRecordMigrationDeletingSourceReason, fmt.Sprintf("deleting the UserAccount from '%s'", source)))
+		}
+		if migration.Reason != MasterUserRecordMigrationProvisioningReason || migration.LastUpdatedTime == nil {
+			// the status update failed when the migration started, so let's (re)start the timeout now
+			return r.config.GetMasterUserRecordMigrationTimeout(), false, r.setMigrationStarted(logger, mur, destination)
+		}
+		remaining := r.config.GetMasterUserRecordMigrationTimeout() - time.Since(migration.LastUpdatedTime.Time)
+		if remaining > 0 {
+			logger.Info("migrating the UserAccount - waiting for the destination member cluster", "remaining", remaining)
+			return remaining, false, nil
+		}
+		// the UserAccount isn't ready in time, so roll back: remove the destination UserAccount from the MasterUserRecord
+		logger.Info("rolling back the migration as the UserAccount isn't ready in the destination member cluster in time")
+		removeUserAccount(mur, destination)
+		if err := r.client.Update(context.TODO(), mur); err != nil {
+			return 0, false, errs.Wrapf(err, "failed to remove the UserAccount for the destination member cluster '%s'", destination)
+		}
+		return 0, true, updateStatusConditions(logger, r.client, mur, toBeMigrating(MasterUserRecordMigrationRollingBackReason,
+			fmt.Sprintf("the UserAccount didn't become ready in '%s' within %s", destination, r.config.GetMasterUserRecordMigrationTimeout())))
+
+	case hasDestination:
+		// phase 3: delete the UserAccount from the source member cluster
+		return r.deleteMigratedUserAccount(logger, mur, source, source, toBeMigrated())
+
+	default:
+		return 0, true, r.endMigration(logger, mur, source, toBeNotMigrated(MasterUserRecordMigrationInvalidReason,
+			fmt.Sprintf("the MasterUserRecord doesn't contain any UserAccount in '%s'", source)))
+	}
+}
+
+// setMigrationStarted sets the condition of the first phase of the migration. The time of the start is stored in the LastUpdatedTime
+// of the condition, so the timeout of the migration can be checked
+func (r *ReconcileMasterUserRecord) setMigrationStarted(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord, destination string) error {
+	logger.Info("updating MUR status conditions", "reason", MasterUserRecordMigrationProvisioningReason)
+	mur.Status.Conditions = condition.AddOrUpdateStatusConditionsWithLastUpdatedTimestamp(mur.Status.Conditions,
+		toBeMigrating(MasterUserRecordMigrationProvisioningReason, fmt.Sprintf("provisioning the UserAccount to '%s'", destination)))
+	return r.client.Status().Update(context.TODO(), mur)
+}
+
+// deleteMigratedUserAccount deletes the UserAccount from the given member cluster that is not part of the MasterUserRecord anymore.
+// When the deletion is complete, then it ends the migration with the given condition.
+func (r *ReconcileMasterUserRecord) deleteMigratedUserAccount(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord, clusterName, source string,
+	result toolchainv1alpha1.Condition) (time.Duration, bool, error) {
+	requeueTime, err := r.deleteUserAccount(logger, clusterName, mur)
+	if err != nil {
+		return 0, false, r.wrapErrorWithStatusUpdate(logger, mur, r.setStatusFailed(deletionFailureReason(err)), err,
+			"failed to delete UserAccount in the member cluster '%s'", clusterName)
+	} else if requeueTime > 0 {
+		return requeueTime, true, nil
+	}
+	return 0, true, r.endMigration(logger, mur, source, result)
+}
+
+// endMigration removes the migration request from the MasterUserRecord as well as the status of the UserAccount that was removed
+// and sets the given condition
+func (r *ReconcileMasterUserRecord) endMigration(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord, source string, result toolchainv1alpha1.Condition) error {
+	logger.Info("migration of the UserAccount is over", "reason", result.Reason)
+	delete(mur.Annotations, MigrationTargetAnnotationKey)
+	delete(mur.Labels, clusterdrain.MigrationSourceLabelKey)
+	if err := r.client.Update(context.TODO(), mur); err != nil {
+		return errs.Wrap(err, "failed to remove the migration request from the MasterUserRecord")
+	}
+	var statuses []toolchainv1alpha1.UserAccountStatusEmbedded
+	for _, uaStatus := range mur.Status.UserAccounts {
+		if clusterdrain.HasUserAccountIn(mur, uaStatus.Cluster.Name) {
+			statuses = append(statuses, uaStatus)
+		}
+	}
+	mur.Status.UserAccounts = statuses
+	return updateStatusConditions(logger, r.client, mur, result)
+}
+
+func removeUserAccount(mur *toolchainv1alpha1.MasterUserRecord, clusterName string) {
+	var accounts []toolchainv1alpha1.UserAccountEmbedded
+	for _, ua := range mur.Spec.UserAccounts {
+		if ua.TargetCluster != clusterName {
+			accounts = append(accounts, ua)
+		}
+	}
+	mur.Spec.UserAccounts = accounts
+}
+
+func toBeMigrating(reason, msg string) toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:    MasterUserRecordMigrated,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: msg,
+	}
+}
+
+func toBeMigrated() toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:   MasterUserRecordMigrated,
+		Status: corev1.ConditionTrue,
+		Reason: MasterUserRecordMigratedReason,
+	}
+}
+
+func toBeNotMigrated(reason, msg string) toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:    MasterUserRecordMigrated,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: msg,
+	}
+}
@@ -0,0 +1,262 @@
+package masteruserrecord
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	uatest "github.com/codeready-toolchain/toolchain-common/pkg/test/useraccount"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestMigrateUserAccount(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	newMigratedMur := func(t *testing.T) *toolchainv1alpha1.MasterUserRecord {
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"))
+		mur.Labels = map[string]string{clusterdrain.MigrationSourceLabelKey: test.MemberClusterName}
+		mur.Annotations = map[string]string{MigrationTargetAnnotationKey: "member2-cluster"}
+		return mur
+	}
+	newToolchainStatus := func() *toolchainv1alpha1.ToolchainStatus {
+		return NewToolchainStatus(
+			WithHost(WithMasterUserRecordCount(1)),
+			WithMember(test.MemberClusterName, WithUserAccountCount(1), WithRoutes("https://console.member-cluster/", "", ToBeReady())),
+			WithMember("member2-cluster", WithRoutes("https://console.member2-cluster/", "", ToBeReady())))
+	}
+
+	t.Run("UserAccount is migrated to the destination member cluster", func(t *testing.T) {
+		// given
+		mur := newMigratedMur(t)
+		toolchainStatus := newToolchainStatus()
+		memberClient := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		memberClient2 := test.NewFakeClient(t)
+		hostClient := test.NewFakeClient(t, mur, toolchainStatus)
+		InitializeCounters(t, toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient), ClusterClient("member2-cluster", memberClient2))
+
+		// when the migration starts
+		result, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then the UserAccount is added for the destination member cluster
+		require.NoError(t, err)
+		assert.True(t, result.Requeue)
+		assert.Equal(t, 10*time.Minute, result.RequeueAfter)
+		assertMigrationCondition(t, hostClient, "john", v1.ConditionFalse, MasterUserRecordMigrationProvisioningReason)
+		assertSpecUserAccounts(t, hostClient, "john", test.MemberClusterName, "member2-cluster")
+
+		t.Run("UserAccount is provisioned to the destination member cluster", func(t *testing.T) {
+			// when
+			result, err := cntrl.Reconcile(newMurRequest(mur))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, result.Requeue)
+			assert.True(t, result.RequeueAfter > 0 && result.RequeueAfter <= 10*time.Minute)
+			uatest.AssertThatUserAccount(t, "john", memberClient2).
+				Exists()
+			assertSpecUserAccounts(t, hostClient, "john", test.MemberClusterName, "member2-cluster")
+			AssertThatCounters(t).HaveMasterUserRecords(1).
+				HaveUserAccountsForCluster(test.MemberClusterName, 1).
+				HaveUserAccountsForCluster("member2-cluster", 1)
+
+			t.Run("source UserAccount is removed when the destination is ready", func(t *testing.T) {
+				// given
+				setUserAccountReady(t, memberClient2, "john")
+				_, err := cntrl.Reconcile(newMurRequest(mur)) // synchronizes the status of the destination UserAccount
+				require.NoError(t, err)
+
+				// when
+				_, err = cntrl.Reconcile(newMurRequest(mur))
+
+				// then
+				require.NoError(t, err)
+				assertMigrationCondition(t, hostClient, "john", v1.ConditionFalse, MasterUserRecordMigrationDeletingSourceReason)
+				assertSpecUserAccounts(t, hostClient, "john", "member2-cluster")
+
+				t.Run("migration is complete when the source UserAccount is deleted", func(t *testing.T) {
+					// when
+					result, err := cntrl.Reconcile(newMurRequest(mur))
+					require.NoError(t, err)
+					assert.True(t, result.Requeue)
+					_, err = cntrl.Reconcile(newMurRequest(mur))
+
+					// then
+					require.NoError(t, err)
+					uatest.AssertThatUserAccount(t, "john", memberClient).
+						DoesNotExist()
+					uatest.AssertThatUserAccount(t, "john", memberClient2).
+						Exists()
+					assertMigrationCondition(t, hostClient, "john", v1.ConditionTrue, MasterUserRecordMigratedReason)
+					murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+						DoesNotHaveLabel(clusterdrain.MigrationSourceLabelKey).
+						HasStatusUserAccounts("member2-cluster").
+						HasFinalizer()
+					assert.NotContains(t, getMur(t, hostClient, "john").Annotations, MigrationTargetAnnotationKey)
+					AssertThatCounters(t).HaveMasterUserRecords(1).
+						HaveUserAccountsForCluster(test.MemberClusterName, 0).
+						HaveUserAccountsForCluster("member2-cluster", 1)
+				})
+			})
+		})
+	})
+
+	t.Run("migration is rolled back when the destination is not ready in time", func(t *testing.T) {
+		// given
+		mur := newMigratedMur(t)
+		murtest.AdditionalAccounts("member2-cluster")(mur)
+		mur.Status.Conditions = []toolchainv1alpha1.Condition{
+			{
+				Type:            MasterUserRecordMigrated,
+				Status:          v1.ConditionFalse,
+				Reason:          MasterUserRecordMigrationProvisioningReason,
+				LastUpdatedTime: &metav1.Time{Time: time.Now().Add(-11 * time.Minute)},
+			},
+		}
+		toolchainStatus := newToolchainStatus()
+		memberClient := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		memberClient2 := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		hostClient := test.NewFakeClient(t, mur, toolchainStatus)
+		InitializeCounters(t, NewToolchainStatus(
+			WithHost(WithMasterUserRecordCount(1)),
+			WithMember(test.MemberClusterName, WithUserAccountCount(1)),
+			WithMember("member2-cluster", WithUserAccountCount(1))))
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient), ClusterClient("member2-cluster", memberClient2))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		assertMigrationCondition(t, hostClient, "john", v1.ConditionFalse, MasterUserRecordMigrationRollingBackReason)
+		assertSpecUserAccounts(t, hostClient, "john", test.MemberClusterName)
+
+		t.Run("rollback is complete when the destination UserAccount is deleted", func(t *testing.T) {
+			// when
+			result, err := cntrl.Reconcile(newMurRequest(mur))
+			require.NoError(t, err)
+			assert.True(t, result.Requeue)
+			_, err = cntrl.Reconcile(newMurRequest(mur))
+
+			// then
+			require.NoError(t, err)
+			uatest.AssertThatUserAccount(t, "john", memberClient).
+				Exists()
+			uatest.AssertThatUserAccount(t, "john", memberClient2).
+				DoesNotExist()
+			assertMigrationCondition(t, hostClient, "john", v1.ConditionFalse, MasterUserRecordMigrationRolledBackReason)
+			murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+				DoesNotHaveLabel(clusterdrain.MigrationSourceLabelKey)
+			AssertThatCounters(t).HaveMasterUserRecords(1).
+				HaveUserAccountsForCluster(test.MemberClusterName, 1).
+				HaveUserAccountsForCluster("member2-cluster", 0)
+		})
+	})
+
+	t.Run("migration waits for the destination until the timeout", func(t *testing.T) {
+		// given
+		mur := newMigratedMur(t)
+		murtest.AdditionalAccounts("member2-cluster")(mur)
+		mur.Status.Conditions = []toolchainv1alpha1.Condition{
+			{
+				Type:            MasterUserRecordMigrated,
+				Status:          v1.ConditionFalse,
+				Reason:          MasterUserRecordMigrationProvisioningReason,
+				LastUpdatedTime: &metav1.Time{Time: time.Now().Add(-9 * time.Minute)},
+			},
+		}
+		toolchainStatus := newToolchainStatus()
+		memberClient := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		memberClient2 := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		hostClient := test.NewFakeClient(t, mur, toolchainStatus)
+		InitializeCounters(t, toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient), ClusterClient("member2-cluster", memberClient2))
+
+		// when
+		result, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, result.Requeue)
+		assert.True(t, result.RequeueAfter > 0 && result.RequeueAfter <= time.Minute)
+		assertMigrationCondition(t, hostClient, "john", v1.ConditionFalse, MasterUserRecordMigrationProvisioningReason)
+		assertSpecUserAccounts(t, hostClient, "john", test.MemberClusterName, "member2-cluster")
+	})
+
+	t.Run("invalid migration request is removed", func(t *testing.T) {
+		// given
+		mur := newMigratedMur(t)
+		mur.Annotations[MigrationTargetAnnotationKey] = test.MemberClusterName
+		toolchainStatus := newToolchainStatus()
+		memberClient := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		hostClient := test.NewFakeClient(t, mur, toolchainStatus)
+		InitializeCounters(t, toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		assertMigrationCondition(t, hostClient, "john", v1.ConditionFalse, MasterUserRecordMigrationInvalidReason)
+		assertSpecUserAccounts(t, hostClient, "john", test.MemberClusterName)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			DoesNotHaveLabel(clusterdrain.MigrationSourceLabelKey)
+		assert.NotContains(t, getMur(t, hostClient, "john").Annotations, MigrationTargetAnnotationKey)
+	})
+}
+
+func getMur(t *testing.T, cl client.Client, name string) *toolchainv1alpha1.MasterUserRecord {
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: test.HostOperatorNs, Name: name}, mur)
+	require.NoError(t, err)
+	return mur
+}
+
+func assertMigrationCondition(t *testing.T, cl client.Client, name string, status v1.ConditionStatus, reason string) {
+	migration, found := condition.FindConditionByType(getMur(t, cl, name).Status.Conditions, MasterUserRecordMigrated)
+	require.True(t, found)
+	assert.Equal(t, status, migration.Status)
+	assert.Equal(t, reason, migration.Reason)
+}
+
+func assertSpecUserAccounts(t *testing.T, cl client.Client, name string, targetClusters ...string) {
+	mur := getMur(t, cl, name)
+	require.Len(t, mur.Spec.UserAccounts, len(targetClusters))
+	for i, targetCluster := range targetClusters {
+		assert.Equal(t, targetCluster, mur.Spec.UserAccounts[i].TargetCluster)
+	}
+}
+
+func setUserAccountReady(t *testing.T, cl client.Client, name string) {
+	userAcc := &toolchainv1alpha1.UserAccount{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: test.MemberOperatorNs, Name: name}, userAcc)
+	require.NoError(t, err)
+	userAcc.Status.Conditions = []toolchainv1alpha1.Condition{
+		{
+			Type:   toolchainv1alpha1.ConditionReady,
+			Status: v1.ConditionTrue,
+			Reason: "Provisioned",
+		},
+	}
+	require.NoError(t, cl.Status().Update(context.TODO(), userAcc))
+}
@@ -0,0 +1,162 @@
+package masteruserrecord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MasterUserRecordOrphanAnnotationKey can be set on a MasterUserRecord with a comma-separated list of member clusters.
+	// When the MasterUserRecord is deleted and any of these member clusters is unavailable, then the UserAccount in it is orphaned
+	// right away instead of waiting for the grace period.
+	MasterUserRecordOrphanAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "orphan-member-clusters"
+
+	// MasterUserRecordUnavailableClustersAnnotationKey contains the JSON encoded map of the member clusters that were unavailable
+	// while the MasterUserRecord was being deleted, see unavailableCluster
+	MasterUserRecordUnavailableClustersAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "unavailable-member-clusters"
+
+	// UserAccountOrphanedReason is the reason of the event emitted when the UserAccount in an unavailable member cluster was orphaned
+	UserAccountOrphanedReason = "UserAccountOrphaned"
+)
+
+// unavailableCluster is a member cluster that was unavailable while the MasterUserRecord was being deleted
+type unavailableCluster struct {
+	// Since is the time when the member cluster was found unavailable for the first time
+	Since metav1.Time `json:"since"`
+	// Orphaned is true if the UserAccount in the member cluster was orphaned
+	Orphaned bool `json:"orphaned,omitempty"`
+}
+
+// getUnavailableClusters returns the member clusters recorded as unavailable in the annotation of the given MasterUserRecord
+func getUnavailableClusters(mur *toolchainv1alpha1.MasterUserRecord) (map[string]unavailableCluster, error) {
+	clusters := map[string]unavailableCluster{}
+	value, found := mur.Annotations[MasterUserRecordUnavailableClustersAnnotationKey]
+	if !found || value == "" {
+		return clusters, nil
+	}
+	if err := json.Unmarshal([]byte(value), &clusters); err != nil {
+		return nil, errs.Wrapf(err, "unable to parse the annotation '%s'", MasterUserRecordUnavailableClustersAnnotationKey)
+	}
+	return clusters, nil
+}
+
+// setUnavailableClusters stores the given member clusters in the annotation of the given MasterUserRecord and updates the resource
+func (r *ReconcileMasterUserRecord) setUnavailableClusters(mur *toolchainv1alpha1.MasterUserRecord, clusters map[string]unavailableCluster) error {
+	if len(clusters) == 0 {
+		delete(mur.Annotations, MasterUserRecordUnavailableClustersAnnotationKey)
+	} else {
+		value, err := json.Marshal(clusters)
+		if err != nil {
+			return errs.Wrapf(err, "unable to encode the annotation '%s'", MasterUserRecordUnavailableClustersAnnotationKey)
+		}
+		if mur.Annotations == nil {
+			mur.Annotations = map[string]string{}
+		}
+		mur.Annotations[MasterUserRecordUnavailableClustersAnnotationKey] = string(value)
+	}
+	return r.client.Update(context.TODO(), mur)
+}
+
+// isOrphanRequested returns true if the given member cluster is listed in the orphan annotation of the given MasterUserRecord
+func isOrphanRequested(mur *toolchainv1alpha1.MasterUserRecord, targetCluster string) bool {
+	for _, name := range strings.Split(mur.Annotations[MasterUserRecordOrphanAnnotationKey], ",") {
+		if strings.TrimSpace(name) == targetCluster {
+			return true
+		}
+	}
+	return false
+}
+
+// isOrphaned returns true if the UserAccount in the given member cluster was already orphaned
+func isOrphaned(mur *toolchainv1alpha1.MasterUserRecord, targetCluster string) (bool, error) {
+	clusters, err := getUnavailableClusters(mur)
+	if err != nil {
+		return false, err
+	}
+	return clusters[targetCluster].Orphaned, nil
+}
+
+// handleUnavailableCluster is called when the given member cluster is unavailable during the deletion of the MasterUserRecord.
+// The UserAccount in the member cluster is orphaned (ie, the MasterUserRecord no longer waits for its deletion) if the member cluster
+// is listed in the orphan annotation or if it has been unavailable for longer than the grace period.
+// Returns true if the UserAccount was orphaned.
+func (r *ReconcileMasterUserRecord) handleUnavailableCluster(logger logr.Logger, mur *toolchainv1alpha1.MasterUserRecord, account toolchainv1alpha1.UserAccountEmbedded, cause error) (bool, error) {
+	clusters, err := getUnavailableClusters(mur)
+	if err != nil {
+		return false, err
+	}
+	unavailable, found := clusters[account.TargetCluster]
+	if !found {
+		unavailable = unavailableCluster{Since: metav1.Now()}
+	}
+	gracePeriod := r.config.GetMasterUserRecordOrphanGracePeriod()
+	if !isOrphanRequested(mur, account.TargetCluster) && time.Since(unavailable.Since.Time) < gracePeriod {
+		if !found {
+			clusters[account.TargetCluster] = unavailable
+			if err := r.setUnavailableClusters(mur, clusters); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	unavailable.Orphaned = true
+	clusters[account.TargetCluster] = unavailable
+	if err := r.setUnavailableClusters(mur, clusters); err != nil {
+		return false, err
+	}
+	// the UserAccount (and the weight applied for it) can't be read from the unavailable member cluster, but its tier was synchronized
+	// from the MasterUserRecord, so the weight of the same tier is subtracted
+	counter.DecrementUserAccountCount(logger, account.TargetCluster, r.getWeight(logger, mur.Namespace, account.Spec.NSTemplateSet.TierName))
+	metrics.UserAccountOrphanedCounterVec.WithLabelValues(account.TargetCluster).Inc()
+	logger.Info("UserAccount orphaned", "member_cluster", account.TargetCluster, "unavailable_since", unavailable.Since, "cause", cause.Error())
+	r.recorder.Event(mur, corev1.EventTypeWarning, UserAccountOrphanedReason,
+		fmt.Sprintf("UserAccount in the member cluster '%s' was orphaned and needs to be deleted manually: %s", account.TargetCluster, cause.Error()))
+	return true, nil
+}
+
+// clearUnavailableCluster removes the given member cluster from the unavailable ones, since it is available again
+func (r *ReconcileMasterUserRecord) clearUnavailableCluster(mur *toolchainv1alpha1.MasterUserRecord, targetCluster string) error {
+	clusters, err := getUnavailableClusters(mur)
+	if err != nil {
+		return err
+	}
+	if _, found := clusters[targetCluster]; !found {
+		return nil
+	}
+	delete(clusters, targetCluster)
+	return r.setUnavailableClusters(mur, clusters)
+}
+
+// deleteOrOrphanUserAccount deletes the UserAccount in the target cluster of the given account, or orphans it if the member cluster
+// is unavailable, see handleUnavailableCluster
+func (r *ReconcileMasterUserRecord) deleteOrOrphanUserAccount(logger logr.Logger, account toolchainv1alpha1.UserAccountEmbedded, mur *toolchainv1alpha1.MasterUserRecord) (time.Duration, error) {
+	orphaned, err := isOrphaned(mur, account.TargetCluster)
+	if err != nil || orphaned {
+		return 0, err
+	}
+	if _, err := r.getMemberCluster(account.TargetCluster); err != nil {
+		if orphaned, orphanErr := r.handleUnavailableCluster(logger, mur, account, err); orphanErr != nil {
+			return 0, orphanErr
+		} else if orphaned {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if err := r.clearUnavailableCluster(mur, account.TargetCluster); err != nil {
+		return 0, err
+	}
+	return r.deleteUserAccount(logger, account.TargetCluster, mur)
+}
@@ -0,0 +1,210 @@
+package masteruserrecord
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	uatest "github.com/codeready-toolchain/toolchain-common/pkg/test/useraccount"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestOrphanUserAccountInUnavailableMemberCluster(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember(test.MemberClusterName, WithUserAccountCount(1)),
+		WithMember("member2-cluster", WithUserAccountCount(1)))
+	msg := "the member cluster member-cluster not found in the registry"
+	orphanedEvent := "Warning UserAccountOrphaned UserAccount in the member cluster 'member-cluster' was orphaned and needs to be deleted manually: " + msg
+
+	t.Run("not orphaned within the grace period", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"), murtest.ToBeDeleted())
+		hostClient := test.NewFakeClient(t, mur)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.EqualError(t, err, "failed to delete UserAccount in the member cluster 'member-cluster': "+msg)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			HasConditions(toBeNotReady(toolchainv1alpha1.MasterUserRecordUnableToDeleteUserAccountsReason, msg)).
+			HasFinalizer()
+		clusters := getUnavailableClustersOf(t, hostClient)
+		require.Contains(t, clusters, test.MemberClusterName)
+		assert.False(t, clusters[test.MemberClusterName].Orphaned)
+		assert.WithinDuration(t, time.Now(), clusters[test.MemberClusterName].Since.Time, time.Minute)
+		AssertThatCounters(t).HaveUserAccountsForCluster(test.MemberClusterName, 1)
+		AssertMetricsCounterEquals(t, 0, metrics.UserAccountOrphanedCounterVec.WithLabelValues(test.MemberClusterName))
+		assert.NotContains(t, drainEvents(cntrl), orphanedEvent)
+
+		t.Run("time of the first unavailability is kept", func(t *testing.T) {
+			// given
+			since := clusters[test.MemberClusterName].Since
+
+			// when
+			_, err := cntrl.Reconcile(newMurRequest(mur))
+
+			// then
+			require.Error(t, err)
+			assert.Equal(t, since.Unix(), getUnavailableClustersOf(t, hostClient)[test.MemberClusterName].Since.Unix())
+		})
+	})
+
+	t.Run("orphaned when the grace period is exceeded", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"), murtest.ToBeDeleted())
+		mur.Annotations = map[string]string{
+			MasterUserRecordUnavailableClustersAnnotationKey: unavailableSince(test.MemberClusterName, time.Now().Add(-25*time.Hour)),
+		}
+		hostClient := test.NewFakeClient(t, mur)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			DoesNotHaveFinalizer()
+		AssertThatCounters(t).HaveUserAccountsForCluster(test.MemberClusterName, 0)
+		AssertMetricsCounterEquals(t, 1, metrics.UserAccountOrphanedCounterVec.WithLabelValues(test.MemberClusterName))
+		assert.Contains(t, drainEvents(cntrl), orphanedEvent)
+	})
+
+	t.Run("grace period is configurable", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_MASTERUSERRECORD_ORPHAN_GRACEPERIOD", "72h")
+		defer restore()
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"), murtest.ToBeDeleted())
+		mur.Annotations = map[string]string{
+			MasterUserRecordUnavailableClustersAnnotationKey: unavailableSince(test.MemberClusterName, time.Now().Add(-25*time.Hour)),
+		}
+		hostClient := test.NewFakeClient(t, mur)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.Error(t, err)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			HasFinalizer()
+		AssertThatCounters(t).HaveUserAccountsForCluster(test.MemberClusterName, 1)
+	})
+
+	t.Run("orphaned right away when requested by the annotation", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"), murtest.ToBeDeleted())
+		mur.Annotations = map[string]string{
+			MasterUserRecordOrphanAnnotationKey: "member3-cluster, member-cluster",
+		}
+		hostClient := test.NewFakeClient(t, mur)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			DoesNotHaveFinalizer()
+		AssertThatCounters(t).HaveUserAccountsForCluster(test.MemberClusterName, 0)
+		AssertMetricsCounterEquals(t, 1, metrics.UserAccountOrphanedCounterVec.WithLabelValues(test.MemberClusterName))
+		assert.Contains(t, drainEvents(cntrl), orphanedEvent)
+	})
+
+	t.Run("orphaned only once while waiting for the deletion in the other member cluster", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"), murtest.ToBeDeleted(),
+			murtest.AdditionalAccounts("member2-cluster"))
+		mur.Annotations = map[string]string{
+			MasterUserRecordOrphanAnnotationKey: test.MemberClusterName,
+		}
+		memberClient2 := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		hostClient := test.NewFakeClient(t, mur)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient("member2-cluster", memberClient2))
+
+		// when
+		result1, err1 := cntrl.Reconcile(newMurRequest(mur)) // waits for the deletion in member2-cluster
+		result2, err2 := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err1)
+		assertDeletionRequeueAfter(t, 10*time.Second, result1.RequeueAfter)
+		require.NoError(t, err2)
+		assert.Empty(t, result2)
+		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
+			DoesNotHaveFinalizer()
+		uatest.AssertThatUserAccount(t, "john", memberClient2).
+			DoesNotExist()
+		AssertThatCounters(t).
+			HaveUserAccountsForCluster(test.MemberClusterName, 0).
+			HaveUserAccountsForCluster("member2-cluster", 0)
+		AssertMetricsCounterEquals(t, 1, metrics.UserAccountOrphanedCounterVec.WithLabelValues(test.MemberClusterName))
+	})
+
+	t.Run("not orphaned when the member cluster is available again", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, toolchainStatus)
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"), murtest.ToBeDeleted())
+		mur.Annotations = map[string]string{
+			MasterUserRecordUnavailableClustersAnnotationKey: unavailableSince(test.MemberClusterName, time.Now().Add(-25*time.Hour)),
+		}
+		memberClient := test.NewFakeClient(t, uatest.NewUserAccountFromMur(mur))
+		hostClient := test.NewFakeClient(t, mur)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		uatest.AssertThatUserAccount(t, "john", memberClient).
+			DoesNotExist()
+		assert.Empty(t, getUnavailableClustersOf(t, hostClient))
+		AssertMetricsCounterEquals(t, 0, metrics.UserAccountOrphanedCounterVec.WithLabelValues(test.MemberClusterName))
+	})
+}
+
+func unavailableSince(clusterName string, since time.Time) string {
+	return fmt.Sprintf(`{"%s":{"since":"%s"}}`, clusterName, since.UTC().Format(time.RFC3339))
+}
+
+func getUnavailableClustersOf(t *testing.T, hostClient client.Client) map[string]unavailableCluster {
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	err := hostClient.Get(context.TODO(), namespacedName(test.HostOperatorNs, "john"), mur)
+	require.NoError(t, err)
+	clusters, err := getUnavailableClusters(mur)
+	require.NoError(t, err)
+	return clusters
+}
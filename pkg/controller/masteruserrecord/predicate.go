@@ -0,0 +1,24 @@
+package masteruserrecord
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	controllerPredicate "sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// MasterUserRecordChangedPredicate filters the update events of MasterUserRecords
+type MasterUserRecordChangedPredicate struct {
+	controllerPredicate.Funcs
+}
+
+// Update filters update events and let the reconcile loop to be triggered when any of the following conditions is met:
+//
+// * generation number has changed
+//
+// * annotation toolchain.dev.openshift.com/migration-target has changed
+func (p MasterUserRecordChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.MetaOld == nil || e.MetaNew == nil {
+		return false
+	}
+	return e.MetaNew.GetGeneration() != e.MetaOld.GetGeneration() ||
+		e.MetaOld.GetAnnotations()[MigrationTargetAnnotationKey] != e.MetaNew.GetAnnotations()[MigrationTargetAnnotationKey]
+}
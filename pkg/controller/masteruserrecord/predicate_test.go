@@ -0,0 +1,80 @@
+package masteruserrecord
+
+import (
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestMasterUserRecordChangedPredicate(t *testing.T) {
+	// given
+	pred := &MasterUserRecordChangedPredicate{}
+	newMur := func(generation int64, annotations map[string]string) *toolchainv1alpha1.MasterUserRecord {
+		return &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "john",
+				Namespace:   test.HostOperatorNs,
+				Generation:  generation,
+				Annotations: annotations,
+			},
+		}
+	}
+	murOld := newMur(1, nil)
+
+	t.Run("generation changed", func(t *testing.T) {
+		// given
+		murNew := newMur(2, nil)
+
+		// when
+		ok := pred.Update(event.UpdateEvent{ObjectOld: murOld, MetaOld: murOld, ObjectNew: murNew, MetaNew: murNew})
+
+		// then
+		assert.True(t, ok)
+	})
+
+	t.Run("migration target annotation changed", func(t *testing.T) {
+		// given
+		murNew := newMur(1, map[string]string{MigrationTargetAnnotationKey: "member2-cluster"})
+
+		// when
+		ok := pred.Update(event.UpdateEvent{ObjectOld: murOld, MetaOld: murOld, ObjectNew: murNew, MetaNew: murNew})
+
+		// then
+		assert.True(t, ok)
+	})
+
+	t.Run("migration target annotation removed", func(t *testing.T) {
+		// given
+		murWithAnnotation := newMur(1, map[string]string{MigrationTargetAnnotationKey: "member2-cluster"})
+
+		// when
+		ok := pred.Update(event.UpdateEvent{ObjectOld: murWithAnnotation, MetaOld: murWithAnnotation, ObjectNew: murOld, MetaNew: murOld})
+
+		// then
+		assert.True(t, ok)
+	})
+
+	t.Run("other annotation changed", func(t *testing.T) {
+		// given
+		murNew := newMur(1, map[string]string{"foo": "bar"})
+
+		// when
+		ok := pred.Update(event.UpdateEvent{ObjectOld: murOld, MetaOld: murOld, ObjectNew: murNew, MetaNew: murNew})
+
+		// then
+		assert.False(t, ok)
+	})
+
+	t.Run("missing metadata", func(t *testing.T) {
+		// when
+		ok := pred.Update(event.UpdateEvent{ObjectOld: murOld, ObjectNew: murOld, MetaNew: murOld})
+
+		// then
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,38 @@
+package masteruserrecord
+
+import (
+	"strconv"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+
+	"github.com/go-logr/logr"
+)
+
+// UserAccountWeightAnnotationKey is set on the UserAccount in the member cluster and contains the weight that was added
+// to the weighted counter of UserAccounts when the UserAccount was created or when its tier was changed. The same weight
+// is subtracted when the UserAccount is deleted, so the weighted counter doesn't drift when the tier of the UserAccount
+// or the configured tier weights change in the meantime.
+const UserAccountWeightAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "weight"
+
+// getAppliedWeight returns the weight that was added to the weighted counter for the given UserAccount.
+// If the UserAccount doesn't have the annotation (eg, it was created before the weight was recorded) or if the annotation
+// is invalid, then the current weight of its tier is returned.
+func (r *ReconcileMasterUserRecord) getAppliedWeight(logger logr.Logger, namespace string, userAccount *toolchainv1alpha1.UserAccount) int {
+	if value, found := userAccount.Annotations[UserAccountWeightAnnotationKey]; found {
+		weight, err := strconv.Atoi(value)
+		if err == nil {
+			return weight
+		}
+		logger.Error(err, "invalid weight annotation of the UserAccount, using the weight of the tier", "value", value)
+	}
+	return r.getWeight(logger, namespace, userAccount.Spec.NSTemplateSet.TierName)
+}
+
+// setAppliedWeight sets the annotation with the weight added to the weighted counter for the given UserAccount.
+// The UserAccount resource is not updated.
+func setAppliedWeight(userAccount *toolchainv1alpha1.UserAccount, weight int) {
+	if userAccount.Annotations == nil {
+		userAccount.Annotations = map[string]string{}
+	}
+	userAccount.Annotations[UserAccountWeightAnnotationKey] = strconv.Itoa(weight)
+}
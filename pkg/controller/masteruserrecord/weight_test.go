@@ -0,0 +1,110 @@
+package masteruserrecord
+
+import (
+	"context"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestUserAccountWeight(t *testing.T) {
+	// given
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	s := apiScheme(t)
+
+	setup := func(t *testing.T) (ReconcileMasterUserRecord, client.Client, client.Client) {
+		mur := murtest.NewMasterUserRecord(t, "john", murtest.Finalizer("finalizer.toolchain.dev.openshift.com"))
+		toolchainStatus := NewToolchainStatus(
+			WithHost(WithMasterUserRecordCount(1)),
+			WithMember(test.MemberClusterName, WithUserAccountCount(1), WithRoutes("https://console.member-cluster/", "", ToBeReady())))
+		memberClient := test.NewFakeClient(t)
+		hostClient := test.NewFakeClient(t, mur, toolchainStatus)
+		InitializeCounters(t, toolchainStatus)
+		cntrl := newController(t, hostClient, s, NewGetMemberCluster(true, v1.ConditionTrue),
+			ClusterClient(test.MemberClusterName, memberClient))
+
+		// the UserAccount is created with the weight of the basic tier
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+		require.NoError(t, err)
+		assertAppliedWeight(t, memberClient, "3")
+		AssertThatCounters(t).
+			HaveUserAccountsForCluster(test.MemberClusterName, 2).
+			HaveWeightedUserAccountsForCluster(test.MemberClusterName, 4)
+		return cntrl, hostClient, memberClient
+	}
+
+	deleteMur := func(t *testing.T, cntrl ReconcileMasterUserRecord, hostClient client.Client) {
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(t, hostClient.Get(context.TODO(), namespacedName(test.HostOperatorNs, "john"), mur))
+		mur.DeletionTimestamp = &metav1.Time{Time: metav1.Now().Time}
+		require.NoError(t, hostClient.Update(context.TODO(), mur))
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+		require.NoError(t, err)
+	}
+
+	t.Run("tier changed and then deleted", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_TIER_WEIGHTS", "basic=3,advanced=5")
+		defer restore()
+		cntrl, hostClient, memberClient := setup(t)
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(t, hostClient.Get(context.TODO(), namespacedName(test.HostOperatorNs, "john"), mur))
+		murtest.ModifyUaInMur(mur, test.MemberClusterName, murtest.TierName("advanced"))
+		require.NoError(t, hostClient.Update(context.TODO(), mur))
+
+		// when
+		_, err := cntrl.Reconcile(newMurRequest(mur))
+
+		// then
+		require.NoError(t, err)
+		assertAppliedWeight(t, memberClient, "5")
+		AssertThatCounters(t).
+			HaveUserAccountsForCluster(test.MemberClusterName, 2).
+			HaveWeightedUserAccountsForCluster(test.MemberClusterName, 6)
+
+		t.Run("deleted with the weight of the new tier", func(t *testing.T) {
+			// when
+			deleteMur(t, cntrl, hostClient)
+
+			// then
+			AssertThatCounters(t).
+				HaveUserAccountsForCluster(test.MemberClusterName, 1).
+				HaveWeightedUserAccountsForCluster(test.MemberClusterName, 1)
+		})
+	})
+
+	t.Run("configured weight changed and then deleted", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_TIER_WEIGHTS", "basic=3")
+		cntrl, hostClient, _ := setup(t)
+		restore()
+		restore = test.SetEnvVarAndRestore(t, "HOST_OPERATOR_TIER_WEIGHTS", "basic=10")
+		defer restore()
+
+		// when
+		deleteMur(t, cntrl, hostClient)
+
+		// then
+		AssertThatCounters(t).
+			HaveUserAccountsForCluster(test.MemberClusterName, 1).
+			HaveWeightedUserAccountsForCluster(test.MemberClusterName, 1)
+	})
+}
+
+func assertAppliedWeight(t *testing.T, memberClient client.Client, expected string) {
+	userAccount := &toolchainv1alpha1.UserAccount{}
+	err := memberClient.Get(context.TODO(), namespacedName("toolchain-member-operator", "john"), userAccount)
+	require.NoError(t, err)
+	assert.Equal(t, expected, userAccount.Annotations[UserAccountWeightAnnotationKey])
+}
@@ -0,0 +1,260 @@
+package toolchainstatus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/hostoperatorconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/usersignup/unapproved"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// capacity headroom condition
+const (
+	// CapacityHeadroom is the type of the condition that contains the estimated remaining user slots of the member clusters
+	// and the estimated time until they are full in its message
+	CapacityHeadroom toolchainv1alpha1.ConditionType = "CapacityHeadroom"
+
+	// CapacityAvailableReason is used when there is at least one remaining user slot
+	CapacityAvailableReason = "CapacityAvailable"
+
+	// CapacityExhaustedReason is used when there is no remaining user slot in any member cluster
+	CapacityExhaustedReason = "CapacityExhausted"
+)
+
+// headroom contains the estimated number of users that can still be provisioned
+type headroom struct {
+	// perMemberCluster contains the remaining user slots mapped by the member cluster names. Nil values mean that the number of users is not limited.
+	perMemberCluster map[string]*int
+	// overall contains the remaining user slots in all the member clusters, nil if the number of users is not limited
+	overall *int
+	// approvalsPerHour is the recent approval rate
+	approvalsPerHour float64
+}
+
+// isLimited returns true if the number of users is limited in any member cluster or overall
+func (h headroom) isLimited() bool {
+	if h.overall != nil {
+		return true
+	}
+	for _, slots := range h.perMemberCluster {
+		if slots != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// timeToFull returns the estimated time until there is no remaining user slot based on the recent approval rate.
+// Returns false if it cannot be estimated, ie. if the number of users is not limited or if there was no recent approval.
+func (h headroom) timeToFull() (time.Duration, bool) {
+	if h.overall == nil {
+		return 0, false
+	}
+	if *h.overall == 0 {
+		return 0, true
+	}
+	if h.approvalsPerHour <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(*h.overall) / h.approvalsPerHour * float64(time.Hour)).Round(time.Minute), true
+}
+
+// String returns a human readable description of the headroom,
+// eg. "member-1: 200, member-2: unlimited, overall: 200, approvals per hour: 4.50, estimated time to full: 44h27m0s"
+func (h headroom) String() string {
+	clusterNames := make([]string, 0, len(h.perMemberCluster))
+	for clusterName := range h.perMemberCluster {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+	values := make([]string, 0, len(clusterNames)+3)
+	for _, clusterName := range clusterNames {
+		values = append(values, fmt.Sprintf("%s: %s", clusterName, slotsToString(h.perMemberCluster[clusterName])))
+	}
+	values = append(values, fmt.Sprintf("overall: %s", slotsToString(h.overall)),
+		fmt.Sprintf("approvals per hour: %.2f", h.approvalsPerHour))
+	if timeToFull, ok := h.timeToFull(); ok {
+		values = append(values, fmt.Sprintf("estimated time to full: %s", timeToFull))
+	}
+	return strings.Join(values, ", ")
+}
+
+func slotsToString(slots *int) string {
+	if slots == nil {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d", *slots)
+}
+
+// updateMetrics sets the headroom gauges. The values that are not limited or cannot be estimated are set to -1.
+func (h headroom) updateMetrics() {
+	for clusterName, slots := range h.perMemberCluster {
+		metrics.UserSlotsRemainingGaugeVec.WithLabelValues(clusterName).Set(slotsToFloat(slots))
+	}
+	metrics.UserSlotsRemainingGauge.Set(slotsToFloat(h.overall))
+	if timeToFull, ok := h.timeToFull(); ok {
+		metrics.CapacityTimeToFullGauge.Set(timeToFull.Seconds())
+	} else {
+		metrics.CapacityTimeToFullGauge.Set(-1)
+	}
+}
+
+func slotsToFloat(slots *int) float64 {
+	if slots == nil {
+		return -1
+	}
+	return float64(*slots)
+}
+
+// computeHeadroom estimates the remaining user slots of the member clusters listed in the ToolchainStatus.
+// The remaining slots of a member cluster are limited by the configured maximal number of users in the cluster (compared with the weighted
+// number of UserAccounts) and by the memory usage thresholds of the node roles (assuming that every new user consumes the same amount
+// of memory as the already provisioned ones on average). The drained member clusters have no remaining slots.
+// The overall remaining slots are the sum of the slots of all the member clusters limited by the configured overall maximal number of users.
+// The given number of approvals per hour is used for estimating the time until the member clusters are full.
+func computeHeadroom(config toolchainv1alpha1.HostOperatorConfigSpec, crtConfig *crtCfg.Config, toolchainStatus *toolchainv1alpha1.ToolchainStatus,
+	counts counter.Counts, drained map[string]string, approvalsPerHour float64) headroom {
+	ignoredRoles := map[string]bool{}
+	for _, role := range crtConfig.GetResourceCapacityIgnoredNodeRoles() {
+		ignoredRoles[role] = true
+	}
+	h := headroom{
+		perMemberCluster: map[string]*int{},
+		approvalsPerHour: approvalsPerHour,
+	}
+	sum := 0
+	unlimited := false
+	for _, member := range toolchainStatus.Status.Members {
+		var slots *int
+		if _, found := drained[member.ClusterName]; found {
+			slots = newInt(0)
+		} else {
+			slots = remainingSlots(config, crtConfig, member, counts, ignoredRoles)
+		}
+		h.perMemberCluster[member.ClusterName] = slots
+		if slots == nil {
+			unlimited = true
+		} else {
+			sum += *slots
+		}
+	}
+	if !unlimited && len(h.perMemberCluster) > 0 {
+		h.overall = newInt(sum)
+	}
+	if maxUsers := config.AutomaticApproval.MaxNumberOfUsers.Overall; maxUsers > 0 {
+		overall := maxInt(maxUsers-counts.MasterUserRecordCount, 0)
+		if h.overall == nil || overall < *h.overall {
+			h.overall = &overall
+		}
+	}
+	return h
+}
+
+// remainingSlots returns the lowest number of users the member cluster can still take with respect to the configured maximal number of users
+// and the memory thresholds. Returns nil if none of them is configured or can be estimated.
+func remainingSlots(config toolchainv1alpha1.HostOperatorConfigSpec, crtConfig *crtCfg.Config, member toolchainv1alpha1.Member,
+	counts counter.Counts, ignoredRoles map[string]bool) *int {
+	var slots *int
+	lower := func(value int) {
+		value = maxInt(value, 0)
+		if slots == nil || value < *slots {
+			slots = &value
+		}
+	}
+
+	if maxUsers := config.AutomaticApproval.MaxNumberOfUsers.SpecificPerMemberCluster[member.ClusterName]; maxUsers > 0 {
+		weighted, found := counts.WeightedUserAccountsPerClusterCounts[member.ClusterName]
+		if !found {
+			weighted = member.UserAccountCount
+		}
+		lower(maxUsers - weighted)
+	}
+
+	threshold, found := config.AutomaticApproval.ResourceCapacityThreshold.SpecificPerMemberCluster[member.ClusterName]
+	if !found {
+		threshold = config.AutomaticApproval.ResourceCapacityThreshold.DefaultThreshold
+	}
+	memoryThreshold := crtCfg.ResourceThreshold{
+		Default:     threshold,
+		PerNodeRole: crtConfig.GetMemoryCapacityThresholdPerNodeRole(),
+	}
+	for role, usage := range member.MemberStatus.ResourceUsage.MemoryUsagePerNodeRole {
+		roleThreshold := memoryThreshold.ForNodeRole(role)
+		if ignoredRoles[role] || roleThreshold == 0 {
+			continue
+		}
+		if usage >= roleThreshold {
+			lower(0)
+		} else if usage > 0 && member.UserAccountCount > 0 {
+			lower((roleThreshold - usage) * member.UserAccountCount / usage)
+		}
+	}
+	return slots
+}
+
+// capacityHeadroomHandleStatus publishes the estimated remaining user slots of the member clusters and the estimated time until they are full
+// in the CapacityHeadroom condition and in the metrics. The condition is set only if the number of users is limited.
+// The headroom doesn't affect the readiness of the toolchain, so it always returns true.
+func (r *ReconcileToolchainStatus) capacityHeadroomHandleStatus(reqLogger logr.Logger, toolchainStatus *toolchainv1alpha1.ToolchainStatus) bool {
+	config, err := hostoperatorconfig.GetConfig(r.client, toolchainStatus.Namespace)
+	if err != nil {
+		reqLogger.Error(err, "unable to compute the capacity headroom")
+		return true
+	}
+	counts, err := counter.GetCounts()
+	if err != nil {
+		reqLogger.Error(err, "unable to compute the capacity headroom")
+		return true
+	}
+	drained, err := clusterdrain.GetDrainedClusters(r.client, toolchainStatus.Namespace)
+	if err != nil {
+		reqLogger.Error(err, "unable to compute the capacity headroom")
+		return true
+	}
+
+	approvalsPerHour, err := unapproved.ApprovalRate(r.client, toolchainStatus.Namespace, r.config.GetCapacityHeadroomApprovalRateWindow(), time.Now())
+	if err != nil {
+		reqLogger.Error(err, "unable to compute the capacity headroom")
+		return true
+	}
+
+	h := computeHeadroom(config, r.config, toolchainStatus, counts, drained, approvalsPerHour)
+	h.updateMetrics()
+	if !h.isLimited() {
+		return true
+	}
+	headroomCondition := toolchainv1alpha1.Condition{
+		Type:    CapacityHeadroom,
+		Status:  corev1.ConditionTrue,
+		Reason:  CapacityAvailableReason,
+		Message: h.String(),
+	}
+	if h.overall != nil && *h.overall == 0 {
+		headroomCondition.Status = corev1.ConditionFalse
+		headroomCondition.Reason = CapacityExhaustedReason
+	}
+	toolchainStatus.Status.Conditions = condition.AddOrUpdateStatusConditionsWithLastUpdatedTimestamp(toolchainStatus.Status.Conditions, headroomCondition)
+	return true
+}
+
+func newInt(value int) *int {
+	return &value
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
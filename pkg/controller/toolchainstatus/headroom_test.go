@@ -0,0 +1,266 @@
+package toolchainstatus
+
+import (
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/registrationservice"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/status"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestComputeHeadroom(t *testing.T) {
+	// given
+	crtConfig, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(300)),
+		WithMember("member-1", WithUserAccountCount(200), WithNodeRoleUsage("worker", 40), WithNodeRoleUsage("master", 20)),
+		WithMember("member-2", WithUserAccountCount(100), WithNodeRoleUsage("worker", 50)))
+	counts := counter.Counts{
+		MasterUserRecordCount:                300,
+		UserAccountsPerClusterCounts:         map[string]int{"member-1": 200, "member-2": 100},
+		WeightedUserAccountsPerClusterCounts: map[string]int{"member-1": 200, "member-2": 150},
+	}
+
+	t.Run("not limited", func(t *testing.T) {
+		// when
+		h := computeHeadroom(toolchainv1alpha1.HostOperatorConfigSpec{}, crtConfig, toolchainStatus, counts, nil, 0)
+
+		// then
+		assert.False(t, h.isLimited())
+		assert.Equal(t, "member-1: unlimited, member-2: unlimited, overall: unlimited, approvals per hour: 0.00", h.String())
+	})
+
+	t.Run("limited by the max number of users", func(t *testing.T) {
+		// given
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().
+			MaxUsersNumber(1000, test.PerMemberCluster("member-1", 500), test.PerMemberCluster("member-2", 400))).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, nil, 0)
+
+		// then
+		assert.True(t, h.isLimited())
+		assert.Equal(t, "member-1: 300, member-2: 250, overall: 550, approvals per hour: 0.00", h.String()) // member-2 uses the weighted count
+	})
+
+	t.Run("limited by the overall max number of users", func(t *testing.T) {
+		// given
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().
+			MaxUsersNumber(400, test.PerMemberCluster("member-1", 500), test.PerMemberCluster("member-2", 400))).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, nil, 0)
+
+		// then
+		assert.Equal(t, "member-1: 300, member-2: 250, overall: 100, approvals per hour: 0.00", h.String())
+	})
+
+	t.Run("limited by the memory thresholds", func(t *testing.T) {
+		// given
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().
+			ResourceCapThreshold(80, test.PerMemberCluster("member-2", 60))).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, nil, 0)
+
+		// then
+		// member-1: (80-40)*200/40 = 200 users on worker role, (80-20)*200/20 = 600 users on master role
+		// member-2: (60-50)*100/50 = 20 users on worker role
+		assert.Equal(t, "member-1: 200, member-2: 20, overall: 220, approvals per hour: 0.00", h.String())
+	})
+
+	t.Run("memory threshold reached", func(t *testing.T) {
+		// given
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().
+			ResourceCapThreshold(80, test.PerMemberCluster("member-2", 50))).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, nil, 0)
+
+		// then
+		assert.Equal(t, "member-1: 200, member-2: 0, overall: 200, approvals per hour: 0.00", h.String())
+	})
+
+	t.Run("lowest of the limits", func(t *testing.T) {
+		// given
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().
+			MaxUsersNumber(1000, test.PerMemberCluster("member-1", 250)).
+			ResourceCapThreshold(80)).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, nil, 0)
+
+		// then
+		assert.Equal(t, "member-1: 50, member-2: 60, overall: 110, approvals per hour: 0.00", h.String())
+	})
+
+	t.Run("ignored node role", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarsAndRestore(t, test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_IGNOREDNODEROLES", "worker"))
+		defer restore()
+		crtConfig, err := configuration.LoadConfig(test.NewFakeClient(t))
+		require.NoError(t, err)
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().ResourceCapThreshold(80)).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, nil, 0)
+
+		// then
+		assert.Equal(t, "member-1: 600, member-2: unlimited, overall: unlimited, approvals per hour: 0.00", h.String())
+	})
+
+	t.Run("drained member cluster", func(t *testing.T) {
+		// given
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().ResourceCapThreshold(80)).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, map[string]string{"member-1": clusterdrain.DrainModeCordon}, 0)
+
+		// then
+		assert.Equal(t, "member-1: 0, member-2: 60, overall: 60, approvals per hour: 0.00", h.String())
+	})
+
+	t.Run("estimated time to full", func(t *testing.T) {
+		// given
+		config := test.NewHostOperatorConfig(test.AutomaticApproval().ResourceCapThreshold(80)).Spec
+
+		// when
+		h := computeHeadroom(config, crtConfig, toolchainStatus, counts, nil, 2)
+
+		// then
+		assert.Equal(t, "member-1: 200, member-2: 60, overall: 260, approvals per hour: 2.00, estimated time to full: 130h0m0s", h.String())
+		timeToFull, ok := h.timeToFull()
+		assert.True(t, ok)
+		assert.Equal(t, 130*time.Hour, timeToFull)
+	})
+}
+
+func TestHeadroomMetrics(t *testing.T) {
+	// given
+	metrics.Reset()
+	defer metrics.Reset()
+	h := headroom{
+		perMemberCluster: map[string]*int{"member-1": newInt(20), "member-2": nil},
+		approvalsPerHour: 10,
+	}
+
+	t.Run("not limited overall", func(t *testing.T) {
+		// when
+		h.updateMetrics()
+
+		// then
+		AssertMetricsGaugeEquals(t, 20, metrics.UserSlotsRemainingGaugeVec.WithLabelValues("member-1"))
+		AssertMetricsGaugeEquals(t, -1, metrics.UserSlotsRemainingGaugeVec.WithLabelValues("member-2"))
+		AssertMetricsGaugeEquals(t, -1, metrics.UserSlotsRemainingGauge)
+		AssertMetricsGaugeEquals(t, -1, metrics.CapacityTimeToFullGauge)
+	})
+
+	t.Run("limited overall", func(t *testing.T) {
+		// given
+		h.overall = newInt(20)
+
+		// when
+		h.updateMetrics()
+
+		// then
+		AssertMetricsGaugeEquals(t, 20, metrics.UserSlotsRemainingGauge)
+		AssertMetricsGaugeEquals(t, 7200, metrics.CapacityTimeToFullGauge)
+	})
+}
+
+func TestCapacityHeadroomCondition(t *testing.T) {
+	// given
+	restore := test.SetEnvVarsAndRestore(t, test.Env(k8sutil.OperatorNameEnvVar, defaultHostOperatorName))
+	defer restore()
+	requestName := configuration.DefaultToolchainStatusName
+	registrationService := newRegistrationServiceReady()
+	hostOperatorDeployment := newDeploymentWithConditions(defaultHostOperatorName, status.DeploymentAvailableCondition(), status.DeploymentProgressingCondition())
+	registrationServiceDeployment := newDeploymentWithConditions(registrationservice.ResourceName, status.DeploymentAvailableCondition(), status.DeploymentProgressingCondition())
+	memberStatus := newMemberStatus(ready())
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(20)),
+		WithMember("member-1", WithUserAccountCount(10)),
+		WithMember("member-2", WithUserAccountCount(10)))
+	InitializeCounters(t, toolchainStatus)
+
+	t.Run("capacity available", func(t *testing.T) {
+		// given
+		config := NewHostOperatorConfigWithReset(t, test.AutomaticApproval().
+			MaxUsersNumber(1000, test.PerMemberCluster("member-1", 15), test.PerMemberCluster("member-2", 30)))
+		reconciler, req, fakeClient := prepareReconcile(t, requestName, newResponseGood(), []string{"member-1", "member-2"},
+			hostOperatorDeployment, memberStatus, registrationServiceDeployment, registrationService, toolchainStatus, config)
+
+		// when
+		_, err := reconciler.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		AssertThatToolchainStatus(t, req.Namespace, requestName, fakeClient).
+			HasConditions(componentsReady(), unreadyNotificationNotCreated(), toolchainv1alpha1.Condition{
+				Type:    CapacityHeadroom,
+				Status:  corev1.ConditionTrue,
+				Reason:  CapacityAvailableReason,
+				Message: "member-1: 5, member-2: 20, overall: 25, approvals per hour: 0.00",
+			})
+	})
+
+	t.Run("capacity available with recent approvals", func(t *testing.T) {
+		// given
+		config := NewHostOperatorConfigWithReset(t, test.AutomaticApproval().
+			MaxUsersNumber(1000, test.PerMemberCluster("member-1", 15), test.PerMemberCluster("member-2", 30)))
+		objs := []runtime.Object{hostOperatorDeployment, memberStatus, registrationServiceDeployment, registrationService, toolchainStatus, config,
+			NewUserSignup(ApprovedAutomaticallyAt(time.Now().Add(-30 * time.Hour)))} // too old to be taken into account
+		for i := 0; i < 48; i++ {
+			objs = append(objs, NewUserSignup(ApprovedAutomaticallyAt(time.Now().Add(-time.Duration(i)*30*time.Minute))))
+		}
+		reconciler, req, fakeClient := prepareReconcile(t, requestName, newResponseGood(), []string{"member-1", "member-2"}, objs...)
+
+		// when
+		_, err := reconciler.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		AssertThatToolchainStatus(t, req.Namespace, requestName, fakeClient).
+			HasConditions(componentsReady(), unreadyNotificationNotCreated(), toolchainv1alpha1.Condition{
+				Type:    CapacityHeadroom,
+				Status:  corev1.ConditionTrue,
+				Reason:  CapacityAvailableReason,
+				Message: "member-1: 5, member-2: 20, overall: 25, approvals per hour: 2.00, estimated time to full: 12h30m0s",
+			})
+	})
+
+	t.Run("capacity exhausted", func(t *testing.T) {
+		// given
+		config := NewHostOperatorConfigWithReset(t, test.AutomaticApproval().
+			MaxUsersNumber(20, test.PerMemberCluster("member-1", 15), test.PerMemberCluster("member-2", 30)))
+		reconciler, req, fakeClient := prepareReconcile(t, requestName, newResponseGood(), []string{"member-1", "member-2"},
+			hostOperatorDeployment, memberStatus, registrationServiceDeployment, registrationService, toolchainStatus, config)
+
+		// when
+		_, err := reconciler.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		AssertThatToolchainStatus(t, req.Namespace, requestName, fakeClient).
+			HasConditions(componentsReady(), unreadyNotificationNotCreated(), toolchainv1alpha1.Condition{
+				Type:    CapacityHeadroom,
+				Status:  corev1.ConditionFalse,
+				Reason:  CapacityExhaustedReason,
+				Message: "member-1: 5, member-2: 20, overall: 0, approvals per hour: 0.00, estimated time to full: 0s",
+			})
+	})
+}
@@ -10,9 +10,12 @@ import (
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/approvalbudget"
+	"github.com/codeready-toolchain/host-operator/pkg/capacity"
 	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/registrationservice"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/tierweight"
 	"github.com/codeready-toolchain/host-operator/version"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
@@ -60,9 +63,23 @@ const (
 	hostOperatorTag        statusComponentTag = "hostOperator"
 	memberConnectionsTag   statusComponentTag = "members"
 	counterTag             statusComponentTag = "MasterUserRecord and UserAccount counter"
+	approvalBudgetTag      statusComponentTag = "automatic approval budget"
+	capacityHeadroomTag    statusComponentTag = "capacity headroom"
 	minutesAfterUnready    time.Duration      = 10
 )
 
+// approval budget condition
+const (
+	// ApprovalBudget is the type of the condition that contains the remaining automatic approval budgets in its message
+	ApprovalBudget toolchainv1alpha1.ConditionType = "ApprovalBudget"
+
+	// ApprovalBudgetAvailableReason is used when there is remaining budget for automatic approvals
+	ApprovalBudgetAvailableReason = "BudgetAvailable"
+
+	// ApprovalBudgetExhaustedReason is used when the hourly or daily budget for automatic approvals is exhausted
+	ApprovalBudgetExhaustedReason = "BudgetExhausted"
+)
+
 const (
 	adminUnreadyNotificationSubject  = "ToolchainStatus has been in an unready status for an extended period"
 	adminRestoredNotificationSubject = "ToolchainStatus has now been restored to ready status"
@@ -108,6 +125,17 @@ func add(mgr manager.Manager, r *ReconcileToolchainStatus) error {
 		return err
 	}
 
+	// Collect the usage of the CPU and storage resources of the member clusters periodically, if it's needed by the capacity checks
+	// of the automatic approval. The usage doesn't affect the readiness of the toolchain.
+	if capacity.IsCollectionEnabled(r.config) && r.config.GetResourceCapacityUsageCollectionPeriod() > 0 {
+		if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			capacity.CollectPeriodically(stop, r.config, r.getMembersFunc)
+			return nil
+		})); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -173,14 +201,20 @@ func (r *ReconcileToolchainStatus) aggregateAndUpdateStatus(reqLogger logr.Logge
 	hostOperatorStatusHandlerFunc := statusHandler{name: hostOperatorTag, handleStatus: r.hostOperatorHandleStatus}
 	registrationServiceStatusHandlerFunc := statusHandler{name: registrationServiceTag, handleStatus: r.registrationServiceHandleStatus}
 	memberStatusHandlerFunc := statusHandler{name: memberConnectionsTag, handleStatus: r.membersHandleStatus}
-	// should be executed as the last one
+	// should be executed after all the component handlers
 	counterHandlerFunc := statusHandler{name: counterTag, handleStatus: r.synchronizeWithCounter}
 
+	approvalBudgetHandlerFunc := statusHandler{name: approvalBudgetTag, handleStatus: r.approvalBudgetHandleStatus}
+	// should be executed after the member and counter handlers
+	capacityHeadroomHandlerFunc := statusHandler{name: capacityHeadroomTag, handleStatus: r.capacityHeadroomHandleStatus}
+
 	statusHandlers := []statusHandler{
 		hostOperatorStatusHandlerFunc,
 		memberStatusHandlerFunc,
 		registrationServiceStatusHandlerFunc,
 		counterHandlerFunc,
+		approvalBudgetHandlerFunc,
+		capacityHeadroomHandlerFunc,
 	}
 
 	// track components that are not ready
@@ -260,13 +294,43 @@ func (r *ReconcileToolchainStatus) restoredCheck(reqLogger logr.Logger, toolchai
 
 // synchronizeWithCounter synchronizes the ToolchainStatus with the cached counter
 func (r *ReconcileToolchainStatus) synchronizeWithCounter(reqLogger logr.Logger, toolchainStatus *toolchainv1alpha1.ToolchainStatus) bool {
-	if err := counter.Synchronize(r.client, toolchainStatus); err != nil {
+	var weightOf counter.WeightFunc
+	if tierweight.IsConfigured(r.config) {
+		weightOf = tierweight.ForNamespace(r.client, r.config, toolchainStatus.Namespace)
+	}
+	if err := counter.Synchronize(r.client, toolchainStatus, weightOf); err != nil {
 		reqLogger.Error(err, "unable to synchronize with the counter")
 		return false
 	}
 	return true
 }
 
+// approvalBudgetHandleStatus publishes the remaining automatic approval budgets in the ApprovalBudget condition.
+// The budgets don't affect the readiness of the toolchain, so it always returns true.
+func (r *ReconcileToolchainStatus) approvalBudgetHandleStatus(reqLogger logr.Logger, toolchainStatus *toolchainv1alpha1.ToolchainStatus) bool {
+	now := time.Now()
+	if err := approvalbudget.Restore(r.client, r.config, toolchainStatus.Namespace, now); err != nil {
+		reqLogger.Error(err, "unable to restore the automatic approval budgets")
+		return true
+	}
+	remaining := approvalbudget.GetRemaining(r.config, now)
+	if !remaining.IsConfigured() {
+		return true
+	}
+	budgetCondition := toolchainv1alpha1.Condition{
+		Type:    ApprovalBudget,
+		Status:  corev1.ConditionTrue,
+		Reason:  ApprovalBudgetAvailableReason,
+		Message: remaining.String(),
+	}
+	if remaining.IsExhausted() {
+		budgetCondition.Status = corev1.ConditionFalse
+		budgetCondition.Reason = ApprovalBudgetExhaustedReason
+	}
+	toolchainStatus.Status.Conditions = condition.AddOrUpdateStatusConditionsWithLastUpdatedTimestamp(toolchainStatus.Status.Conditions, budgetCondition)
+	return true
+}
+
 // hostOperatorHandleStatus retrieves the Deployment for the host operator and adds its status to ToolchainStatus. It returns false
 // if the deployment is not determined to be ready
 func (r *ReconcileToolchainStatus) hostOperatorHandleStatus(reqLogger logr.Logger, toolchainStatus *toolchainv1alpha1.ToolchainStatus) bool {
@@ -13,6 +13,7 @@ import (
 
 	"github.com/codeready-toolchain/api/pkg/apis"
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/approvalbudget"
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/registrationservice"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
@@ -172,6 +173,85 @@ func TestToolchainStatusConditions(t *testing.T) {
 			HasRegistrationServiceStatus(registrationServiceReady())
 	})
 
+	t.Run("All components ready with approval budget", func(t *testing.T) {
+		// given
+		approvalbudget.Reset()
+		defer approvalbudget.Reset()
+		restore := test.SetEnvVarsAndRestore(t,
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "10"),
+			test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_PERMEMBERCLUSTER", "member-2=1,member-1=5"))
+		defer restore()
+		registrationService := newRegistrationServiceReady()
+		hostOperatorDeployment := newDeploymentWithConditions(defaultHostOperatorName, status.DeploymentAvailableCondition(), status.DeploymentProgressingCondition())
+		registrationServiceDeployment := newDeploymentWithConditions(registrationservice.ResourceName, status.DeploymentAvailableCondition(), status.DeploymentProgressingCondition())
+		memberStatus := newMemberStatus(ready())
+		toolchainStatus := NewToolchainStatus()
+
+		t.Run("budget available", func(t *testing.T) {
+			// given
+			reconciler, req, fakeClient := prepareReconcile(t, requestName, newResponseGood(), []string{"member-1", "member-2"}, hostOperatorDeployment, memberStatus, registrationServiceDeployment, registrationService, toolchainStatus)
+			require.NoError(t, approvalbudget.Restore(fakeClient, reconciler.config, req.Namespace, time.Now()))
+			approvalbudget.Consume(reconciler.config, time.Now(), "member-1")
+
+			// when
+			res, err := reconciler.Reconcile(req)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, requeueResult, res)
+			AssertThatToolchainStatus(t, req.Namespace, requestName, fakeClient).
+				HasConditions(componentsReady(), unreadyNotificationNotCreated(), toolchainv1alpha1.Condition{
+					Type:    ApprovalBudget,
+					Status:  corev1.ConditionTrue,
+					Reason:  ApprovalBudgetAvailableReason,
+					Message: "hourly: 9/10, member-1: 4/5, member-2: 1/1",
+				})
+		})
+
+		t.Run("budget exhausted", func(t *testing.T) {
+			// given
+			reconciler, req, fakeClient := prepareReconcile(t, requestName, newResponseGood(), []string{"member-1", "member-2"}, hostOperatorDeployment, memberStatus, registrationServiceDeployment, registrationService, toolchainStatus)
+			for i := 0; i < 9; i++ {
+				approvalbudget.Consume(reconciler.config, time.Now(), "member-3")
+			}
+
+			// when
+			res, err := reconciler.Reconcile(req)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, requeueResult, res)
+			AssertThatToolchainStatus(t, req.Namespace, requestName, fakeClient).
+				HasConditions(componentsReady(), unreadyNotificationNotCreated(), toolchainv1alpha1.Condition{
+					Type:    ApprovalBudget,
+					Status:  corev1.ConditionFalse,
+					Reason:  ApprovalBudgetExhaustedReason,
+					Message: "hourly: 0/10, member-1: 4/5, member-2: 1/1",
+				})
+		})
+
+		t.Run("budget restored from the existing UserSignups", func(t *testing.T) {
+			// given
+			approvalbudget.Reset()
+			userSignup := NewUserSignup(ApprovedAutomaticallyAt(time.Now().Add(-time.Minute)))
+			reconciler, req, fakeClient := prepareReconcile(t, requestName, newResponseGood(), []string{"member-1", "member-2"}, hostOperatorDeployment, memberStatus, registrationServiceDeployment, registrationService, toolchainStatus, userSignup)
+
+			// when
+			res, err := reconciler.Reconcile(req)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, requeueResult, res)
+			AssertThatToolchainStatus(t, req.Namespace, requestName, fakeClient).
+				HasConditions(componentsReady(), unreadyNotificationNotCreated(), toolchainv1alpha1.Condition{
+					Type:    ApprovalBudget,
+					Status:  corev1.ConditionTrue,
+					Reason:  ApprovalBudgetAvailableReason,
+					Message: "hourly: 9/10, member-1: 5/5, member-2: 1/1",
+				})
+		})
+	})
+
 	t.Run("HostOperator tests", func(t *testing.T) {
 		registrationService := newRegistrationServiceReady()
 		toolchainStatus := NewToolchainStatus()
@@ -1105,7 +1185,7 @@ func TestSynchronizationWithCounter(t *testing.T) {
 			// given
 			counter.IncrementMasterUserRecordCount()
 			counter.IncrementMasterUserRecordCount()
-			counter.IncrementUserAccountCount("member-1")
+			counter.IncrementUserAccountCount("member-1", 1)
 			toolchainStatus := NewToolchainStatus(
 				WithHost(WithMasterUserRecordCount(1)),
 			)
@@ -1133,7 +1213,7 @@ func TestSynchronizationWithCounter(t *testing.T) {
 		// given
 		defer counter.Reset()
 		counter.IncrementMasterUserRecordCount()
-		counter.IncrementUserAccountCount("member-1")
+		counter.IncrementUserAccountCount("member-1", 1)
 		toolchainStatus := NewToolchainStatus(
 			WithHost(WithMasterUserRecordCount(8)),
 			WithMember("member-1", WithUserAccountCount(6)), // will increase
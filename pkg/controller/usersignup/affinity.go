@@ -0,0 +1,129 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// UserSignupClusterAffinityAnnotationKey contains a label selector (eg. "region=eu,gpu!=true") that the labels
+	// of the ToolchainCluster resource of a member cluster have to match so the user can be provisioned to it
+	UserSignupClusterAffinityAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "cluster-affinity"
+
+	// UserSignupClusterAntiAffinityAnnotationKey contains a label selector (eg. "gpu") that the labels
+	// of the ToolchainCluster resource of a member cluster must not match so the user can be provisioned to it
+	UserSignupClusterAntiAffinityAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "cluster-anti-affinity"
+
+	// UserSignupPreferredClusterAffinityAnnotationKey contains semicolon-separated label selectors (eg. "region=eu;tier=premium").
+	// The member clusters whose ToolchainCluster labels match the highest number of them are preferred,
+	// but the user is provisioned to other member clusters if none of the preferred ones is available.
+	UserSignupPreferredClusterAffinityAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "preferred-cluster-affinity"
+)
+
+// clusterAffinity contains the placement constraints of a UserSignup evaluated against the labels of the ToolchainCluster resources
+type clusterAffinity struct {
+	required      labels.Selector
+	antiAffinity  labels.Selector
+	preferred     []labels.Selector
+	clusterLabels map[string]labels.Set
+}
+
+// newClusterAffinity parses the placement constraints from the annotations of the UserSignup. If there is any constraint,
+// then it also loads the labels of the ToolchainCluster resources from the namespace of the UserSignup.
+func newClusterAffinity(cl client.Client, userSignup *toolchainv1alpha1.UserSignup) (*clusterAffinity, error) {
+	affinity := &clusterAffinity{}
+	var err error
+	if affinity.required, err = parseSelector(userSignup, UserSignupClusterAffinityAnnotationKey); err != nil {
+		return nil, err
+	}
+	if affinity.antiAffinity, err = parseSelector(userSignup, UserSignupClusterAntiAffinityAnnotationKey); err != nil {
+		return nil, err
+	}
+	for _, value := range strings.Split(userSignup.Annotations[UserSignupPreferredClusterAffinityAnnotationKey], ";") {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		selector, err := labels.Parse(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value of the annotation '%s'", UserSignupPreferredClusterAffinityAnnotationKey)
+		}
+		affinity.preferred = append(affinity.preferred, selector)
+	}
+	if !affinity.isDefined() {
+		return affinity, nil
+	}
+
+	toolchainClusters := &toolchainv1alpha1.ToolchainClusterList{}
+	if err := cl.List(context.TODO(), toolchainClusters, client.InNamespace(userSignup.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "unable to list the ToolchainCluster resources")
+	}
+	affinity.clusterLabels = map[string]labels.Set{}
+	for _, toolchainCluster := range toolchainClusters.Items {
+		affinity.clusterLabels[toolchainCluster.Name] = labels.Set(toolchainCluster.Labels)
+	}
+	return affinity, nil
+}
+
+func parseSelector(userSignup *toolchainv1alpha1.UserSignup, annotationKey string) (labels.Selector, error) {
+	value := strings.TrimSpace(userSignup.Annotations[annotationKey])
+	if value == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid value of the annotation '%s'", annotationKey)
+	}
+	return selector, nil
+}
+
+func (a *clusterAffinity) isDefined() bool {
+	return a.required != nil || a.antiAffinity != nil || len(a.preferred) > 0
+}
+
+// check returns a clusterCheck that rejects the member clusters that don't match the required affinity or match the anti-affinity
+func (a *clusterAffinity) check() clusterCheck {
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		clusterLabels := a.clusterLabels[cluster.Name]
+		if a.required != nil && !a.required.Matches(clusterLabels) {
+			return false, fmt.Sprintf("labels don't match the cluster affinity '%s'", a.required)
+		}
+		if a.antiAffinity != nil && a.antiAffinity.Matches(clusterLabels) {
+			return false, fmt.Sprintf("labels match the cluster anti-affinity '%s'", a.antiAffinity)
+		}
+		return true, ""
+	}
+}
+
+// preferredCandidates returns the candidates whose labels match the highest number of the preferred selectors.
+// If there is no preferred selector, then all the candidates are returned.
+func (a *clusterAffinity) preferredCandidates(candidates []*cluster.CachedToolchainCluster) []*cluster.CachedToolchainCluster {
+	if len(a.preferred) == 0 {
+		return candidates
+	}
+	var preferred []*cluster.CachedToolchainCluster
+	highest := -1
+	for _, candidate := range candidates {
+		score := 0
+		for _, selector := range a.preferred {
+			if selector.Matches(a.clusterLabels[candidate.Name]) {
+				score++
+			}
+		}
+		if score > highest {
+			preferred = nil
+			highest = score
+		}
+		if score == highest {
+			preferred = append(preferred, candidate)
+		}
+	}
+	return preferred
+}
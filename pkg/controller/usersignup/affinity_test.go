@@ -0,0 +1,148 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetClusterIfApprovedWithClusterAffinity(t *testing.T) {
+	// given
+	config, err := configuration.LoadConfig(NewFakeClient(t))
+	require.NoError(t, err)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(10)),
+		WithMember("us-1", WithUserAccountCount(5), WithNodeRoleUsage("worker", 50)),
+		WithMember("eu-1", WithUserAccountCount(5), WithNodeRoleUsage("worker", 50)),
+		WithMember("eu-2", WithUserAccountCount(5), WithNodeRoleUsage("worker", 50)))
+	hostOperatorConfig := NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled())
+	toolchainClusters := []runtime.Object{
+		newToolchainCluster("us-1", map[string]string{"region": "us", "gpu": "true"}),
+		newToolchainCluster("eu-1", map[string]string{"region": "eu", "gpu": "true"}),
+		newToolchainCluster("eu-2", map[string]string{"region": "eu", "tier": "premium"}),
+	}
+	clusters := NewGetMemberClusters(
+		NewMemberCluster(t, "us-1", v1.ConditionTrue),
+		NewMemberCluster(t, "eu-1", v1.ConditionTrue),
+		NewMemberCluster(t, "eu-2", v1.ConditionTrue))
+
+	test := func(t *testing.T, annotations map[string]string, expectedCluster targetCluster, expectedMessage string) {
+		// given
+		signup := NewUserSignup()
+		for key, value := range annotations {
+			signup.Annotations[key] = value
+		}
+		fakeClient := NewFakeClient(t, append(toolchainClusters, toolchainStatus, hostOperatorConfig)...)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		_, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, expectedCluster, clusterName)
+		assert.Equal(t, expectedMessage, msg)
+	}
+
+	t.Run("without any affinity the first cluster is selected", func(t *testing.T) {
+		test(t, nil, "us-1", "")
+	})
+
+	t.Run("required affinity", func(t *testing.T) {
+		test(t, map[string]string{
+			UserSignupClusterAffinityAnnotationKey: "region=us",
+		}, "us-1", "")
+	})
+
+	t.Run("anti-affinity", func(t *testing.T) {
+		test(t, map[string]string{
+			UserSignupClusterAntiAffinityAnnotationKey: "gpu",
+		}, "eu-2", "")
+	})
+
+	t.Run("preferred affinity", func(t *testing.T) {
+		test(t, map[string]string{
+			UserSignupClusterAffinityAnnotationKey:          "region=eu",
+			UserSignupPreferredClusterAffinityAnnotationKey: "tier=premium;region=eu",
+		}, "eu-2", "")
+	})
+
+	t.Run("preferred affinity is not required", func(t *testing.T) {
+		test(t, map[string]string{
+			UserSignupPreferredClusterAffinityAnnotationKey: "region=asia",
+		}, "us-1", "")
+	})
+
+	t.Run("affinity combined with anti-affinity and preferred affinity", func(t *testing.T) {
+		test(t, map[string]string{
+			UserSignupClusterAffinityAnnotationKey:          "region in (eu,us)",
+			UserSignupClusterAntiAffinityAnnotationKey:      "tier=premium",
+			UserSignupPreferredClusterAffinityAnnotationKey: "region=eu",
+		}, "eu-1", "")
+	})
+
+	t.Run("no cluster matches", func(t *testing.T) {
+		test(t, map[string]string{
+			UserSignupClusterAffinityAnnotationKey: "region=asia",
+		}, notFound, "eu-1 rejected: labels don't match the cluster affinity 'region=asia'; "+
+			"eu-2 rejected: labels don't match the cluster affinity 'region=asia'; "+
+			"us-1 rejected: labels don't match the cluster affinity 'region=asia'")
+	})
+
+	t.Run("invalid affinity", func(t *testing.T) {
+		// given
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupClusterAffinityAnnotationKey] = "region in (eu"
+		fakeClient := NewFakeClient(t, append(toolchainClusters, toolchainStatus, hostOperatorConfig)...)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		_, _, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid value of the annotation 'toolchain.dev.openshift.com/cluster-affinity'")
+	})
+
+	t.Run("unable to list ToolchainClusters", func(t *testing.T) {
+		// given
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupClusterAffinityAnnotationKey] = "region=eu"
+		fakeClient := NewFakeClient(t, append(toolchainClusters, toolchainStatus, hostOperatorConfig)...)
+		fakeClient.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			if _, ok := list.(*v1alpha1.ToolchainClusterList); ok {
+				return fmt.Errorf("some error")
+			}
+			return fakeClient.Client.List(ctx, list, opts...)
+		}
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		_, _, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.EqualError(t, err, "unable to list the ToolchainCluster resources: some error")
+	})
+}
+
+func newToolchainCluster(name string, labels map[string]string) *v1alpha1.ToolchainCluster {
+	return &v1alpha1.ToolchainCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: HostOperatorNs,
+			Labels:    labels,
+		},
+	}
+}
@@ -2,9 +2,16 @@ package usersignup
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/approvalbudget"
+	"github.com/codeready-toolchain/host-operator/pkg/capacity"
 	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/hostoperatorconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
@@ -24,93 +31,308 @@ func (c targetCluster) getClusterName() string {
 	return string(c)
 }
 
+// approvalEvaluation is the outcome of the evaluation of the approval rules and member cluster conditions for a UserSignup
+type approvalEvaluation struct {
+	// approved is true if the user is (or would be) approved
+	approved bool
+	// targetCluster is the member cluster the user should be provisioned to, notFound if there is no suitable member cluster
+	// or unknown if the user is not approved
+	targetCluster targetCluster
+	// additionalTargetClusters are the member clusters the other UserAccounts of the user should be provisioned to (if more than one is requested)
+	additionalTargetClusters []string
+	// message describes the rules that affected the decision (if any)
+	message string
+}
+
 // getClusterIfApproved checks if the user can be approved and provisioned to any member cluster.
 // If the user can be approved then the function returns true as the first returned value, the second value contains a cluster name the user should be provisioned to.
 // If there is no suitable member cluster, then it returns notFound as the second returned value.
+// The third value contains the names of the member clusters the other UserAccounts of the user should be provisioned to (if more than one is requested).
+// The fourth value contains a message describing the rules that affected the decision (if any): the matching email domain rule,
+// the inactive automatic approval schedule, the exhausted approval budgets and the reasons why the member clusters were rejected.
+// The position in the approval queue is not part of the message, it's added by the caller when the user is left pending.
+//
+// The decision is made by evaluateApproval. The approval budgets are not charged here, but only when the MasterUserRecord is created,
+// see consumeApprovalBudget.
+func getClusterIfApproved(cl client.Client, crtConfig *crtCfg.Config, userSignup *toolchainv1alpha1.UserSignup, getMemberClusters cluster.GetMemberClustersFunc) (bool, targetCluster, []string, string, error) {
+	evaluation, err := evaluateApproval(cl, crtConfig, userSignup, getMemberClusters, time.Now(), false)
+	if err != nil {
+		return false, unknown, nil, evaluation.message, err
+	}
+	return evaluation.approved, evaluation.targetCluster, evaluation.additionalTargetClusters, evaluation.message, nil
+}
+
+// consumeApprovalBudget charges the automatic approval of a user to the overall approval budgets (once) and to the budgets
+// of all the selected member clusters.
+// It is supposed to be called only once the MasterUserRecord of the user was created, so the failed or retried attempts
+// to provision the user are not charged.
+func consumeApprovalBudget(crtConfig *crtCfg.Config, targetCluster string, additionalTargetClusters []string, now time.Time) {
+	approvalbudget.Consume(crtConfig, now, append([]string{targetCluster}, additionalTargetClusters...)...)
+}
+
+// evaluateApproval evaluates if the user can be approved and provisioned to any member cluster at the given time without changing anything
+// but the state of the placement strategy. If dryRun is true, then the placement strategy only peeks at the selection, so nothing is changed at all.
 //
 // If the user is approved manually then it tries to get member cluster with enough capacity if the target cluster is not already specified for UserSignup.
-// If the user is not approved manually, then it loads HostOperatorConfig to check if automatic approval is enabled or not. If it is then it checks
-// capacity thresholds and the actual use if there is any suitable member cluster. If it is not then it returns false as the first value and
-// targetCluster unknown as the second value.
-func getClusterIfApproved(cl client.Client, crtConfig *crtCfg.Config, userSignup *toolchainv1alpha1.UserSignup, getMemberClusters cluster.GetMemberClustersFunc) (bool, targetCluster, error) {
+// If the user is not approved manually, then it checks the email domain of the user against the deny and allow lists - if the domain is denied,
+// then the user is not approved; if it is allowed, then the user is subject of automatic approval regardless of the HostOperatorConfig.
+// Otherwise it loads HostOperatorConfig to check if automatic approval is enabled or not. If it is (or the domain is allowed) then
+// it checks that the automatic approval is active according to the configured schedule, and then it checks
+// capacity thresholds, the remaining approval budgets, the cluster affinity, the drain status and the actual use if there is any suitable member cluster.
+// If it is not then the evaluation is not approved and the target cluster is unknown.
+// If the user should get UserAccounts on more member clusters, then all of them have to be found, otherwise the target cluster is notFound.
+func evaluateApproval(cl client.Client, crtConfig *crtCfg.Config, userSignup *toolchainv1alpha1.UserSignup, getMemberClusters cluster.GetMemberClustersFunc, now time.Time, dryRun bool) (approvalEvaluation, error) {
 	config, err := hostoperatorconfig.GetConfig(cl, userSignup.Namespace)
 	if err != nil {
-		return false, unknown, errors.Wrapf(err, "unable to read HostOperatorConfig resource")
+		return notApproved(""), errors.Wrapf(err, "unable to read HostOperatorConfig resource")
 	}
 
-	if !userSignup.Spec.Approved && !config.AutomaticApproval.Enabled {
-		return false, unknown, nil
+	var message string
+	if !userSignup.Spec.Approved {
+		domainMatch := matchEmailDomainRules(crtConfig, userSignup)
+		message = domainMatch.message()
+		if domainMatch.denied || (!domainMatch.allowed && !config.AutomaticApproval.Enabled) {
+			return notApproved(message), nil
+		}
+		active, scheduleMessage, err := checkAutomaticApprovalSchedule(cl, crtConfig, userSignup.Namespace, now)
+		if err != nil {
+			return notApproved(message), errors.Wrapf(err, "unable to check the automatic approval schedule")
+		}
+		if !active {
+			return notApproved(joinMessages(message, scheduleMessage)), nil
+		}
+	}
+
+	count, err := getRequestedNumberOfUserAccounts(crtConfig, userSignup)
+	if err != nil {
+		return notApproved(message), err
+	}
+
+	var checks []clusterCheck
+	if !userSignup.Spec.Approved {
+		if err := approvalbudget.Restore(cl, crtConfig, userSignup.Namespace, now); err != nil {
+			return notApproved(message), errors.Wrapf(err, "unable to restore the automatic approval budgets")
+		}
+		checks = append(checks, hasRemainingApprovalBudget(crtConfig, now))
+	}
+	clusterName, rejections, err := selectTargetCluster(cl, crtConfig, config, userSignup, getMemberClusters, dryRun, checks...)
+	if err != nil {
+		return notApproved(message), err
+	}
+	if clusterName == "" {
+		if !userSignup.Spec.Approved {
+			if remaining := approvalbudget.GetRemaining(crtConfig, now); remaining.IsExhausted() {
+				message = joinMessages(message, fmt.Sprintf("automatic approval budget exhausted (%s)", remaining))
+			}
+		}
+		message = joinMessages(message, rejections.String())
+		return approvalEvaluation{approved: userSignup.Spec.Approved, targetCluster: notFound, message: message}, nil
 	}
+	additionalClusters, rejections, err := selectAdditionalTargetClusters(cl, crtConfig, config, userSignup, getMemberClusters, clusterName, count, dryRun, checks...)
+	if err != nil {
+		return notApproved(message), err
+	}
+	if len(additionalClusters) < count-1 {
+		message = joinMessages(message, fmt.Sprintf("only %d of %d requested member clusters found", len(additionalClusters)+1, count), rejections.String())
+		return approvalEvaluation{approved: userSignup.Spec.Approved, targetCluster: notFound, message: message}, nil
+	}
+	return approvalEvaluation{approved: true, targetCluster: targetCluster(clusterName), additionalTargetClusters: additionalClusters, message: message}, nil
+}
 
+// selectTargetCluster selects the member cluster the user should be provisioned to using the configured placement strategy.
+// Apart from the given checks, the member cluster has to be ready, not drained, below the capacity thresholds and it has to match the cluster affinity.
+// If there is no such member cluster, then it returns an empty string and the rejections explain why.
+// If dryRun is true, then the state of the placement strategy is not changed.
+func selectTargetCluster(cl client.Client, crtConfig *crtCfg.Config, config toolchainv1alpha1.HostOperatorConfigSpec, userSignup *toolchainv1alpha1.UserSignup,
+	getMemberClusters cluster.GetMemberClustersFunc, dryRun bool, checks ...clusterCheck) (string, *clusterRejections, error) {
 	status := &toolchainv1alpha1.ToolchainStatus{}
 	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: userSignup.Namespace, Name: crtConfig.GetToolchainStatusName()}, status); err != nil {
-		return false, unknown, errors.Wrapf(err, "unable to read ToolchainStatus resource")
+		return "", nil, errors.Wrapf(err, "unable to read ToolchainStatus resource")
 	}
 	counts, err := counter.GetCounts()
 	if err != nil {
-		return false, unknown, errors.Wrapf(err, "unable to get the number of provisioned users")
+		return "", nil, errors.Wrapf(err, "unable to get the number of provisioned users")
 	}
 
-	clusterName := getOptimalTargetCluster(userSignup, getMemberClusters, hasNotReachedMaxNumberOfUsersThreshold(config, counts), hasEnoughResources(config, status))
-	if clusterName == "" {
-		return userSignup.Spec.Approved, notFound, nil
+	strategy, err := newPlacementStrategy(crtConfig, counts, status, dryRun)
+	if err != nil {
+		return "", nil, err
+	}
+
+	affinity, err := newClusterAffinity(cl, userSignup)
+	if err != nil {
+		return "", nil, err
+	}
+
+	drained, err := clusterdrain.GetDrainedClusters(cl, userSignup.Namespace)
+	if err != nil {
+		return "", nil, err
+	}
+
+	checks = append([]clusterCheck{hasNotReachedMaxNumberOfUsersThreshold(config, counts), hasEnoughResources(config, crtConfig, status)}, checks...)
+	checks = append(checks, isNotDrained(drained))
+	if affinity.isDefined() {
+		checks = append(checks, affinity.check())
 	}
-	return true, targetCluster(clusterName), nil
+	rejections := newClusterRejections()
+	clusterName := getOptimalTargetCluster(userSignup, getMemberClusters, strategy, affinity, rejections.condition(append(checks, isReady)...))
+	return clusterName, rejections, nil
 }
 
-func hasNotReachedMaxNumberOfUsersThreshold(config toolchainv1alpha1.HostOperatorConfigSpec, counts counter.Counts) cluster.Condition {
-	return func(cluster *cluster.CachedToolchainCluster) bool {
+func notApproved(message string) approvalEvaluation {
+	return approvalEvaluation{targetCluster: unknown, message: message}
+}
+
+func hasRemainingApprovalBudget(crtConfig *crtCfg.Config, now time.Time) clusterCheck {
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		if !approvalbudget.HasRemaining(crtConfig, cluster.Name, now) {
+			return false, "automatic approval budget exhausted"
+		}
+		return true, ""
+	}
+}
+
+func hasNotReachedMaxNumberOfUsersThreshold(config toolchainv1alpha1.HostOperatorConfigSpec, counts counter.Counts) clusterCheck {
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
 		if config.AutomaticApproval.MaxNumberOfUsers.Overall != 0 {
 			if config.AutomaticApproval.MaxNumberOfUsers.Overall <= counts.MasterUserRecordCount {
-				return false
+				return false, fmt.Sprintf("number of users %d >= overall threshold %d", counts.MasterUserRecordCount, config.AutomaticApproval.MaxNumberOfUsers.Overall)
 			}
 		}
+		// the per-cluster threshold is compared with the weighted number of UserAccounts (equal to the plain number when no tier weights are configured)
 		numberOfUserAccounts := counts.UserAccountsPerClusterCounts[cluster.Name]
+		weightedNumberOfUserAccounts := counts.WeightedUserAccountsPerClusterCounts[cluster.Name]
 		threshold := config.AutomaticApproval.MaxNumberOfUsers.SpecificPerMemberCluster[cluster.Name]
-		return threshold == 0 || numberOfUserAccounts < threshold
+		if threshold != 0 && weightedNumberOfUserAccounts >= threshold {
+			if weightedNumberOfUserAccounts != numberOfUserAccounts {
+				return false, fmt.Sprintf("weighted number of users %d (%d users) >= threshold %d", weightedNumberOfUserAccounts, numberOfUserAccounts, threshold)
+			}
+			return false, fmt.Sprintf("number of users %d >= threshold %d", numberOfUserAccounts, threshold)
+		}
+		return true, ""
 	}
 }
 
-func hasEnoughResources(config toolchainv1alpha1.HostOperatorConfigSpec, status *toolchainv1alpha1.ToolchainStatus) cluster.Condition {
-	return func(cluster *cluster.CachedToolchainCluster) bool {
+// hasEnoughResources checks that the usage of the memory (reported in ToolchainStatus) and the requests of CPU, ephemeral storage
+// and PVC storage (collected from the member clusters) are below the configured thresholds. The node roles that are ignored
+// or whose threshold is 0 are not checked.
+func hasEnoughResources(config toolchainv1alpha1.HostOperatorConfigSpec, crtConfig *crtCfg.Config, status *toolchainv1alpha1.ToolchainStatus) clusterCheck {
+	ignoredRoles := map[string]bool{}
+	for _, role := range crtConfig.GetResourceCapacityIgnoredNodeRoles() {
+		ignoredRoles[role] = true
+	}
+	cpuThreshold := crtConfig.GetCPUCapacityThreshold()
+	ephemeralStorageThreshold := crtConfig.GetEphemeralStorageCapacityThreshold()
+	pvcThreshold := crtConfig.GetPVCCapacityThreshold()
+	pvcStorage := crtConfig.GetPVCStoragePerMemberCluster()
+
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
 		threshold, found := config.AutomaticApproval.ResourceCapacityThreshold.SpecificPerMemberCluster[cluster.Name]
 		if !found {
 			threshold = config.AutomaticApproval.ResourceCapacityThreshold.DefaultThreshold
 		}
-		if threshold == 0 {
-			return true
+		memoryThreshold := crtCfg.ResourceThreshold{
+			Default:     threshold,
+			PerNodeRole: crtConfig.GetMemoryCapacityThresholdPerNodeRole(),
+		}
+
+		var reasons []string
+		if memoryThreshold.IsDefined() {
+			if ok, reason := hasMemberEnoughMemory(cluster.Name, status, memoryThreshold, ignoredRoles); !ok {
+				reasons = append(reasons, reason)
+			}
+		}
+
+		availablePVCStorage, pvcStorageDefined := pvcStorage[cluster.Name]
+		checkPVCStorage := pvcThreshold > 0 && pvcStorageDefined
+		capacityToCheck := cpuThreshold.IsDefined() || ephemeralStorageThreshold.IsDefined() || checkPVCStorage
+		if !capacityToCheck {
+			return len(reasons) == 0, strings.Join(reasons, ", ")
+		}
+		usage, found := capacity.Get(crtConfig, cluster.Name, time.Now())
+		if !found {
+			reasons = append(reasons, "no CPU and storage usage collected")
+			return false, strings.Join(reasons, ", ")
+		}
+		if cpuThreshold.IsDefined() {
+			reasons = append(reasons, checkUsagePerNodeRole("CPU requests", usage.CPURequestsPerNodeRole, cpuThreshold, ignoredRoles)...)
+		}
+		if ephemeralStorageThreshold.IsDefined() {
+			reasons = append(reasons, checkUsagePerNodeRole("ephemeral storage requests", usage.EphemeralStorageRequestsPerNodeRole, ephemeralStorageThreshold, ignoredRoles)...)
 		}
-		for _, memberStatus := range status.Status.Members {
-			if memberStatus.ClusterName == cluster.Name {
-				return hasMemberEnoughResources(memberStatus, threshold)
+		if checkPVCStorage {
+			if used := capacity.Percentage(usage.PVCStorage, availablePVCStorage); used >= pvcThreshold {
+				reasons = append(reasons, fmt.Sprintf("PVC storage %d%% >= threshold %d%%", used, pvcThreshold))
 			}
 		}
-		return false
+		return len(reasons) == 0, strings.Join(reasons, ", ")
 	}
 }
 
-func hasMemberEnoughResources(memberStatus toolchainv1alpha1.Member, threshold int) bool {
-	if len(memberStatus.MemberStatus.ResourceUsage.MemoryUsagePerNodeRole) > 0 {
-		for _, usagePerNode := range memberStatus.MemberStatus.ResourceUsage.MemoryUsagePerNodeRole {
-			if usagePerNode >= threshold {
-				return false
-			}
+func hasMemberEnoughMemory(clusterName string, status *toolchainv1alpha1.ToolchainStatus, threshold crtCfg.ResourceThreshold, ignoredRoles map[string]bool) (bool, string) {
+	for _, memberStatus := range status.Status.Members {
+		if memberStatus.ClusterName == clusterName {
+			return hasMemberEnoughResources(memberStatus, threshold, ignoredRoles)
+		}
+	}
+	return false, "not present in ToolchainStatus"
+}
+
+func hasMemberEnoughResources(memberStatus toolchainv1alpha1.Member, threshold crtCfg.ResourceThreshold, ignoredRoles map[string]bool) (bool, string) {
+	usagePerNodeRole := memberStatus.MemberStatus.ResourceUsage.MemoryUsagePerNodeRole
+	if len(usagePerNodeRole) == 0 {
+		return false, "no resource usage reported"
+	}
+	reasons := checkUsagePerNodeRole("memory", usagePerNodeRole, threshold, ignoredRoles)
+	return len(reasons) == 0, strings.Join(reasons, ", ")
+}
+
+// checkUsagePerNodeRole returns the reasons for all the node roles whose usage of the resource reached the threshold
+func checkUsagePerNodeRole(resourceName string, usagePerNodeRole map[string]int, threshold crtCfg.ResourceThreshold, ignoredRoles map[string]bool) []string {
+	roles := make([]string, 0, len(usagePerNodeRole))
+	for role := range usagePerNodeRole {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	var reasons []string
+	for _, role := range roles {
+		roleThreshold := threshold.ForNodeRole(role)
+		if ignoredRoles[role] || roleThreshold == 0 {
+			continue
+		}
+		if usagePerNodeRole[role] >= roleThreshold {
+			reasons = append(reasons, fmt.Sprintf("%s %d%% >= threshold %d%% on %s role", resourceName, usagePerNodeRole[role], roleThreshold, role))
 		}
-		return true
 	}
-	return false
+	return reasons
 }
 
-func getOptimalTargetCluster(userSignup *toolchainv1alpha1.UserSignup, getMemberClusters cluster.GetMemberClustersFunc, conditions ...cluster.Condition) string {
+var isReady clusterCheck = func(c *cluster.CachedToolchainCluster) (bool, string) {
+	if !cluster.Ready(c) {
+		return false, "not ready"
+	}
+	return true, ""
+}
+
+// isNotDrained rejects the member clusters that are being drained
+func isNotDrained(drained map[string]string) clusterCheck {
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		if mode, found := drained[cluster.Name]; found {
+			return false, fmt.Sprintf("drained (%s)", mode)
+		}
+		return true, ""
+	}
+}
+
+func getOptimalTargetCluster(userSignup *toolchainv1alpha1.UserSignup, getMemberClusters cluster.GetMemberClustersFunc, strategy PlacementStrategy,
+	affinity *clusterAffinity, conditions ...cluster.Condition) string {
 	// If a target cluster hasn't been selected, select one from the members
 	if userSignup.Spec.TargetCluster != "" {
 		return userSignup.Spec.TargetCluster
 	}
-	// Automatic cluster selection based on cluster readiness
-	members := getMemberClusters(append(conditions, cluster.Ready)...)
+	// Automatic cluster selection based on cluster readiness and the given conditions
+	members := getMemberClusters(conditions...)
 
-	if len(members) > 0 {
-		return members[0].Name
-	}
-	return ""
+	// The strategy selects only from the members that match the preferred cluster affinity the best
+	return strategy.SelectCluster(affinity.preferredCandidates(members))
 }
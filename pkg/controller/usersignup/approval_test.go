@@ -4,14 +4,19 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/approvalbudget"
+	"github.com/codeready-toolchain/host-operator/pkg/capacity"
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
 	. "github.com/codeready-toolchain/host-operator/test"
 	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -39,7 +44,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -59,7 +64,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -79,7 +84,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -99,7 +104,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -107,6 +112,29 @@ func TestGetClusterIfApproved(t *testing.T) {
 		assert.Equal(t, "member2", clusterName.getClusterName())
 	})
 
+	t.Run("with two clusters where the first one reaches max weighted number of UserAccounts", func(t *testing.T) {
+		// given
+		hostOperatorConfig := NewHostOperatorConfigWithReset(t,
+			AutomaticApproval().
+				Enabled().
+				MaxUsersNumber(2000, PerMemberCluster("member1", 1000), PerMemberCluster("member2", 700)).
+				ResourceCapThreshold(80, PerMemberCluster("member1", 90), PerMemberCluster("member2", 95)))
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+		counter.IncrementUserAccountCount("member1", 300)
+		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
+
+		// when
+		approved, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, notFound, clusterName)
+		assert.Equal(t, "member1 rejected: weighted number of users 1100 (801 users) >= threshold 1000; "+
+			"member2 rejected: number of users 700 >= threshold 700", msg)
+	})
+
 	t.Run("with two clusters, none of them is returned since it reaches max number of MURs", func(t *testing.T) {
 		// given
 		hostOperatorConfig := NewHostOperatorConfigWithReset(t,
@@ -119,7 +147,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -139,7 +167,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -159,7 +187,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -174,7 +202,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -189,7 +217,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -205,7 +233,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		signup := NewUserSignup(Approved())
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -223,7 +251,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		signup := NewUserSignup(Approved())
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -243,7 +271,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		signup := NewUserSignup(Approved())
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -261,7 +289,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		signup := NewUserSignup(Approved(), WithTargetCluster("member1"))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -281,7 +309,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 		clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionFalse), NewMemberCluster(t, "member2", v1.ConditionTrue))
 
 		// when
-		approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 		// then
 		require.NoError(t, err)
@@ -300,7 +328,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 			clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
 
 			// when
-			approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+			approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 			// then
 			require.EqualError(t, err, "unable to read HostOperatorConfig resource: some error")
@@ -321,7 +349,7 @@ func TestGetClusterIfApproved(t *testing.T) {
 			clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
 
 			// when
-			approved, clusterName, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+			approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
 
 			// then
 			require.EqualError(t, err, "unable to read ToolchainStatus resource: some error")
@@ -341,10 +369,283 @@ func TestGetClusterIfApprovedWhenCounterIsNotInitialized(t *testing.T) {
 	clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
 
 	// when
-	approved, clusterName, err := getClusterIfApproved(fakeClient, config, NewUserSignup(), clusters)
+	approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, NewUserSignup(), clusters)
 
 	// then
 	require.EqualError(t, err, "unable to get the number of provisioned users: counter is not initialized")
 	assert.False(t, approved)
 	assert.Equal(t, unknown, clusterName)
 }
+
+func TestGetClusterIfApprovedWithApprovalBudget(t *testing.T) {
+	// given
+	approvalbudget.Reset()
+	defer approvalbudget.Reset()
+	restore := SetEnvVarsAndRestore(t,
+		Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "2"),
+		Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_PERMEMBERCLUSTER", "member1=1"))
+	defer restore()
+	config, err := configuration.LoadConfig(NewFakeClient(t))
+	require.NoError(t, err)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1500)),
+		WithMember("member1", WithUserAccountCount(800), WithNodeRoleUsage("worker", 68), WithNodeRoleUsage("master", 65)),
+		WithMember("member2", WithUserAccountCount(700), WithNodeRoleUsage("worker", 55), WithNodeRoleUsage("master", 60)))
+	hostOperatorConfig := NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled())
+	fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+	InitializeCounters(t, toolchainStatus)
+	clusters := NewGetMemberClusters(
+		NewMemberCluster(t, "member1", v1.ConditionTrue),
+		NewMemberCluster(t, "member2", v1.ConditionTrue))
+
+	t.Run("first approval goes to member1", func(t *testing.T) {
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, NewUserSignup(), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+		assert.Equal(t, "hourly: 2/2, member1: 1/1", approvalbudget.GetRemaining(config, time.Now()).String(), "the budget is charged only when the MasterUserRecord is created")
+		consumeApprovalBudget(config, clusterName.getClusterName(), nil, time.Now())
+	})
+
+	t.Run("second approval goes to member2 since the budget of member1 is exhausted", func(t *testing.T) {
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, NewUserSignup(), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member2", clusterName.getClusterName())
+		consumeApprovalBudget(config, clusterName.getClusterName(), nil, time.Now())
+	})
+
+	t.Run("manual approval ignores the budget and doesn't consume it", func(t *testing.T) {
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, NewUserSignup(Approved()), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+		assert.Equal(t, "hourly: 0/2, member1: 0/1", approvalbudget.GetRemaining(config, time.Now()).String())
+	})
+
+	t.Run("no cluster is available when the hourly budget is exhausted", func(t *testing.T) {
+		// when
+		approved, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, NewUserSignup(), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, notFound, clusterName)
+		assert.Equal(t, "automatic approval budget exhausted (hourly: 0/2, member1: 0/1); member1 rejected: automatic approval budget exhausted; member2 rejected: automatic approval budget exhausted", msg)
+	})
+}
+
+func TestApprovalBudgetChargedOnlyWhenMasterUserRecordIsCreated(t *testing.T) {
+	// given
+	approvalbudget.Reset()
+	defer approvalbudget.Reset()
+	restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "5")
+	defer restore()
+	userSignup := NewUserSignup()
+	ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+	r, req, fakeClient := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+	fakeClient.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+		if _, ok := obj.(*v1alpha1.MasterUserRecord); ok {
+			return fmt.Errorf("unable to create mur")
+		}
+		return fakeClient.Client.Create(ctx, obj, opts...)
+	}
+
+	t.Run("not charged when the MasterUserRecord creation fails", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			// when
+			_, err := r.Reconcile(req)
+
+			// then
+			require.EqualError(t, err, "Error creating MasterUserRecord: unable to create mur")
+		}
+		assert.Equal(t, "hourly: 5/5", approvalbudget.GetRemaining(r.crtConfig, time.Now()).String())
+	})
+
+	t.Run("charged once when the MasterUserRecord is created", func(t *testing.T) {
+		// given
+		fakeClient.MockCreate = nil
+
+		// when
+		_, err := r.Reconcile(req)
+		require.NoError(t, err)
+		_, err = r.Reconcile(req)
+		require.NoError(t, err)
+
+		// then
+		assert.Equal(t, "hourly: 4/5", approvalbudget.GetRemaining(r.crtConfig, time.Now()).String())
+	})
+}
+
+func TestGetClusterIfApprovedWithResourceCapacity(t *testing.T) {
+	// given
+	capacity.Reset()
+	defer capacity.Reset()
+	signup := NewUserSignup()
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(10)),
+		WithMember("member1", WithUserAccountCount(5), WithNodeRoleUsage("worker", 60), WithNodeRoleUsage("master", 85)),
+		WithMember("member2", WithUserAccountCount(5), WithNodeRoleUsage("worker", 50), WithNodeRoleUsage("master", 50)))
+	hostOperatorConfig := NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled().ResourceCapThreshold(80))
+	clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
+	capacity.Set("member1", capacity.Usage{
+		CPURequestsPerNodeRole:              map[string]int{"worker": 75, "master": 95},
+		EphemeralStorageRequestsPerNodeRole: map[string]int{"worker": 20, "master": 30},
+		PVCStorage:                          resource.MustParse("900Gi"),
+	}, time.Now())
+	capacity.Set("member2", capacity.Usage{
+		CPURequestsPerNodeRole:              map[string]int{"worker": 85, "master": 10},
+		EphemeralStorageRequestsPerNodeRole: map[string]int{"worker": 20, "master": 30},
+		PVCStorage:                          resource.MustParse("100Gi"),
+	}, time.Now())
+
+	t.Run("memory threshold of the master role is reached", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member2", clusterName.getClusterName())
+	})
+
+	t.Run("master role is ignored", func(t *testing.T) {
+		// given
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_IGNOREDNODEROLES", "master")
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+	})
+
+	t.Run("memory threshold overridden for the worker role", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_IGNOREDNODEROLES", "master"),
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_MEMORY_PERNODEROLE", "worker=55"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member2", clusterName.getClusterName())
+	})
+
+	t.Run("CPU threshold is reached in both clusters", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_CPU_THRESHOLD", "80"),
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_CPU_PERNODEROLE", "worker=70"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, notFound, clusterName)
+		assert.Equal(t, "member1 rejected: memory 85% >= threshold 80% on master role, CPU requests 95% >= threshold 80% on master role, "+
+			"CPU requests 75% >= threshold 70% on worker role; member2 rejected: CPU requests 85% >= threshold 70% on worker role", msg)
+	})
+
+	t.Run("PVC storage threshold is reached", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_IGNOREDNODEROLES", "master"),
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_PVC_THRESHOLD", "90"),
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_PVC_STORAGE", "member1=1000Gi"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member2", clusterName.getClusterName())
+	})
+
+	t.Run("usage is stale", func(t *testing.T) {
+		// given
+		capacity.Reset()
+		capacity.Set("member1", capacity.Usage{}, time.Now().Add(-31*time.Minute))
+		capacity.Set("member2", capacity.Usage{}, time.Now().Add(-29*time.Minute))
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_EPHEMERALSTORAGE_THRESHOLD", "80")
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member2", clusterName.getClusterName())
+		assert.Empty(t, msg)
+	})
+
+	t.Run("usage not collected", func(t *testing.T) {
+		// given
+		capacity.Reset()
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_RESOURCECAPACITY_EPHEMERALSTORAGE_THRESHOLD", "80")
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, notFound, clusterName)
+		assert.Equal(t, "member1 rejected: memory 85% >= threshold 80% on master role, no CPU and storage usage collected; "+
+			"member2 rejected: no CPU and storage usage collected", msg)
+	})
+}
@@ -0,0 +1,106 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UserSignupDryRunApprovalAnnotationKey is the annotation that asks for a dry-run evaluation of the approval of the UserSignup.
+// The result of the evaluation is written to the ApprovalDryRun condition and the annotation is removed afterwards.
+const UserSignupDryRunApprovalAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "dry-run-approval"
+
+// isDryRunApprovalRequested returns true if the UserSignup has the dry-run approval annotation
+func isDryRunApprovalRequested(userSignup *toolchainv1alpha1.UserSignup) bool {
+	_, found := userSignup.Annotations[UserSignupDryRunApprovalAnnotationKey]
+	return found
+}
+
+// dryRunApproval evaluates if the UserSignup would be approved now and to which member cluster it would be provisioned.
+// If the user is banned, deactivated or already provisioned, then this is reported instead of the evaluation of the approval.
+// Neither the approval, the approval budgets nor the state of the placement strategy are changed, the outcome is only written
+// to the ApprovalDryRun condition.
+// Then the dry-run approval annotation is removed so the evaluation can be requested again.
+func (r *ReconcileUserSignup) dryRunApproval(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) error {
+	reqLogger.Info("evaluating approval in the dry-run mode")
+	blocker, err := r.getApprovalBlocker(reqLogger, userSignup)
+	if err != nil {
+		return r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.set(statusApprovalDryRunFailed), err, "dry-run approval evaluation failed")
+	}
+	approved := false
+	message := fmt.Sprintf("the user would not be approved: %s", blocker)
+	if blocker == "" {
+		evaluation, err := evaluateApproval(r.client, r.crtConfig, userSignup, r.getMemberClusters, time.Now(), true)
+		if err != nil {
+			return r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.set(statusApprovalDryRunFailed), err, "dry-run approval evaluation failed")
+		}
+		approved = evaluation.approved && evaluation.targetCluster != notFound
+		message = evaluation.describe(userSignup)
+	}
+	if approved {
+		err = r.updateStatusWithMessage(reqLogger, userSignup, r.set(statusApprovalDryRunApproved), message)
+	} else {
+		err = r.updateStatusWithMessage(reqLogger, userSignup, r.set(statusApprovalDryRunNotApproved), message)
+	}
+	if err != nil {
+		return err
+	}
+
+	delete(userSignup.Annotations, UserSignupDryRunApprovalAnnotationKey)
+	if err := r.client.Update(context.TODO(), userSignup); err != nil {
+		return errs.Wrapf(err, "unable to remove the annotation '%s'", UserSignupDryRunApprovalAnnotationKey)
+	}
+	return nil
+}
+
+// getApprovalBlocker returns the reason why the UserSignup would not get to the approval at all, ie. when the user is banned,
+// deactivated or already provisioned. These are checked in the same way (and in the same order) as in the regular reconcile
+// before the approval. If there is no such reason, then it returns an empty string.
+func (r *ReconcileUserSignup) getApprovalBlocker(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) (string, error) {
+	banned, err := r.isUserBanned(reqLogger, userSignup)
+	if err != nil {
+		return "", err
+	}
+	murList := &toolchainv1alpha1.MasterUserRecordList{}
+	if err := r.client.List(context.TODO(), murList, client.InNamespace(userSignup.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.MasterUserRecordOwnerLabelKey: userSignup.Name}); err != nil {
+		return "", errs.Wrap(err, "unable to list MasterUserRecords by owner")
+	}
+	switch {
+	case banned:
+		return "the user is banned", nil
+	case userSignup.Spec.Deactivated:
+		return "the user is deactivated", nil
+	case len(murList.Items) > 0:
+		return fmt.Sprintf("the user is already provisioned (MasterUserRecord '%s' exists)", murList.Items[0].Name), nil
+	}
+	return "", nil
+}
+
+// describe returns a human-readable description of the evaluation
+func (e approvalEvaluation) describe(userSignup *toolchainv1alpha1.UserSignup) string {
+	approval := "automatically"
+	if userSignup.Spec.Approved {
+		approval = "by admin"
+	}
+	var outcome string
+	switch {
+	case !e.approved:
+		outcome = "the user would not be approved automatically"
+	case e.targetCluster == notFound:
+		outcome = fmt.Sprintf("the user would be approved %s, but there is no suitable member cluster", approval)
+	case len(e.additionalTargetClusters) > 0:
+		outcome = fmt.Sprintf("the user would be approved %s and provisioned to the member clusters '%s'", approval,
+			strings.Join(append([]string{e.targetCluster.getClusterName()}, e.additionalTargetClusters...), "', '"))
+	default:
+		outcome = fmt.Sprintf("the user would be approved %s and provisioned to the member cluster '%s'", approval, e.targetCluster.getClusterName())
+	}
+	return joinMessages(outcome, e.message)
+}
@@ -0,0 +1,224 @@
+package usersignup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/approvalbudget"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDryRunApproval(t *testing.T) {
+	withDryRun := func(userSignup *v1alpha1.UserSignup) {
+		userSignup.Annotations[UserSignupDryRunApprovalAnnotationKey] = ""
+	}
+	ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+
+	t.Run("would be approved automatically", func(t *testing.T) {
+		// given
+		approvalbudget.Reset()
+		defer approvalbudget.Reset()
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "1")
+		defer restore()
+		userSignup := NewUserSignup(withDryRun)
+		r, req, cl := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		userSignup = assertDryRunCondition(t, cl, userSignup.Name, v1.ConditionTrue, UserSignupDryRunApprovedReason,
+			"the user would be approved automatically and provisioned to the member cluster 'member1'")
+		_, found := condition.FindConditionByType(userSignup.Status.Conditions, v1alpha1.UserSignupApproved)
+		assert.False(t, found)
+		AssertThatCounters(t).HaveMasterUserRecords(1)
+		assert.Equal(t, "hourly: 1/1", approvalbudget.GetRemaining(r.crtConfig, time.Now()).String())
+	})
+
+	t.Run("would be provisioned to multiple member clusters", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(withDryRun)
+		userSignup.Annotations[UserSignupUserAccountsAnnotationKey] = "2"
+		members := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
+		r, req, cl := prepareReconcile(t, userSignup.Name, members, userSignup, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		assertDryRunCondition(t, cl, userSignup.Name, v1.ConditionTrue, UserSignupDryRunApprovedReason,
+			"the user would be approved automatically and provisioned to the member clusters 'member1', 'member2'")
+	})
+
+	t.Run("doesn't change the member cluster selected for the next user", func(t *testing.T) {
+		// given
+		resetPlacementState(t)
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_PLACEMENT_STRATEGY", "weighted-round-robin")
+		defer restore()
+		userSignup := NewUserSignup(withDryRun)
+		members := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue))
+		r, req, cl := prepareReconcile(t, userSignup.Name, members, userSignup, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		assertDryRunCondition(t, cl, userSignup.Name, v1.ConditionTrue, UserSignupDryRunApprovedReason,
+			"the user would be approved automatically and provisioned to the member cluster 'member1'")
+		_, clusterName, _, _, err := getClusterIfApproved(cl, r.crtConfig, NewUserSignup(), members)
+		require.NoError(t, err)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+	})
+
+	t.Run("would not be approved when automatic approval is disabled", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(withDryRun)
+		r, req, cl := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, AutomaticApproval().Disabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		assertDryRunCondition(t, cl, userSignup.Name, v1.ConditionFalse, UserSignupDryRunNotApprovedReason,
+			"the user would not be approved automatically")
+	})
+
+	t.Run("approved by admin but no suitable member cluster", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(Approved(), withDryRun)
+		r, req, cl := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, NewHostOperatorConfigWithReset(t), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		assertDryRunCondition(t, cl, userSignup.Name, v1.ConditionFalse, UserSignupDryRunNotApprovedReason,
+			"the user would be approved by admin, but there is no suitable member cluster")
+	})
+
+	t.Run("would not be approved when banned", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(withDryRun)
+		bannedUser := &v1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "banned",
+				Namespace: HostOperatorNs,
+				Labels: map[string]string{
+					v1alpha1.BannedUserEmailHashLabelKey: userSignup.Labels[v1alpha1.UserSignupUserEmailHashLabelKey],
+				},
+			},
+			Spec: v1alpha1.BannedUserSpec{
+				Email: userSignup.Annotations[v1alpha1.UserSignupUserEmailAnnotationKey],
+			},
+		}
+		r, req, cl := prepareReconcile(t, userSignup.Name, ready, userSignup, bannedUser, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		userSignup = assertDryRunCondition(t, cl, userSignup.Name, v1.ConditionFalse, UserSignupDryRunNotApprovedReason,
+			"the user would not be approved: the user is banned")
+		assert.Empty(t, userSignup.Labels[v1alpha1.UserSignupStateLabelKey])
+	})
+
+	t.Run("would not be approved when deactivated", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(Approved(), Deactivated(), withDryRun)
+		r, req, cl := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		assertDryRunCondition(t, cl, userSignup.Name, v1.ConditionFalse, UserSignupDryRunNotApprovedReason,
+			"the user would not be approved: the user is deactivated")
+	})
+
+	t.Run("would not be approved when already provisioned", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(withDryRun)
+		mur := &v1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: HostOperatorNs,
+				Labels:    map[string]string{v1alpha1.MasterUserRecordOwnerLabelKey: userSignup.Name},
+			},
+		}
+		r, req, cl := prepareReconcile(t, userSignup.Name, ready, userSignup, mur, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		userSignup = &v1alpha1.UserSignup{}
+		require.NoError(t, cl.Get(context.TODO(), req.NamespacedName, userSignup))
+		assert.NotContains(t, userSignup.Annotations, UserSignupDryRunApprovalAnnotationKey)
+		dryRun, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupApprovalDryRun)
+		require.True(t, found)
+		assert.Equal(t, v1.ConditionFalse, dryRun.Status)
+		assert.Equal(t, UserSignupDryRunNotApprovedReason, dryRun.Reason)
+		assert.Equal(t, "the user would not be approved: the user is already provisioned (MasterUserRecord 'foo' exists)", dryRun.Message)
+	})
+
+	t.Run("evaluation failed", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(withDryRun)
+		r, req, cl := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()), baseNSTemplateTier)
+		counter.Reset()
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.Error(t, err)
+		userSignup = &v1alpha1.UserSignup{}
+		require.NoError(t, cl.Get(context.TODO(), req.NamespacedName, userSignup))
+		dryRun, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupApprovalDryRun)
+		require.True(t, found)
+		assert.Equal(t, UserSignupDryRunFailedReason, dryRun.Reason)
+		assert.Contains(t, userSignup.Annotations, UserSignupDryRunApprovalAnnotationKey)
+	})
+}
+
+func assertDryRunCondition(t *testing.T, cl client.Client, name string, status v1.ConditionStatus, reason, message string) *v1alpha1.UserSignup {
+	userSignup := &v1alpha1.UserSignup{}
+	require.NoError(t, cl.Get(context.TODO(), newReconcileRequest(name).NamespacedName, userSignup))
+	assert.NotContains(t, userSignup.Annotations, UserSignupDryRunApprovalAnnotationKey)
+	dryRun, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupApprovalDryRun)
+	require.True(t, found)
+	assert.Equal(t, status, dryRun.Status)
+	assert.Equal(t, reason, dryRun.Reason)
+	assert.Equal(t, message, dryRun.Message)
+	murs := &v1alpha1.MasterUserRecordList{}
+	require.NoError(t, cl.List(context.TODO(), murs))
+	assert.Empty(t, murs.Items)
+	return userSignup
+}
@@ -0,0 +1,80 @@
+package usersignup
+
+import (
+	"fmt"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+)
+
+// emailDomainRuleMatch is the result of matching the email domain of a user against the allow and deny lists
+type emailDomainRuleMatch struct {
+	domain  string
+	allowed bool
+	denied  bool
+	pattern string
+}
+
+// message returns a human readable description of the matching rule, or an empty string if no rule matched
+func (m emailDomainRuleMatch) message() string {
+	switch {
+	case m.denied:
+		return fmt.Sprintf("automatic approval denied: email domain '%s' matches the deny rule '%s'", m.domain, m.pattern)
+	case m.allowed:
+		return fmt.Sprintf("approved automatically: email domain '%s' matches the allow rule '%s'", m.domain, m.pattern)
+	}
+	return ""
+}
+
+// matchEmailDomainRules matches the domain of the user's email address against the configured deny and allow lists.
+// The deny list takes precedence, so if the domain matches both lists then the user is denied.
+func matchEmailDomainRules(crtConfig *crtCfg.Config, userSignup *toolchainv1alpha1.UserSignup) emailDomainRuleMatch {
+	domain := emailDomain(userSignup)
+	result := emailDomainRuleMatch{domain: domain}
+	if domain == "" {
+		return result
+	}
+	if pattern, found := findMatchingDomainPattern(domain, crtConfig.GetAutomaticApprovalDomainsDenied()); found {
+		result.denied = true
+		result.pattern = pattern.Value
+		return result
+	}
+	if pattern, found := findMatchingDomainPattern(domain, crtConfig.GetAutomaticApprovalDomainsAllowed()); found {
+		result.allowed = true
+		result.pattern = pattern.Value
+	}
+	return result
+}
+
+// emailDomain returns the lower-cased domain of the email address stored in the UserSignup annotation
+func emailDomain(userSignup *toolchainv1alpha1.UserSignup) string {
+	email := userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]
+	atIndex := strings.LastIndex(email, "@")
+	if atIndex < 0 {
+		return ""
+	}
+	return strings.ToLower(email[atIndex+1:])
+}
+
+func findMatchingDomainPattern(domain string, patterns []crtCfg.EmailDomainPattern) (crtCfg.EmailDomainPattern, bool) {
+	for _, pattern := range patterns {
+		if matchesDomainPattern(domain, pattern) {
+			return pattern, true
+		}
+	}
+	return crtCfg.EmailDomainPattern{}, false
+}
+
+// matchesDomainPattern checks if the domain matches the given pattern which is either an exact domain ("redhat.com"),
+// a wildcard matching any subdomain ("*.redhat.com") or a regular expression enclosed in slashes ("/^(eu|us)\.redhat\.com$/")
+// which was already compiled when the configuration was loaded
+func matchesDomainPattern(domain string, pattern crtCfg.EmailDomainPattern) bool {
+	switch {
+	case pattern.Regexp != nil:
+		return pattern.Regexp.MatchString(domain)
+	case strings.HasPrefix(pattern.Value, "*."):
+		return strings.HasSuffix(domain, strings.ToLower(pattern.Value[1:]))
+	}
+	return domain == strings.ToLower(strings.TrimPrefix(pattern.Value, "@"))
+}
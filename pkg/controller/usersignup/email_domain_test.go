@@ -0,0 +1,133 @@
+package usersignup
+
+import (
+	"testing"
+
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestMatchesDomainPattern(t *testing.T) {
+	tests := map[string]struct {
+		domain   string
+		pattern  string
+		expected bool
+	}{
+		"exact match":                         {domain: "redhat.com", pattern: "redhat.com", expected: true},
+		"exact match with at sign":            {domain: "redhat.com", pattern: "@redhat.com", expected: true},
+		"exact match is case insensitive":     {domain: "redhat.com", pattern: "RedHat.com", expected: true},
+		"exact match doesn't match subdomain": {domain: "eu.redhat.com", pattern: "redhat.com", expected: false},
+		"wildcard matches subdomain":          {domain: "eu.redhat.com", pattern: "*.redhat.com", expected: true},
+		"wildcard doesn't match the domain":   {domain: "redhat.com", pattern: "*.redhat.com", expected: false},
+		"wildcard doesn't match other domain": {domain: "notredhat.com", pattern: "*.redhat.com", expected: false},
+		"regexp matches":                      {domain: "mailinator.net", pattern: "/^mailinator\\.(com|net)$/", expected: true},
+		"regexp doesn't match":                {domain: "mailinator.org", pattern: "/^mailinator\\.(com|net)$/", expected: false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			pattern, err := configuration.ParseEmailDomainPattern(tc.pattern)
+			require.NoError(t, err)
+
+			// when
+			matches := matchesDomainPattern(tc.domain, pattern)
+
+			// then
+			assert.Equal(t, tc.expected, matches)
+		})
+	}
+}
+
+func TestGetClusterIfApprovedWithEmailDomainRules(t *testing.T) {
+	// given
+	restore := SetEnvVarsAndRestore(t,
+		Env("HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_ALLOWED", "redhat.com,*.partner.com"),
+		Env("HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_DENIED", "/^mailinator\\.(com|net)$/,denied.partner.com"))
+	defer restore()
+	config, err := configuration.LoadConfig(NewFakeClient(t))
+	require.NoError(t, err)
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(10)),
+		WithMember("member1", WithUserAccountCount(10), WithNodeRoleUsage("worker", 50), WithNodeRoleUsage("master", 50)))
+	clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+
+	t.Run("allowed domain is approved even when automatic approval is disabled", func(t *testing.T) {
+		// given
+		fakeClient := NewFakeClient(t, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Disabled()))
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, message, err := getClusterIfApproved(fakeClient, config, NewUserSignup(WithEmail("john@eu.partner.com")), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+		assert.Equal(t, "approved automatically: email domain 'eu.partner.com' matches the allow rule '*.partner.com'", message)
+	})
+
+	t.Run("denied domain is not approved even when automatic approval is enabled", func(t *testing.T) {
+		// given
+		fakeClient := NewFakeClient(t, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()))
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, message, err := getClusterIfApproved(fakeClient, config, NewUserSignup(WithEmail("john@mailinator.com")), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, unknown, clusterName)
+		assert.Equal(t, "automatic approval denied: email domain 'mailinator.com' matches the deny rule '/^mailinator\\.(com|net)$/'", message)
+	})
+
+	t.Run("deny list takes precedence over allow list", func(t *testing.T) {
+		// given
+		fakeClient := NewFakeClient(t, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Disabled()))
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, message, err := getClusterIfApproved(fakeClient, config, NewUserSignup(WithEmail("john@denied.partner.com")), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, unknown, clusterName)
+		assert.Equal(t, "automatic approval denied: email domain 'denied.partner.com' matches the deny rule 'denied.partner.com'", message)
+	})
+
+	t.Run("denied domain can still be approved manually", func(t *testing.T) {
+		// given
+		fakeClient := NewFakeClient(t, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Disabled()))
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, message, err := getClusterIfApproved(fakeClient, config, NewUserSignup(Approved(), WithEmail("john@mailinator.com")), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+		assert.Empty(t, message)
+	})
+
+	t.Run("other domain is not approved when automatic approval is disabled", func(t *testing.T) {
+		// given
+		fakeClient := NewFakeClient(t, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Disabled()))
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, message, err := getClusterIfApproved(fakeClient, config, NewUserSignup(WithEmail("john@gmail.com")), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, unknown, clusterName)
+		assert.Empty(t, message)
+	})
+}
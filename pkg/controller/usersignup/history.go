@@ -0,0 +1,115 @@
+package usersignup
+
+import (
+	"encoding/json"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+
+	errs "github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// UserSignupStateHistoryAnnotationKey contains the JSON encoded list of the transitions of the state label of the UserSignup,
+	// ordered from the oldest to the most recent one. The number of the kept transitions is limited.
+	//
+	// The history is kept in an annotation rather than in status.history because the UserSignupStatus type and the CRD
+	// are defined in the codeready-toolchain/api module. The StateTransition type matches the intended status field,
+	// so the history can be moved there (and the annotation converted) once the field is added.
+	UserSignupStateHistoryAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "state-history"
+
+	// UserSignupDeactivatedByAnnotationKey is set by the controller that deactivates the UserSignup, so the transition
+	// to the deactivated state can be attributed to it. The annotation is removed when the transition is recorded.
+	UserSignupDeactivatedByAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivated-by"
+)
+
+// the actors of the state transitions
+const (
+	// StateTransitionActorAutomatic is used for the transitions done by the host operator on its own
+	StateTransitionActorAutomatic = "automatic"
+	// StateTransitionActorAdmin is used for the transitions caused by an admin changing the UserSignup
+	StateTransitionActorAdmin = "admin"
+	// StateTransitionActorDeactivationController is used when the user was deactivated by the deactivation controller
+	StateTransitionActorDeactivationController = "deactivation-controller"
+	// StateTransitionActorBanning is used when the user was banned
+	StateTransitionActorBanning = "banning"
+)
+
+// StateTransition is a record of a change of the state label of the UserSignup
+type StateTransition struct {
+	// State is the new value of the state label
+	State string `json:"state"`
+	// PreviousState is the value of the state label before the transition (empty for a new UserSignup)
+	PreviousState string `json:"previousState,omitempty"`
+	// TransitionTime is the time when the state label was changed
+	TransitionTime metav1.Time `json:"transitionTime"`
+	// Actor is who (or what) caused the transition
+	Actor string `json:"actor"`
+	// Reason is a human readable explanation of the transition
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetStateHistory returns the recorded transitions of the state label of the given UserSignup, ordered from the oldest to the most recent one
+func GetStateHistory(userSignup *toolchainv1alpha1.UserSignup) ([]StateTransition, error) {
+	value, found := userSignup.Annotations[UserSignupStateHistoryAnnotationKey]
+	if !found || value == "" {
+		return nil, nil
+	}
+	var history []StateTransition
+	if err := json.Unmarshal([]byte(value), &history); err != nil {
+		return nil, errs.Wrapf(err, "unable to parse the annotation '%s'", UserSignupStateHistoryAnnotationKey)
+	}
+	return history, nil
+}
+
+// recordStateTransition appends the given transition to the history in the annotation of the UserSignup and drops the oldest
+// transitions so there are at most maxLength of them. If the current history cannot be parsed, then it is replaced by a new one.
+// The UserSignup resource is not updated.
+func recordStateTransition(userSignup *toolchainv1alpha1.UserSignup, transition StateTransition, maxLength int) error {
+	if maxLength <= 0 {
+		delete(userSignup.Annotations, UserSignupStateHistoryAnnotationKey)
+		return nil
+	}
+	history, err := GetStateHistory(userSignup)
+	if err != nil {
+		history = nil
+	}
+	history = append(history, transition)
+	if len(history) > maxLength {
+		history = history[len(history)-maxLength:]
+	}
+	value, err := json.Marshal(history)
+	if err != nil {
+		return errs.Wrapf(err, "unable to encode the annotation '%s'", UserSignupStateHistoryAnnotationKey)
+	}
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	userSignup.Annotations[UserSignupStateHistoryAnnotationKey] = string(value)
+	return nil
+}
+
+// approvalActor returns the actor of the approval of the given UserSignup
+func approvalActor(userSignup *toolchainv1alpha1.UserSignup) string {
+	if userSignup.Spec.Approved {
+		return StateTransitionActorAdmin
+	}
+	return StateTransitionActorAutomatic
+}
+
+// approvalReason returns the reason of the approval of the given UserSignup
+func approvalReason(userSignup *toolchainv1alpha1.UserSignup, approvalMessage string) string {
+	if userSignup.Spec.Approved {
+		return joinMessages("approved by admin", approvalMessage)
+	}
+	return joinMessages("approved automatically", approvalMessage)
+}
+
+// deactivationActor returns the actor of the deactivation of the given UserSignup - the controller that set the deactivated-by annotation
+// or an admin if there is no such annotation
+func deactivationActor(userSignup *toolchainv1alpha1.UserSignup) string {
+	if actor, found := userSignup.Annotations[UserSignupDeactivatedByAnnotationKey]; found && actor != "" {
+		return actor
+	}
+	return StateTransitionActorAdmin
+}
@@ -0,0 +1,221 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordStateTransition(t *testing.T) {
+	// given
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	transition := func(state string) StateTransition {
+		return StateTransition{
+			State:          state,
+			TransitionTime: now,
+			Actor:          StateTransitionActorAutomatic,
+			Reason:         fmt.Sprintf("%s reason", state),
+		}
+	}
+
+	t.Run("first transition", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup()
+
+		// when
+		err := recordStateTransition(userSignup, transition("not-ready"), 3)
+
+		// then
+		require.NoError(t, err)
+		history, err := GetStateHistory(userSignup)
+		require.NoError(t, err)
+		assert.Equal(t, []StateTransition{transition("not-ready")}, history)
+	})
+
+	t.Run("oldest transitions are dropped", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup()
+
+		// when
+		for _, state := range []string{"not-ready", "pending", "approved", "deactivated"} {
+			err := recordStateTransition(userSignup, transition(state), 3)
+			require.NoError(t, err)
+		}
+
+		// then
+		history, err := GetStateHistory(userSignup)
+		require.NoError(t, err)
+		assert.Equal(t, []StateTransition{transition("pending"), transition("approved"), transition("deactivated")}, history)
+	})
+
+	t.Run("invalid history is replaced", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup()
+		userSignup.Annotations[UserSignupStateHistoryAnnotationKey] = "invalid"
+		_, err := GetStateHistory(userSignup)
+		require.Error(t, err)
+
+		// when
+		err = recordStateTransition(userSignup, transition("approved"), 3)
+
+		// then
+		require.NoError(t, err)
+		history, err := GetStateHistory(userSignup)
+		require.NoError(t, err)
+		assert.Equal(t, []StateTransition{transition("approved")}, history)
+	})
+
+	t.Run("history disabled", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup()
+		require.NoError(t, recordStateTransition(userSignup, transition("not-ready"), 3))
+
+		// when
+		err := recordStateTransition(userSignup, transition("approved"), 0)
+
+		// then
+		require.NoError(t, err)
+		assert.NotContains(t, userSignup.Annotations, UserSignupStateHistoryAnnotationKey)
+	})
+}
+
+func TestUserSignupStateHistory(t *testing.T) {
+	t.Run("approved automatically", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup()
+		ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+		r, req, _ := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()), baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		history := getStateHistory(t, r, userSignup.Name)
+		require.Len(t, history, 2)
+		assertStateTransition(t, history[0], "", "not-ready", StateTransitionActorAutomatic, "new UserSignup")
+		assertStateTransition(t, history[1], "not-ready", "approved", StateTransitionActorAutomatic, "approved automatically")
+		assert.Equal(t, "Normal Approved approved automatically (actor: automatic)", <-r.recorder.(*record.FakeRecorder).Events)
+	})
+
+	t.Run("pending and then approved by admin", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup()
+		ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+		r, req, _ := prepareReconcile(t, userSignup.Name, ready, userSignup, baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus())
+		_, err := r.Reconcile(req)
+		require.NoError(t, err)
+		err = r.client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+		require.NoError(t, err)
+		userSignup.Spec.Approved = true
+		err = r.client.Update(context.TODO(), userSignup)
+		require.NoError(t, err)
+
+		// when
+		_, err = r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		history := getStateHistory(t, r, userSignup.Name)
+		require.Len(t, history, 3)
+		assertStateTransition(t, history[1], "not-ready", "pending", StateTransitionActorAutomatic, "pending approval")
+		assertStateTransition(t, history[2], "pending", "approved", StateTransitionActorAdmin, "approved by admin")
+	})
+
+	t.Run("deactivated by the deactivation controller", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(Deactivated(), WithStateLabel("approved"))
+		userSignup.Annotations[UserSignupDeactivatedByAnnotationKey] = StateTransitionActorDeactivationController
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		history := getStateHistory(t, r, userSignup.Name)
+		require.Len(t, history, 1)
+		assertStateTransition(t, history[0], "approved", "deactivated", StateTransitionActorDeactivationController, "user is deactivated")
+		updated := &v1alpha1.UserSignup{}
+		err = r.client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), updated)
+		require.NoError(t, err)
+		assert.NotContains(t, updated.Annotations, UserSignupDeactivatedByAnnotationKey)
+	})
+
+	t.Run("deactivated by admin", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(Deactivated(), WithStateLabel("approved"))
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		history := getStateHistory(t, r, userSignup.Name)
+		require.Len(t, history, 1)
+		assertStateTransition(t, history[0], "approved", "deactivated", StateTransitionActorAdmin, "user is deactivated")
+		assert.Equal(t, "Normal Deactivated user is deactivated (actor: admin)", <-r.recorder.(*record.FakeRecorder).Events)
+	})
+
+	t.Run("banned", func(t *testing.T) {
+		// given
+		userSignup := NewUserSignup(WithStateLabel("approved"))
+		bannedUser := &v1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "banned",
+				Namespace: test.HostOperatorNs,
+				Labels: map[string]string{
+					v1alpha1.BannedUserEmailHashLabelKey: userSignup.Labels[v1alpha1.UserSignupUserEmailHashLabelKey],
+				},
+			},
+			Spec: v1alpha1.BannedUserSpec{
+				Email: "foo@redhat.com",
+			},
+		}
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, bannedUser, baseNSTemplateTier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		history := getStateHistory(t, r, userSignup.Name)
+		require.Len(t, history, 1)
+		assertStateTransition(t, history[0], "approved", "banned", StateTransitionActorBanning, "user is banned")
+		assert.Equal(t, "Normal Banned user is banned (actor: banning)", <-r.recorder.(*record.FakeRecorder).Events)
+	})
+}
+
+func getStateHistory(t *testing.T, r *ReconcileUserSignup, name string) []StateTransition {
+	userSignup := &v1alpha1.UserSignup{}
+	err := r.client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, name), userSignup)
+	require.NoError(t, err)
+	history, err := GetStateHistory(userSignup)
+	require.NoError(t, err)
+	return history
+}
+
+func assertStateTransition(t *testing.T, transition StateTransition, previousState, state, actor, reason string) {
+	assert.Equal(t, previousState, transition.PreviousState)
+	assert.Equal(t, state, transition.State)
+	assert.Equal(t, actor, transition.Actor)
+	assert.Equal(t, reason, transition.Reason)
+	assert.WithinDuration(t, time.Now(), transition.TransitionTime.Time, time.Minute)
+}
@@ -40,18 +40,20 @@ func migrateOrFixMurIfNecessary(mur *toolchainv1alpha1.MasterUserRecord, nstempl
 	return changed, nil
 }
 
+// newMasterUserRecord returns a new MasterUserRecord with a UserAccount for the target cluster and for each of the additional target clusters
 func newMasterUserRecord(nstemplateTier *toolchainv1alpha1.NSTemplateTier, name, namespace, targetCluster,
-	userSignupName, userID string) (*toolchainv1alpha1.MasterUserRecord, error) {
-	userAccounts := []toolchainv1alpha1.UserAccountEmbedded{
-		{
-			TargetCluster: targetCluster,
+	userSignupName, userID string, additionalTargetClusters ...string) (*toolchainv1alpha1.MasterUserRecord, error) {
+	userAccounts := make([]toolchainv1alpha1.UserAccountEmbedded, 0, len(additionalTargetClusters)+1)
+	for _, cluster := range append([]string{targetCluster}, additionalTargetClusters...) {
+		userAccounts = append(userAccounts, toolchainv1alpha1.UserAccountEmbedded{
+			TargetCluster: cluster,
 			Spec: toolchainv1alpha1.UserAccountSpecEmbedded{
 				UserAccountSpecBase: toolchainv1alpha1.UserAccountSpecBase{
 					NSLimit:       "default",
 					NSTemplateSet: NewNSTemplateSetSpec(nstemplateTier),
 				},
 			},
-		},
+		})
 	}
 	hash, err := nstemplatetier.ComputeHashForNSTemplateTier(*nstemplateTier)
 	if err != nil {
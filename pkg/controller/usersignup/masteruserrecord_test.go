@@ -41,6 +41,23 @@ func TestNewMasterUserRecord(t *testing.T) {
 		withoutClusterRes.Spec.UserAccounts[0].Spec.NSTemplateSet.ClusterResources = nil
 		assert.EqualValues(t, withoutClusterRes, *mur)
 	})
+
+	t.Run("with additional target clusters", func(t *testing.T) {
+		// given
+		nsTemplateTier := newNsTemplateTier("advanced", "dev", "stage", "extra")
+
+		// when
+		mur, err := newMasterUserRecord(nsTemplateTier, "johny", test.HostOperatorNs, test.MemberClusterName,
+			"123456789", "UserID123", "member2-cluster")
+
+		// then
+		require.NoError(t, err)
+		expectedMur := newExpectedMur(*nsTemplateTier)
+		additionalAccount := expectedMur.Spec.UserAccounts[0]
+		additionalAccount.TargetCluster = "member2-cluster"
+		expectedMur.Spec.UserAccounts = append(expectedMur.Spec.UserAccounts, additionalAccount)
+		assert.Equal(t, expectedMur, *mur)
+	})
 }
 
 func TestNewNsTemplateSetSpec(t *testing.T) {
@@ -0,0 +1,88 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/hostoperatorconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/masteruserrecord"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// migrateOffDrainedClusterIfNecessary requests the migration of the UserAccount of the MasterUserRecord labeled by the ClusterDrain controller
+// to another member cluster. The target cluster is selected in the same way as for new users, except that the TargetCluster
+// set in the UserSignup is ignored. The MasterUserRecord controller then performs the migration itself.
+// If the member cluster isn't drained anymore before the migration starts, then the migration is cancelled.
+// Returns true if the MasterUserRecord was changed.
+func (r *ReconcileUserSignup) migrateOffDrainedClusterIfNecessary(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup,
+	mur *toolchainv1alpha1.MasterUserRecord) (bool, error) {
+	sourceCluster, found := mur.Labels[clusterdrain.MigrationSourceLabelKey]
+	if !found || !clusterdrain.HasUserAccountIn(mur, sourceCluster) {
+		// nothing to migrate or the UserAccount was already moved and the MasterUserRecord controller takes care of the rest
+		return false, nil
+	}
+	if _, requested := mur.Annotations[masteruserrecord.MigrationTargetAnnotationKey]; requested {
+		// the migration is in progress
+		return false, nil
+	}
+
+	drained, err := clusterdrain.GetDrainedClusters(r.client, userSignup.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if _, found := drained[sourceCluster]; !found {
+		reqLogger.Info("cancelling the migration as the member cluster is not drained anymore", "member_cluster", sourceCluster)
+		delete(mur.Labels, clusterdrain.MigrationSourceLabelKey)
+		if err := r.client.Update(context.TODO(), mur); err != nil {
+			return false, errs.Wrap(err, "unable to cancel the migration of the MasterUserRecord")
+		}
+		return true, nil
+	}
+
+	config, err := hostoperatorconfig.GetConfig(r.client, userSignup.Namespace)
+	if err != nil {
+		return false, errs.Wrapf(err, "unable to read HostOperatorConfig resource")
+	}
+	// the TargetCluster set in the UserSignup is most likely the drained member cluster, so it can't be respected
+	placement := userSignup.DeepCopy()
+	placement.Spec.TargetCluster = ""
+	targetCluster, rejections, err := selectTargetCluster(r.client, r.crtConfig, config, placement, r.getMemberClusters, false, hasNoUserAccountOf(mur))
+	if err != nil {
+		return false, errs.Wrap(err, "unable to select a member cluster to migrate the user to")
+	}
+	if targetCluster == "" {
+		message := joinMessages(fmt.Sprintf("no suitable member cluster found to migrate the user off the drained member cluster '%s'", sourceCluster),
+			rejections.String())
+		r.recorder.Event(userSignup, corev1.EventTypeWarning, UserSignupMigrationFailedReason, message)
+		// return an error so the migration is retried later
+		return false, fmt.Errorf("%s", message)
+	}
+
+	reqLogger.Info("migrating the user off the drained member cluster", "member_cluster", sourceCluster, "target_cluster", targetCluster)
+	if mur.Annotations == nil {
+		mur.Annotations = map[string]string{}
+	}
+	mur.Annotations[masteruserrecord.MigrationTargetAnnotationKey] = targetCluster
+	if err := r.client.Update(context.TODO(), mur); err != nil {
+		return false, errs.Wrap(err, "unable to request the migration of the MasterUserRecord")
+	}
+	r.recorder.Eventf(userSignup, corev1.EventTypeNormal, UserSignupMigratingReason,
+		"migrating the user from the drained member cluster '%s' to '%s'", sourceCluster, targetCluster)
+	return true, nil
+}
+
+// hasNoUserAccountOf rejects the member clusters that already contain a UserAccount of the given MasterUserRecord
+func hasNoUserAccountOf(mur *toolchainv1alpha1.MasterUserRecord) clusterCheck {
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		if clusterdrain.HasUserAccountIn(mur, cluster.Name) {
+			return false, "already contains the UserAccount"
+		}
+		return true, ""
+	}
+}
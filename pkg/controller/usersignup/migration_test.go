@@ -0,0 +1,159 @@
+package usersignup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/clusterdrain"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/masteruserrecord"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestMigrateOffDrainedCluster(t *testing.T) {
+	// given
+	userSignup := NewUserSignup(Approved(), WithTargetCluster("member1"), WithStateLabel("approved"))
+	newMur := func(t *testing.T, targetCluster string) *v1alpha1.MasterUserRecord {
+		mur, err := newMasterUserRecord(baseNSTemplateTier, "foo", test.HostOperatorNs, targetCluster, userSignup.Name, userSignup.Spec.UserID)
+		require.NoError(t, err)
+		mur.Labels[clusterdrain.MigrationSourceLabelKey] = "member1"
+		return mur
+	}
+	members := NewGetMemberClusters(
+		NewMemberCluster(t, "member1", v1.ConditionTrue),
+		NewMemberCluster(t, "member2", v1.ConditionTrue))
+
+	t.Run("moves the UserAccount to another member cluster", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, userSignup.Name, members, userSignup, baseNSTemplateTier, newMur(t, "member1"),
+			newDrainedToolchainCluster("member1", clusterdrain.DrainModeMigrate), NewHostOperatorConfigWithReset(t))
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		mur := getMur(t, cl)
+		require.Len(t, mur.Spec.UserAccounts, 1)
+		assert.Equal(t, "member1", mur.Spec.UserAccounts[0].TargetCluster) // the MasterUserRecord controller performs the migration
+		assert.Equal(t, "member1", mur.Labels[clusterdrain.MigrationSourceLabelKey])
+		assert.Equal(t, "member2", mur.Annotations[masteruserrecord.MigrationTargetAnnotationKey])
+		assert.Contains(t, <-r.recorder.(*record.FakeRecorder).Events,
+			"Normal Migrating migrating the user from the drained member cluster 'member1' to 'member2'")
+	})
+
+	t.Run("nothing to do when the UserAccount was already moved", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, userSignup.Name, members, userSignup, baseNSTemplateTier, newMur(t, "member2"),
+			newDrainedToolchainCluster("member1", clusterdrain.DrainModeMigrate), NewHostOperatorConfigWithReset(t))
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		mur := getMur(t, cl)
+		assert.Equal(t, "member2", mur.Spec.UserAccounts[0].TargetCluster)
+		assert.Equal(t, "member1", mur.Labels[clusterdrain.MigrationSourceLabelKey])
+	})
+
+	t.Run("nothing to do when the migration is in progress", func(t *testing.T) {
+		// given
+		mur := newMur(t, "member1")
+		mur.Annotations = map[string]string{masteruserrecord.MigrationTargetAnnotationKey: "member2"}
+		r, req, cl := prepareReconcile(t, userSignup.Name, members, userSignup, baseNSTemplateTier, mur,
+			NewHostOperatorConfigWithReset(t))
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		mur = getMur(t, cl)
+		assert.Equal(t, "member1", mur.Spec.UserAccounts[0].TargetCluster)
+		assert.Equal(t, "member1", mur.Labels[clusterdrain.MigrationSourceLabelKey])
+		assert.Equal(t, "member2", mur.Annotations[masteruserrecord.MigrationTargetAnnotationKey])
+	})
+
+	t.Run("cancels the migration when the member cluster is not drained anymore", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, userSignup.Name, members, userSignup, baseNSTemplateTier, newMur(t, "member1"),
+			NewHostOperatorConfigWithReset(t))
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		mur := getMur(t, cl)
+		assert.Equal(t, "member1", mur.Spec.UserAccounts[0].TargetCluster)
+		assert.NotContains(t, mur.Labels, clusterdrain.MigrationSourceLabelKey)
+	})
+
+	t.Run("fails when there is no other member cluster", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue)),
+			userSignup, baseNSTemplateTier, newMur(t, "member1"),
+			newDrainedToolchainCluster("member1", clusterdrain.DrainModeMigrate), NewHostOperatorConfigWithReset(t))
+		InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.EqualError(t, err, "no suitable member cluster found to migrate the user off the drained member cluster 'member1'; "+
+			"member1 rejected: already contains the UserAccount, drained (migrate)")
+		mur := getMur(t, cl)
+		assert.Equal(t, "member1", mur.Spec.UserAccounts[0].TargetCluster)
+		assert.Contains(t, <-r.recorder.(*record.FakeRecorder).Events, "Warning MigrationFailed")
+	})
+}
+
+func TestDrainedClusterIsNotSelected(t *testing.T) {
+	// given
+	userSignup := NewUserSignup()
+	r, req, cl := prepareReconcile(t, userSignup.Name,
+		NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue), NewMemberCluster(t, "member2", v1.ConditionTrue)),
+		userSignup, baseNSTemplateTier, newDrainedToolchainCluster("member1", clusterdrain.DrainModeCordon),
+		NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()))
+	InitializeCounters(t, NewToolchainStatus())
+
+	// when
+	_, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	mur := getMur(t, cl)
+	assert.Equal(t, "member2", mur.Spec.UserAccounts[0].TargetCluster)
+}
+
+func newDrainedToolchainCluster(name, drainMode string) *v1alpha1.ToolchainCluster {
+	return &v1alpha1.ToolchainCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: test.HostOperatorNs,
+			Labels: map[string]string{
+				clusterdrain.DrainLabelKey: drainMode,
+			},
+		},
+	}
+}
+
+func getMur(t *testing.T, cl *test.FakeClient) *v1alpha1.MasterUserRecord {
+	mur := &v1alpha1.MasterUserRecord{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: test.HostOperatorNs, Name: "foo"}, mur)
+	require.NoError(t, err)
+	return mur
+}
@@ -0,0 +1,208 @@
+package usersignup
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+)
+
+// PlacementStrategy selects the member cluster a new user should be provisioned to
+type PlacementStrategy interface {
+	// SelectCluster returns the name of the cluster chosen from the given candidates, or an empty string if there is no candidate.
+	// All the candidates are ready and satisfy all capacity conditions.
+	SelectCluster(candidates []*cluster.CachedToolchainCluster) string
+}
+
+// newPlacementStrategy returns the PlacementStrategy configured in the given config.
+// If dryRun is true, then the strategy only peeks at the selection, ie. the state kept between the individual selections is not changed,
+// so the following (real) selections are the same as if the dry-run selection didn't happen.
+func newPlacementStrategy(crtConfig *crtCfg.Config, counts counter.Counts, status *toolchainv1alpha1.ToolchainStatus, dryRun bool) (PlacementStrategy, error) {
+	switch crtConfig.GetPlacementStrategy() {
+	case crtCfg.PlacementStrategyFirstReady, "":
+		return firstReady{}, nil
+	case crtCfg.PlacementStrategyLeastLoaded:
+		return leastLoaded{counts: counts}, nil
+	case crtCfg.PlacementStrategyLowestMemoryUsage:
+		return lowestMemoryUsage{status: status}, nil
+	case crtCfg.PlacementStrategyWeightedRoundRobin:
+		return &weightedRoundRobin{weights: crtConfig.GetPlacementWeights(), dryRun: dryRun}, nil
+	case crtCfg.PlacementStrategyRandom:
+		return random{seed: crtConfig.GetPlacementRandomSeed(), dryRun: dryRun}, nil
+	}
+	return nil, fmt.Errorf("unknown placement strategy '%s'", crtConfig.GetPlacementStrategy())
+}
+
+// firstReady selects the first candidate
+type firstReady struct{}
+
+func (firstReady) SelectCluster(candidates []*cluster.CachedToolchainCluster) string {
+	if len(candidates) > 0 {
+		return candidates[0].Name
+	}
+	return ""
+}
+
+// leastLoaded selects the candidate with the lowest weighted number of UserAccounts
+type leastLoaded struct {
+	counts counter.Counts
+}
+
+func (s leastLoaded) SelectCluster(candidates []*cluster.CachedToolchainCluster) string {
+	return selectLowest(candidates, func(name string) int {
+		return s.counts.WeightedUserAccountsPerClusterCounts[name]
+	})
+}
+
+// lowestMemoryUsage selects the candidate whose most loaded node role has the lowest memory usage.
+// Clusters without any usage information in the ToolchainStatus are selected only if there is no other candidate.
+type lowestMemoryUsage struct {
+	status *toolchainv1alpha1.ToolchainStatus
+}
+
+func (s lowestMemoryUsage) SelectCluster(candidates []*cluster.CachedToolchainCluster) string {
+	return selectLowest(candidates, func(name string) int {
+		for _, memberStatus := range s.status.Status.Members {
+			if memberStatus.ClusterName == name && len(memberStatus.MemberStatus.ResourceUsage.MemoryUsagePerNodeRole) > 0 {
+				highest := 0
+				for _, usage := range memberStatus.MemberStatus.ResourceUsage.MemoryUsagePerNodeRole {
+					if usage > highest {
+						highest = usage
+					}
+				}
+				return highest
+			}
+		}
+		return 101 // over 100 percent, so it's the last option
+	})
+}
+
+// selectLowest returns the name of the candidate with the lowest value. If there are more candidates with the same value,
+// then it returns the first one in alphabetical order so the result is deterministic.
+func selectLowest(candidates []*cluster.CachedToolchainCluster, valueOf func(name string) int) string {
+	selected := ""
+	lowest := 0
+	for _, name := range sortedNames(candidates) {
+		value := valueOf(name)
+		if selected == "" || value < lowest {
+			selected = name
+			lowest = value
+		}
+	}
+	return selected
+}
+
+// weightedRoundRobinState keeps the current weights of the member clusters between the individual selections
+var weightedRoundRobinState = struct {
+	sync.Mutex
+	current map[string]int
+}{current: map[string]int{}}
+
+// weightedRoundRobin selects the candidates using the smooth weighted round-robin algorithm,
+// so a cluster with weight 3 gets three users for every user provisioned to a cluster with weight 1.
+// Clusters without any configured weight have the weight 1, clusters with weight 0 are never selected.
+type weightedRoundRobin struct {
+	weights map[string]int
+	// dryRun makes the strategy work with a copy of the current weights
+	dryRun bool
+}
+
+func (s *weightedRoundRobin) SelectCluster(candidates []*cluster.CachedToolchainCluster) string {
+	weightedRoundRobinState.Lock()
+	defer weightedRoundRobinState.Unlock()
+
+	current := weightedRoundRobinState.current
+	if s.dryRun {
+		current = make(map[string]int, len(weightedRoundRobinState.current))
+		for name, weight := range weightedRoundRobinState.current {
+			current[name] = weight
+		}
+	}
+	selected := ""
+	total := 0
+	for _, name := range sortedNames(candidates) {
+		weight, found := s.weights[name]
+		if !found {
+			weight = 1
+		}
+		if weight == 0 {
+			continue
+		}
+		total += weight
+		current[name] += weight
+		if selected == "" || current[name] > current[selected] {
+			selected = name
+		}
+	}
+	if selected != "" {
+		current[selected] -= total
+	}
+	return selected
+}
+
+// randomState keeps the number of selections made by the random strategy so the sequence of selections is given by the configured seed.
+// The generator of each selection is seeded by a value derived from the seed and the number of the previous selections,
+// so the next selection can be peeked at without replaying the previous ones.
+var randomState = struct {
+	sync.Mutex
+	initialized bool
+	seed        int64
+	// effectiveSeed is the configured seed or the current time if the configured seed is 0
+	effectiveSeed int64
+	selections    int64
+}{}
+
+// selectionSeed derives the seed of the generator used for the given selection from the given seed (using the SplitMix64 mixing function),
+// so the seeds of the consecutive selections are not correlated
+func selectionSeed(seed, selection int64) int64 {
+	z := uint64(seed) + uint64(selection+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// random selects a random candidate
+type random struct {
+	seed int64
+	// dryRun makes the strategy not count the selection, so the next selection is the same
+	dryRun bool
+}
+
+func (s random) SelectCluster(candidates []*cluster.CachedToolchainCluster) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	randomState.Lock()
+	defer randomState.Unlock()
+	if !randomState.initialized || randomState.seed != s.seed {
+		effectiveSeed := s.seed
+		if effectiveSeed == 0 {
+			effectiveSeed = time.Now().UnixNano()
+		}
+		randomState.initialized = true
+		randomState.seed = s.seed
+		randomState.effectiveSeed = effectiveSeed
+		randomState.selections = 0
+	}
+	generator := rand.New(rand.NewSource(selectionSeed(randomState.effectiveSeed, randomState.selections))) // nolint:gosec
+	if !s.dryRun {
+		randomState.selections++
+	}
+	names := sortedNames(candidates)
+	return names[generator.Intn(len(names))]
+}
+
+func sortedNames(candidates []*cluster.CachedToolchainCluster) []string {
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		names = append(names, candidate.Name)
+	}
+	sort.Strings(names)
+	return names
+}
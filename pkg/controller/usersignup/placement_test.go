@@ -0,0 +1,301 @@
+package usersignup
+
+import (
+	"testing"
+
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestGetClusterIfApprovedWithPlacementStrategy(t *testing.T) {
+	// given
+	signup := NewUserSignup()
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1500)),
+		WithMember("member1", WithUserAccountCount(800), WithNodeRoleUsage("worker", 68), WithNodeRoleUsage("master", 65)),
+		WithMember("member2", WithUserAccountCount(700), WithNodeRoleUsage("worker", 55), WithNodeRoleUsage("master", 60)),
+		WithMember("member3", WithUserAccountCount(750), WithNodeRoleUsage("worker", 50), WithNodeRoleUsage("master", 70)))
+	hostOperatorConfig := NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled().ResourceCapThreshold(80))
+	clusters := NewGetMemberClusters(
+		NewMemberCluster(t, "member1", v1.ConditionTrue),
+		NewMemberCluster(t, "member2", v1.ConditionTrue),
+		NewMemberCluster(t, "member3", v1.ConditionTrue))
+
+	t.Run("first-ready is used by default", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+	})
+
+	t.Run("least-loaded", func(t *testing.T) {
+		// given
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_PLACEMENT_STRATEGY", configuration.PlacementStrategyLeastLoaded)
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member2", clusterName.getClusterName())
+	})
+
+	t.Run("lowest-memory-usage", func(t *testing.T) {
+		// given
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_PLACEMENT_STRATEGY", configuration.PlacementStrategyLowestMemoryUsage)
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member2", clusterName.getClusterName())
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		// given
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_PLACEMENT_STRATEGY", "unknown")
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, toolchainStatus, hostOperatorConfig)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.EqualError(t, err, "unknown placement strategy 'unknown'")
+		assert.False(t, approved)
+		assert.Equal(t, unknown, clusterName)
+	})
+}
+
+func TestLowestMemoryUsageStrategy(t *testing.T) {
+	// given
+	toolchainStatus := NewToolchainStatus(
+		WithMember("member1", WithNodeRoleUsage("worker", 40), WithNodeRoleUsage("master", 75)),
+		WithMember("member2", WithNodeRoleUsage("worker", 60), WithNodeRoleUsage("master", 60)),
+		WithMember("member3"))
+	strategy := lowestMemoryUsage{status: toolchainStatus}
+
+	t.Run("the most loaded node role is taken into account", func(t *testing.T) {
+		// when
+		clusterName := strategy.SelectCluster(candidates(t, "member1", "member2", "member3"))
+
+		// then
+		assert.Equal(t, "member2", clusterName)
+	})
+
+	t.Run("cluster without resource usage is selected only as the last option", func(t *testing.T) {
+		// when
+		clusterName := strategy.SelectCluster(candidates(t, "member3"))
+
+		// then
+		assert.Equal(t, "member3", clusterName)
+	})
+
+	t.Run("no candidate", func(t *testing.T) {
+		// when
+		clusterName := strategy.SelectCluster(candidates(t))
+
+		// then
+		assert.Empty(t, clusterName)
+	})
+}
+
+func TestLeastLoadedStrategy(t *testing.T) {
+	t.Run("same weights", func(t *testing.T) {
+		// given
+		strategy := leastLoaded{counts: counter.Counts{
+			UserAccountsPerClusterCounts:         map[string]int{"member1": 10, "member2": 5, "member3": 5},
+			WeightedUserAccountsPerClusterCounts: map[string]int{"member1": 10, "member2": 5, "member3": 5},
+		}}
+
+		// when
+		clusterName := strategy.SelectCluster(candidates(t, "member3", "member1", "member2"))
+
+		// then
+		assert.Equal(t, "member2", clusterName) // member2 and member3 have the same load, so the first one in alphabetical order is selected
+	})
+
+	t.Run("weighted load", func(t *testing.T) {
+		// given
+		strategy := leastLoaded{counts: counter.Counts{
+			UserAccountsPerClusterCounts:         map[string]int{"member1": 10, "member2": 5, "member3": 5},
+			WeightedUserAccountsPerClusterCounts: map[string]int{"member1": 10, "member2": 20, "member3": 15},
+		}}
+
+		// when
+		clusterName := strategy.SelectCluster(candidates(t, "member3", "member1", "member2"))
+
+		// then
+		assert.Equal(t, "member1", clusterName)
+	})
+}
+
+func TestWeightedRoundRobinStrategy(t *testing.T) {
+	t.Run("respects weights", func(t *testing.T) {
+		// given
+		resetPlacementState(t)
+		strategy := &weightedRoundRobin{weights: map[string]int{"member1": 3, "member2": 1}}
+		selected := map[string]int{}
+
+		// when
+		for i := 0; i < 8; i++ {
+			selected[strategy.SelectCluster(candidates(t, "member1", "member2"))]++
+		}
+
+		// then
+		assert.Equal(t, map[string]int{"member1": 6, "member2": 2}, selected)
+	})
+
+	t.Run("clusters without weight have weight 1 and clusters with weight 0 are skipped", func(t *testing.T) {
+		// given
+		resetPlacementState(t)
+		strategy := &weightedRoundRobin{weights: map[string]int{"member3": 0}}
+		var selected []string
+
+		// when
+		for i := 0; i < 4; i++ {
+			selected = append(selected, strategy.SelectCluster(candidates(t, "member1", "member2", "member3")))
+		}
+
+		// then
+		assert.Equal(t, []string{"member1", "member2", "member1", "member2"}, selected)
+	})
+
+	t.Run("dry run doesn't change the next selection", func(t *testing.T) {
+		// given
+		resetPlacementState(t)
+		strategy := &weightedRoundRobin{weights: map[string]int{"member1": 3, "member2": 1}}
+		dryRun := &weightedRoundRobin{weights: map[string]int{"member1": 3, "member2": 1}, dryRun: true}
+		var selected []string
+
+		// when
+		for i := 0; i < 4; i++ {
+			peeked := dryRun.SelectCluster(candidates(t, "member1", "member2"))
+			assert.Equal(t, peeked, dryRun.SelectCluster(candidates(t, "member1", "member2")))
+			clusterName := strategy.SelectCluster(candidates(t, "member1", "member2"))
+			assert.Equal(t, peeked, clusterName)
+			selected = append(selected, clusterName)
+		}
+
+		// then
+		assert.Equal(t, []string{"member1", "member1", "member2", "member1"}, selected)
+	})
+
+	t.Run("no candidate", func(t *testing.T) {
+		// given
+		resetPlacementState(t)
+		strategy := &weightedRoundRobin{weights: map[string]int{"member1": 0}}
+
+		// when
+		clusterName := strategy.SelectCluster(candidates(t, "member1"))
+
+		// then
+		assert.Empty(t, clusterName)
+	})
+}
+
+func TestRandomStrategy(t *testing.T) {
+	selectClusters := func(t *testing.T, seed int64) []string {
+		resetPlacementState(t)
+		strategy := random{seed: seed}
+		var selected []string
+		for i := 0; i < 10; i++ {
+			selected = append(selected, strategy.SelectCluster(candidates(t, "member1", "member2", "member3")))
+		}
+		return selected
+	}
+
+	t.Run("same seed gives same sequence", func(t *testing.T) {
+		// when
+		first := selectClusters(t, 42)
+		second := selectClusters(t, 42)
+
+		// then
+		assert.Equal(t, first, second)
+		for _, clusterName := range first {
+			assert.Contains(t, []string{"member1", "member2", "member3"}, clusterName)
+		}
+	})
+
+	t.Run("dry run doesn't change the next selection", func(t *testing.T) {
+		// given
+		expected := selectClusters(t, 42)
+		resetPlacementState(t)
+		strategy := random{seed: 42}
+		dryRun := random{seed: 42, dryRun: true}
+		var selected []string
+
+		// when
+		for i := 0; i < 10; i++ {
+			peeked := dryRun.SelectCluster(candidates(t, "member1", "member2", "member3"))
+			assert.Equal(t, peeked, dryRun.SelectCluster(candidates(t, "member1", "member2", "member3")))
+			clusterName := strategy.SelectCluster(candidates(t, "member1", "member2", "member3"))
+			assert.Equal(t, peeked, clusterName)
+			selected = append(selected, clusterName)
+		}
+
+		// then
+		assert.Equal(t, expected, selected)
+	})
+
+	t.Run("no candidate", func(t *testing.T) {
+		// when
+		clusterName := random{seed: 42}.SelectCluster(candidates(t))
+
+		// then
+		assert.Empty(t, clusterName)
+	})
+}
+
+func candidates(t *testing.T, names ...string) []*cluster.CachedToolchainCluster {
+	clusters := make([]*cluster.CachedToolchainCluster, 0, len(names))
+	for _, name := range names {
+		clusters = append(clusters, NewMemberCluster(t, name, v1.ConditionTrue))
+	}
+	return clusters
+}
+
+func resetPlacementState(t *testing.T) {
+	reset := func() {
+		weightedRoundRobinState.Lock()
+		weightedRoundRobinState.current = map[string]int{}
+		weightedRoundRobinState.Unlock()
+		randomState.Lock()
+		randomState.initialized = false
+		randomState.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
@@ -1,6 +1,9 @@
 package usersignup
 
 import (
+	"time"
+
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
 	"github.com/codeready-toolchain/host-operator/pkg/controller/hostoperatorconfig"
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,13 +27,16 @@ type UserSignupChangedPredicate struct {
 // * annotation toolchain.dev.openshift.com/user-email has changed
 //
 // * label toolchain.dev.openshift.com/email-hash has changed
+//
+// * annotation toolchain.dev.openshift.com/dry-run-approval is set
 func (p UserSignupChangedPredicate) Update(e event.UpdateEvent) bool {
 	if !checkMetaObjects(changedLog, e) {
 		return false
 	}
 	if e.MetaNew.GetGeneration() == e.MetaOld.GetGeneration() &&
 		!p.AnnotationChanged(e, toolchainv1alpha1.UserSignupUserEmailAnnotationKey) &&
-		!p.LabelChanged(e, toolchainv1alpha1.UserSignupUserEmailHashLabelKey) {
+		!p.LabelChanged(e, toolchainv1alpha1.UserSignupUserEmailHashLabelKey) &&
+		!p.AnnotationSet(e, UserSignupDryRunApprovalAnnotationKey) {
 		return false
 	}
 	return true
@@ -40,6 +46,11 @@ func (p UserSignupChangedPredicate) AnnotationChanged(e event.UpdateEvent, annot
 	return e.MetaOld.GetAnnotations()[annotationName] != e.MetaNew.GetAnnotations()[annotationName]
 }
 
+func (p UserSignupChangedPredicate) AnnotationSet(e event.UpdateEvent, annotationName string) bool {
+	_, found := e.MetaNew.GetAnnotations()[annotationName]
+	return found
+}
+
 func (p UserSignupChangedPredicate) LabelChanged(e event.UpdateEvent, labelName string) bool {
 	return e.MetaOld.GetLabels()[labelName] != e.MetaNew.GetLabels()[labelName]
 }
@@ -47,8 +58,11 @@ func (p UserSignupChangedPredicate) LabelChanged(e event.UpdateEvent, labelName
 var configLog = logf.Log.WithName("automatic_approval_predicate")
 
 // OnlyWhenAutomaticApprovalIsEnabled let the reconcile to be triggered only when the automatic approval is enabled
+// or when there is any email domain whose users are approved automatically, and only when the automatic approval
+// is active according to the configured schedule
 type OnlyWhenAutomaticApprovalIsEnabled struct {
-	client client.Client
+	client    client.Client
+	crtConfig *crtCfg.Config
 }
 
 // Update implements default UpdateEvent filter for validating no generation change
@@ -84,7 +98,15 @@ func (p OnlyWhenAutomaticApprovalIsEnabled) checkIfAutomaticApprovalIsEnabled(na
 		configLog.Error(nil, "unable to get HostOperatorConfig resource", "namespace", namespace)
 		return false
 	}
-	return config.AutomaticApproval.Enabled
+	if !config.AutomaticApproval.Enabled && len(p.crtConfig.GetAutomaticApprovalDomainsAllowed()) == 0 {
+		return false
+	}
+	active, _, err := checkAutomaticApprovalSchedule(p.client, p.crtConfig, namespace, time.Now())
+	if err != nil {
+		configLog.Error(err, "unable to check the automatic approval schedule", "namespace", namespace)
+		return false
+	}
+	return active
 }
 
 func checkMetaObjects(log logr.Logger, e event.UpdateEvent) bool {
@@ -2,9 +2,11 @@ package usersignup
 
 import (
 	"testing"
+	"time"
 
 	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
 	. "github.com/codeready-toolchain/host-operator/test"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	uuid "github.com/satori/go.uuid"
@@ -124,13 +126,27 @@ func TestUserSignupChangedPredicate(t *testing.T) {
 		}
 		require.True(t, pred.Update(e))
 	})
+	t.Run("test UserSignupChangedPredicate returns true when dry-run approval annotation added", func(t *testing.T) {
+		userSignupNewDryRun := userSignupOld.DeepCopy()
+		userSignupNewDryRun.Annotations[UserSignupDryRunApprovalAnnotationKey] = ""
+		e := event.UpdateEvent{
+			MetaOld:   userSignupOld.ObjectMeta.GetObjectMeta(),
+			ObjectOld: userSignupOld,
+			MetaNew:   userSignupNewDryRun.ObjectMeta.GetObjectMeta(),
+			ObjectNew: userSignupNewDryRun,
+		}
+		require.True(t, pred.Update(e))
+	})
 }
 
 func TestAutomaticApprovalPredicateWhenApprovalIsEnabled(t *testing.T) {
 	// given
 	cl := test.NewFakeClient(t, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()))
+	config, err := configuration.LoadConfig(cl)
+	require.NoError(t, err)
 	predicate := OnlyWhenAutomaticApprovalIsEnabled{
-		client: cl,
+		client:    cl,
+		crtConfig: config,
 	}
 	toolchainStatus := NewToolchainStatus()
 
@@ -258,8 +274,11 @@ func TestAutomaticApprovalPredicateWhenApprovalIsEnabled(t *testing.T) {
 func TestAutomaticApprovalPredicateWhenApprovalIsNotEnabled(t *testing.T) {
 	// given
 	cl := test.NewFakeClient(t, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Disabled()))
+	config, err := configuration.LoadConfig(cl)
+	require.NoError(t, err)
 	predicate := OnlyWhenAutomaticApprovalIsEnabled{
-		client: cl,
+		client:    cl,
+		crtConfig: config,
 	}
 	toolchainStatus := NewToolchainStatus()
 
@@ -293,3 +312,67 @@ func TestAutomaticApprovalPredicateWhenApprovalIsNotEnabled(t *testing.T) {
 		assert.False(t, shouldTriggerReconcile)
 	})
 }
+
+func TestAutomaticApprovalPredicateWhenApprovalIsNotEnabledButDomainsAreAllowed(t *testing.T) {
+	// given
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_ALLOWED", "redhat.com")
+	defer restore()
+	cl := test.NewFakeClient(t, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Disabled()))
+	config, err := configuration.LoadConfig(cl)
+	require.NoError(t, err)
+	predicate := OnlyWhenAutomaticApprovalIsEnabled{
+		client:    cl,
+		crtConfig: config,
+	}
+	toolchainStatus := NewToolchainStatus()
+	updateEvent := event.UpdateEvent{
+		MetaOld:   toolchainStatus.GetObjectMeta(),
+		ObjectOld: toolchainStatus,
+		MetaNew:   toolchainStatus.GetObjectMeta(),
+		ObjectNew: toolchainStatus,
+	}
+
+	// when
+	shouldTriggerReconcile := predicate.Update(updateEvent)
+
+	// then
+	assert.True(t, shouldTriggerReconcile)
+}
+
+func TestAutomaticApprovalPredicateWhenOutsideOfSchedule(t *testing.T) {
+	// given
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_WINDOWS", closedWindow(time.Now()))
+	defer restore()
+	cl := test.NewFakeClient(t, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()))
+	config, err := configuration.LoadConfig(cl)
+	require.NoError(t, err)
+	predicate := OnlyWhenAutomaticApprovalIsEnabled{
+		client:    cl,
+		crtConfig: config,
+	}
+	toolchainStatus := NewToolchainStatus()
+
+	t.Run("update", func(t *testing.T) {
+		// when
+		shouldTriggerReconcile := predicate.Update(event.UpdateEvent{
+			MetaOld:   toolchainStatus.GetObjectMeta(),
+			ObjectOld: toolchainStatus,
+			MetaNew:   toolchainStatus.GetObjectMeta(),
+			ObjectNew: toolchainStatus,
+		})
+
+		// then
+		assert.False(t, shouldTriggerReconcile)
+	})
+
+	t.Run("generic", func(t *testing.T) {
+		// when
+		shouldTriggerReconcile := predicate.Generic(event.GenericEvent{
+			Meta:   toolchainStatus.GetObjectMeta(),
+			Object: toolchainStatus,
+		})
+
+		// then
+		assert.False(t, shouldTriggerReconcile)
+	})
+}
@@ -0,0 +1,95 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// UserSignupDeactivatedEmailDomainAnnotationKey contains the domain of the email address the user had when the UserSignup was deactivated.
+	// It is set only if the re-verification on the change of the email domain is enabled.
+	UserSignupDeactivatedEmailDomainAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivated-email-domain"
+
+	// UserSignupReverificationRequestedAnnotationKey contains the time (in RFC3339 format) when the reactivated user was required
+	// to go through the verification again. The retention period of the unverified UserSignup starts at this time.
+	UserSignupReverificationRequestedAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "reverification-requested"
+)
+
+// recordDeactivatedEmailDomain stores the domain of the email address of the deactivated user in the annotation,
+// so it can be compared with the domain of the email address the user has when the UserSignup is reactivated
+func (r *ReconcileUserSignup) recordDeactivatedEmailDomain(userSignup *toolchainv1alpha1.UserSignup) error {
+	if !r.crtConfig.GetUserSignupReactivationVerificationOnEmailDomainChange() {
+		return nil
+	}
+	domain := emailDomain(userSignup)
+	if domain == "" || userSignup.Annotations[UserSignupDeactivatedEmailDomainAnnotationKey] == domain {
+		return nil
+	}
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	userSignup.Annotations[UserSignupDeactivatedEmailDomainAnnotationKey] = domain
+	if err := r.client.Update(context.TODO(), userSignup); err != nil {
+		return errs.Wrapf(err, "unable to set the annotation '%s'", UserSignupDeactivatedEmailDomainAnnotationKey)
+	}
+	return nil
+}
+
+// reverificationReason returns the reason why the user of the reactivated UserSignup has to go through the verification again,
+// or an empty string if there is no such a reason or if the UserSignup was not reactivated.
+// The UserSignup was reactivated if it isn't deactivated anymore, but the Complete condition still says it is. The time of the deactivation
+// is the last transition time of the condition (in the same way as for the retention period of the deactivated UserSignups).
+func reverificationReason(crtConfig *crtCfg.Config, userSignup *toolchainv1alpha1.UserSignup, now time.Time) string {
+	if userSignup.Spec.Deactivated || userSignup.Spec.VerificationRequired {
+		return ""
+	}
+	complete, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete)
+	if !found || complete.Status != corev1.ConditionTrue || complete.Reason != toolchainv1alpha1.UserSignupUserDeactivatedReason {
+		return ""
+	}
+	if period := crtConfig.GetUserSignupReactivationVerificationAfter(); period > 0 && complete.LastTransitionTime.Time.Before(now.Add(-period)) {
+		return fmt.Sprintf("deactivated for more than %s", period)
+	}
+	if crtConfig.GetUserSignupReactivationVerificationOnEmailDomainChange() {
+		previous, found := userSignup.Annotations[UserSignupDeactivatedEmailDomainAnnotationKey]
+		if current := emailDomain(userSignup); found && previous != current {
+			return fmt.Sprintf("email domain changed from '%s' to '%s'", previous, current)
+		}
+	}
+	return ""
+}
+
+// requireReverificationIfNeeded sets the VerificationRequired flag of the reactivated UserSignup if the user has to go through
+// the verification again before a new MasterUserRecord is created. Returns true if the flag was set.
+func (r *ReconcileUserSignup) requireReverificationIfNeeded(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) (bool, error) {
+	now := time.Now()
+	reason := reverificationReason(r.crtConfig, userSignup, now)
+	if reason == "" {
+		return false, nil
+	}
+	reqLogger.Info("reactivated user has to go through the verification again", "reason", reason)
+	userSignup.Spec.VerificationRequired = true
+	delete(userSignup.Annotations, UserSignupDeactivatedEmailDomainAnnotationKey)
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	userSignup.Annotations[UserSignupReverificationRequestedAnnotationKey] = now.Format(time.RFC3339)
+	if err := r.client.Update(context.TODO(), userSignup); err != nil {
+		return false, errs.Wrap(err, "unable to require the verification of the reactivated user")
+	}
+	message := fmt.Sprintf("verification required on reactivation: %s", reason)
+	if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueNotReady, StateTransitionActorAutomatic, message); err != nil {
+		return false, err
+	}
+	r.recorder.Event(userSignup, corev1.EventTypeNormal, UserSignupReverificationRequiredReason, message)
+	return true, r.updateStatusWithMessage(reqLogger, userSignup, r.setStatusVerificationRequired, message)
+}
@@ -0,0 +1,198 @@
+package usersignup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReverificationReason(t *testing.T) {
+	// given
+	now := time.Now()
+	newReactivatedUserSignup := func(deactivatedBefore time.Duration, email string) *v1alpha1.UserSignup {
+		userSignup := NewUserSignup(DeactivatedWithLastTransitionTime(deactivatedBefore))
+		userSignup.Spec.Deactivated = false
+		userSignup.Annotations[v1alpha1.UserSignupUserEmailAnnotationKey] = email
+		return userSignup
+	}
+	loadConfig := func(t *testing.T) *configuration.Config {
+		config, err := configuration.LoadConfig(test.NewFakeClient(t))
+		require.NoError(t, err)
+		return config
+	}
+
+	t.Run("not required when disabled", func(t *testing.T) {
+		// given
+		userSignup := newReactivatedUserSignup(365*24*time.Hour, "foo@acme.com")
+		userSignup.Annotations[UserSignupDeactivatedEmailDomainAnnotationKey] = "redhat.com"
+
+		// when
+		reason := reverificationReason(loadConfig(t), userSignup, now)
+
+		// then
+		assert.Empty(t, reason)
+	})
+
+	t.Run("deactivation period", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarsAndRestore(t, test.Env("HOST_OPERATOR_USERSIGNUP_REACTIVATION_VERIFICATION_AFTER", "720h"))
+		defer restore()
+		config := loadConfig(t)
+
+		t.Run("deactivated for too long", func(t *testing.T) {
+			// when
+			reason := reverificationReason(config, newReactivatedUserSignup(31*24*time.Hour, "foo@redhat.com"), now)
+
+			// then
+			assert.Equal(t, "deactivated for more than 720h0m0s", reason)
+		})
+
+		t.Run("not deactivated for too long", func(t *testing.T) {
+			// when
+			reason := reverificationReason(config, newReactivatedUserSignup(29*24*time.Hour, "foo@redhat.com"), now)
+
+			// then
+			assert.Empty(t, reason)
+		})
+
+		t.Run("still deactivated", func(t *testing.T) {
+			// given
+			userSignup := NewUserSignup(DeactivatedWithLastTransitionTime(365 * 24 * time.Hour))
+
+			// when
+			reason := reverificationReason(config, userSignup, now)
+
+			// then
+			assert.Empty(t, reason)
+		})
+
+		t.Run("never deactivated", func(t *testing.T) {
+			// given
+			userSignup := NewUserSignup(SignupComplete(""))
+			userSignup.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-365 * 24 * time.Hour))
+
+			// when
+			reason := reverificationReason(config, userSignup, now)
+
+			// then
+			assert.Empty(t, reason)
+		})
+
+		t.Run("verification already required", func(t *testing.T) {
+			// given
+			userSignup := newReactivatedUserSignup(365*24*time.Hour, "foo@redhat.com")
+			userSignup.Spec.VerificationRequired = true
+
+			// when
+			reason := reverificationReason(config, userSignup, now)
+
+			// then
+			assert.Empty(t, reason)
+		})
+	})
+
+	t.Run("email domain change", func(t *testing.T) {
+		// given
+		restore := test.SetEnvVarsAndRestore(t, test.Env("HOST_OPERATOR_USERSIGNUP_REACTIVATION_VERIFICATION_EMAILDOMAINCHANGE", "true"))
+		defer restore()
+		config := loadConfig(t)
+
+		t.Run("email domain changed", func(t *testing.T) {
+			// given
+			userSignup := newReactivatedUserSignup(time.Hour, "foo@ACME.com")
+			userSignup.Annotations[UserSignupDeactivatedEmailDomainAnnotationKey] = "redhat.com"
+
+			// when
+			reason := reverificationReason(config, userSignup, now)
+
+			// then
+			assert.Equal(t, "email domain changed from 'redhat.com' to 'acme.com'", reason)
+		})
+
+		t.Run("email domain not changed", func(t *testing.T) {
+			// given
+			userSignup := newReactivatedUserSignup(time.Hour, "bar@redhat.com")
+			userSignup.Annotations[UserSignupDeactivatedEmailDomainAnnotationKey] = "redhat.com"
+
+			// when
+			reason := reverificationReason(config, userSignup, now)
+
+			// then
+			assert.Empty(t, reason)
+		})
+
+		t.Run("email domain not recorded", func(t *testing.T) {
+			// when
+			reason := reverificationReason(config, newReactivatedUserSignup(time.Hour, "foo@acme.com"), now)
+
+			// then
+			assert.Empty(t, reason)
+		})
+	})
+}
+
+func TestUserSignupDeactivatedRecordsEmailDomain(t *testing.T) {
+	// given
+	restore := test.SetEnvVarsAndRestore(t, test.Env("HOST_OPERATOR_USERSIGNUP_REACTIVATION_VERIFICATION_EMAILDOMAINCHANGE", "true"))
+	defer restore()
+	userSignup := NewUserSignup(Deactivated())
+	userSignup.Status.CompliantUsername = "foo"
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()), baseNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus())
+
+	// when
+	_, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	err = r.client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+	require.NoError(t, err)
+	assert.Equal(t, "redhat.com", userSignup.Annotations[UserSignupDeactivatedEmailDomainAnnotationKey])
+	assert.Equal(t, "deactivated", userSignup.Labels[v1alpha1.UserSignupStateLabelKey])
+}
+
+func TestUserSignupReactivatedRequiresVerification(t *testing.T) {
+	// given
+	restore := test.SetEnvVarsAndRestore(t, test.Env("HOST_OPERATOR_USERSIGNUP_REACTIVATION_VERIFICATION_AFTER", "720h"))
+	defer restore()
+	userSignup := NewUserSignup(DeactivatedWithLastTransitionTime(60*24*time.Hour), ApprovedAutomatically(), WithStateLabel("deactivated"))
+	userSignup.Spec.Deactivated = false
+	userSignup.Status.CompliantUsername = "foo"
+	ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+	r, req, _ := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()), baseNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+	// when
+	_, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	err = r.client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+	require.NoError(t, err)
+	assert.True(t, userSignup.Spec.VerificationRequired)
+	requested, err := time.Parse(time.RFC3339, userSignup.Annotations[UserSignupReverificationRequestedAnnotationKey])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), requested, time.Minute)
+	assert.Equal(t, "not-ready", userSignup.Labels[v1alpha1.UserSignupStateLabelKey])
+	test.AssertContainsCondition(t, userSignup.Status.Conditions, v1alpha1.Condition{
+		Type:    v1alpha1.UserSignupComplete,
+		Status:  v1.ConditionFalse,
+		Reason:  v1alpha1.UserSignupVerificationRequiredReason,
+		Message: "verification required on reactivation: deactivated for more than 720h0m0s",
+	})
+	// no MasterUserRecord is created until the user is verified again
+	murs := &v1alpha1.MasterUserRecordList{}
+	require.NoError(t, r.client.List(context.TODO(), murs))
+	assert.Empty(t, murs.Items)
+	AssertThatCounters(t).HaveMasterUserRecords(1)
+}
@@ -0,0 +1,65 @@
+package usersignup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+)
+
+// clusterCheck checks if a user can be provisioned to the given member cluster.
+// If not, then it returns false together with a reason why the member cluster was rejected.
+type clusterCheck func(cluster *cluster.CachedToolchainCluster) (bool, string)
+
+// clusterRejections collects the reasons why the member clusters were rejected when looking for a target cluster
+type clusterRejections struct {
+	sync.Mutex
+	reasons map[string][]string
+}
+
+func newClusterRejections() *clusterRejections {
+	return &clusterRejections{
+		reasons: map[string][]string{},
+	}
+}
+
+// condition returns a cluster.Condition that is satisfied when all the given checks pass.
+// Unlike a plain list of conditions, all the checks are evaluated so the reasons of all the failed checks are recorded.
+func (r *clusterRejections) condition(checks ...clusterCheck) cluster.Condition {
+	return func(cluster *cluster.CachedToolchainCluster) bool {
+		var reasons []string
+		for _, check := range checks {
+			if ok, reason := check(cluster); !ok {
+				reasons = append(reasons, reason)
+			}
+		}
+		r.Lock()
+		defer r.Unlock()
+		// the member clusters can be evaluated more than once (eg. after refreshing the cache), so the last evaluation wins
+		if len(reasons) > 0 {
+			r.reasons[cluster.Name] = reasons
+		} else {
+			delete(r.reasons, cluster.Name)
+		}
+		return len(reasons) == 0
+	}
+}
+
+// String returns the reasons why the member clusters were rejected ordered by the cluster names,
+// eg. "member-1 rejected: not ready; member-2 rejected: memory 86% >= threshold 80% on worker role"
+func (r *clusterRejections) String() string {
+	r.Lock()
+	defer r.Unlock()
+	names := make([]string, 0, len(r.reasons))
+	for name := range r.reasons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	explanations := make([]string, len(names))
+	for i, name := range names {
+		explanations[i] = fmt.Sprintf("%s rejected: %s", name, strings.Join(r.reasons[name], ", "))
+	}
+	return strings.Join(explanations, "; ")
+}
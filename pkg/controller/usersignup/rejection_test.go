@@ -0,0 +1,63 @@
+package usersignup
+
+import (
+	"testing"
+
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestClusterRejections(t *testing.T) {
+	// given
+	member1 := NewMemberCluster(t, "member1", v1.ConditionFalse)
+	member2 := NewMemberCluster(t, "member2", v1.ConditionTrue)
+	member3 := NewMemberCluster(t, "member3", v1.ConditionTrue)
+	notMember3 := func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		if cluster.Name == "member3" {
+			return false, "is member3"
+		}
+		return true, ""
+	}
+	alwaysFails := func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		return false, "always fails"
+	}
+
+	t.Run("all reasons of the rejected clusters are collected", func(t *testing.T) {
+		// given
+		rejections := newClusterRejections()
+
+		// when
+		members := NewGetMemberClusters(member3, member2, member1)(rejections.condition(notMember3, isReady))
+
+		// then
+		assert.Len(t, members, 1)
+		assert.Equal(t, "member1 rejected: not ready; member3 rejected: is member3", rejections.String())
+	})
+
+	t.Run("all failed checks are reported", func(t *testing.T) {
+		// given
+		rejections := newClusterRejections()
+
+		// when
+		members := NewGetMemberClusters(member1)(rejections.condition(alwaysFails, isReady))
+
+		// then
+		assert.Empty(t, members)
+		assert.Equal(t, "member1 rejected: always fails, not ready", rejections.String())
+	})
+
+	t.Run("nothing is reported when no cluster is rejected", func(t *testing.T) {
+		// given
+		rejections := newClusterRejections()
+
+		// when
+		members := NewGetMemberClusters(member2)(rejections.condition(notMember3, isReady))
+
+		// then
+		assert.Len(t, members, 1)
+		assert.Empty(t, rejections.String())
+	})
+}
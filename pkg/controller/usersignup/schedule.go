@@ -0,0 +1,167 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// approvalSchedule represents the windows during which the automatic approval is active
+type approvalSchedule struct {
+	windows      []crtCfg.ApprovalWindow
+	location     *time.Location
+	maxApprovals int
+}
+
+func newApprovalSchedule(crtConfig *crtCfg.Config) approvalSchedule {
+	return approvalSchedule{
+		windows:      crtConfig.GetAutomaticApprovalSchedule(),
+		location:     crtConfig.GetAutomaticApprovalScheduleLocation(),
+		maxApprovals: crtConfig.GetAutomaticApprovalScheduleMaxApprovals(),
+	}
+}
+
+// isDefined returns true if there is at least one window defined, ie. if the automatic approval is restricted by the schedule
+func (s approvalSchedule) isDefined() bool {
+	return len(s.windows) > 0
+}
+
+// currentWindowStart returns the time when the currently open window started.
+// If there is no open window, then it returns false as the second value.
+func (s approvalSchedule) currentWindowStart(now time.Time) (time.Time, bool) {
+	now = now.In(s.location)
+	var start time.Time
+	found := false
+	// the window could have started yesterday if it ends after midnight
+	for _, day := range []time.Time{midnight(now).AddDate(0, 0, -1), midnight(now)} {
+		for _, window := range s.windows {
+			if !window.Weekdays[day.Weekday()] {
+				continue
+			}
+			from, to := windowBounds(day, window)
+			if !now.Before(from) && now.Before(to) && (!found || from.Before(start)) {
+				start = from
+				found = true
+			}
+		}
+	}
+	return start, found
+}
+
+// nextOpening returns the time when the next window opens after the given time
+func (s approvalSchedule) nextOpening(now time.Time) time.Time {
+	now = now.In(s.location)
+	var next time.Time
+	for days := 0; days <= 7; days++ {
+		day := midnight(now).AddDate(0, 0, days)
+		for _, window := range s.windows {
+			if !window.Weekdays[day.Weekday()] {
+				continue
+			}
+			if from, _ := windowBounds(day, window); from.After(now) && (next.IsZero() || from.Before(next)) {
+				next = from
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+// notifyOnOpenings sends a GenericEvent to the given channel every time a window opens, until the stop channel is closed
+func (s approvalSchedule) notifyOnOpenings(stop <-chan struct{}, events chan<- event.GenericEvent, crtConfig *crtCfg.Config) {
+	for {
+		timer := time.NewTimer(time.Until(s.nextOpening(time.Now())))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		namespace, err := k8sutil.GetWatchNamespace()
+		if err != nil {
+			log.Error(err, "unable to get the watch namespace, the pending UserSignups won't be requeued")
+			continue
+		}
+		status := &toolchainv1alpha1.ToolchainStatus{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      crtConfig.GetToolchainStatusName(),
+			},
+		}
+		log.Info("automatic approval window opened, requeuing the oldest pending UserSignups")
+		select {
+		case <-stop:
+			return
+		case events <- event.GenericEvent{Meta: status, Object: status}:
+		}
+	}
+}
+
+// checkAutomaticApprovalSchedule checks if the automatic approval is active according to the schedule at the given time
+// and if the maximum number of automatic approvals for the current window hasn't been reached yet.
+// If the automatic approval is not active, then it returns false together with a message describing the reason.
+func checkAutomaticApprovalSchedule(cl client.Client, crtConfig *crtCfg.Config, namespace string, now time.Time) (bool, string, error) {
+	schedule := newApprovalSchedule(crtConfig)
+	if !schedule.isDefined() {
+		return true, "", nil
+	}
+	start, open := schedule.currentWindowStart(now)
+	if !open {
+		return false, fmt.Sprintf("automatic approval is outside of the scheduled windows, the next window opens at %s",
+			schedule.nextOpening(now).Format(time.RFC3339)), nil
+	}
+	if schedule.maxApprovals <= 0 {
+		return true, "", nil
+	}
+	approvals, err := countAutomaticApprovalsSince(cl, namespace, start)
+	if err != nil {
+		return false, "", err
+	}
+	if approvals >= schedule.maxApprovals {
+		return false, fmt.Sprintf("the maximum number of automatic approvals (%d) for the current window has been reached, the next window opens at %s",
+			schedule.maxApprovals, schedule.nextOpening(now).Format(time.RFC3339)), nil
+	}
+	return true, "", nil
+}
+
+// countAutomaticApprovalsSince returns the number of UserSignups that were approved automatically at or after the given time
+func countAutomaticApprovalsSince(cl client.Client, namespace string, since time.Time) (int, error) {
+	userSignupList := &toolchainv1alpha1.UserSignupList{}
+	if err := cl.List(context.TODO(), userSignupList, client.InNamespace(namespace),
+		client.MatchingLabels{toolchainv1alpha1.UserSignupStateLabelKey: toolchainv1alpha1.UserSignupStateLabelValueApproved}); err != nil {
+		return 0, errors.Wrapf(err, "unable to list approved UserSignup resources")
+	}
+	count := 0
+	for _, userSignup := range userSignupList.Items {
+		approved, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupApproved)
+		if found && approved.Reason == toolchainv1alpha1.UserSignupApprovedAutomaticallyReason &&
+			!approved.LastTransitionTime.Time.Before(since.Truncate(time.Second)) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func windowBounds(day time.Time, window crtCfg.ApprovalWindow) (time.Time, time.Time) {
+	from := day.Add(window.From)
+	to := day.Add(window.To)
+	if !to.After(from) {
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to
+}
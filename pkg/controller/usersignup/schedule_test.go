@@ -0,0 +1,214 @@
+package usersignup
+
+import (
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApprovalSchedule(t *testing.T) {
+	// given
+	restore := SetEnvVarsAndRestore(t,
+		Env("HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_WINDOWS", "Mon-Fri 09:00-17:00,Sat 22:00-02:00"),
+		Env("HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_TIMEZONE", "Europe/Prague"))
+	defer restore()
+	config, err := configuration.LoadConfig(NewFakeClient(t))
+	require.NoError(t, err)
+	schedule := newApprovalSchedule(config)
+	prague, err := time.LoadLocation("Europe/Prague")
+	require.NoError(t, err)
+	// 2021-04-12 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2021, 4, day, hour, minute, 0, 0, prague)
+	}
+
+	t.Run("inside of a window", func(t *testing.T) {
+		// when
+		start, open := schedule.currentWindowStart(at(14, 12, 30))
+
+		// then
+		assert.True(t, open)
+		assert.Equal(t, at(14, 9, 0), start)
+	})
+
+	t.Run("inside of a window that started the day before", func(t *testing.T) {
+		// when
+		start, open := schedule.currentWindowStart(at(18, 1, 0))
+
+		// then
+		assert.True(t, open)
+		assert.Equal(t, at(17, 22, 0), start)
+	})
+
+	t.Run("the end of the window is excluded", func(t *testing.T) {
+		// when
+		_, open := schedule.currentWindowStart(at(14, 17, 0))
+
+		// then
+		assert.False(t, open)
+	})
+
+	t.Run("time in a different time zone is converted", func(t *testing.T) {
+		// when
+		_, open := schedule.currentWindowStart(time.Date(2021, 4, 14, 7, 30, 0, 0, time.UTC)) // 9:30 in Prague
+
+		// then
+		assert.True(t, open)
+	})
+
+	t.Run("next opening on the same day", func(t *testing.T) {
+		// when
+		next := schedule.nextOpening(at(14, 6, 0))
+
+		// then
+		assert.True(t, at(14, 9, 0).Equal(next))
+	})
+
+	t.Run("next opening after the weekend", func(t *testing.T) {
+		// when
+		next := schedule.nextOpening(at(17, 23, 0))
+
+		// then
+		assert.True(t, at(19, 9, 0).Equal(next))
+	})
+}
+
+func TestCheckAutomaticApprovalSchedule(t *testing.T) {
+	t.Run("no schedule means no restriction", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		active, msg, err := checkAutomaticApprovalSchedule(NewFakeClient(t), config, HostOperatorNs, time.Now())
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, active)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("outside of the windows", func(t *testing.T) {
+		// given
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_WINDOWS", "Mon 09:00-17:00")
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		active, msg, err := checkAutomaticApprovalSchedule(NewFakeClient(t), config, HostOperatorNs,
+			time.Date(2021, 4, 13, 10, 0, 0, 0, time.UTC))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, active)
+		assert.Equal(t, "automatic approval is outside of the scheduled windows, the next window opens at 2021-04-19T09:00:00Z", msg)
+	})
+
+	t.Run("maximum number of approvals per window", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_WINDOWS", "* 00:00-00:00"),
+			Env("HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_MAXAPPROVALS", "2"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		now := time.Now()
+		approvedToday := approvedAt(toolchainv1alpha1.UserSignupApprovedAutomaticallyReason, midnight(now.UTC()).Add(time.Second))
+		approvedByAdminToday := approvedAt(toolchainv1alpha1.UserSignupApprovedByAdminReason, midnight(now.UTC()).Add(time.Second))
+		approvedYesterday := approvedAt(toolchainv1alpha1.UserSignupApprovedAutomaticallyReason, midnight(now.UTC()).Add(-time.Hour))
+
+		t.Run("not reached", func(t *testing.T) {
+			// when
+			active, msg, err := checkAutomaticApprovalSchedule(NewFakeClient(t, approvedToday, approvedByAdminToday, approvedYesterday),
+				config, HostOperatorNs, now)
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, active)
+			assert.Empty(t, msg)
+		})
+
+		t.Run("reached", func(t *testing.T) {
+			// given
+			anotherApprovedToday := approvedAt(toolchainv1alpha1.UserSignupApprovedAutomaticallyReason, midnight(now.UTC()).Add(time.Minute))
+
+			// when
+			active, msg, err := checkAutomaticApprovalSchedule(NewFakeClient(t, approvedToday, anotherApprovedToday),
+				config, HostOperatorNs, now)
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, active)
+			assert.Contains(t, msg, "the maximum number of automatic approvals (2) for the current window has been reached")
+		})
+	})
+}
+
+func TestGetClusterIfApprovedOutsideOfSchedule(t *testing.T) {
+	// given
+	restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_SCHEDULE_WINDOWS", closedWindow(time.Now()))
+	defer restore()
+	config, err := configuration.LoadConfig(NewFakeClient(t))
+	require.NoError(t, err)
+	toolchainStatus := NewToolchainStatus(
+		WithMember("member1", WithUserAccountCount(1), WithNodeRoleUsage("worker", 10), WithNodeRoleUsage("master", 10)))
+	clusters := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+
+	t.Run("automatic approval is postponed", func(t *testing.T) {
+		// given
+		fakeClient := NewFakeClient(t, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()))
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, NewUserSignup(), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Equal(t, unknown, clusterName)
+		assert.Contains(t, msg, "automatic approval is outside of the scheduled windows")
+	})
+
+	t.Run("manual approval is not affected", func(t *testing.T) {
+		// given
+		fakeClient := NewFakeClient(t, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()))
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, _, msg, err := getClusterIfApproved(fakeClient, config, NewUserSignup(Approved()), clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Equal(t, "member1", clusterName.getClusterName())
+		assert.Empty(t, msg)
+	})
+}
+
+func approvedAt(reason string, at time.Time) *toolchainv1alpha1.UserSignup {
+	userSignup := NewUserSignup(WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueApproved))
+	userSignup.Status.Conditions = []toolchainv1alpha1.Condition{
+		{
+			Type:               toolchainv1alpha1.UserSignupApproved,
+			Status:             v1.ConditionTrue,
+			Reason:             reason,
+			LastTransitionTime: metav1.NewTime(at),
+		},
+	}
+	return userSignup
+}
+
+// closedWindow returns a schedule window (in UTC) that is closed at the given time
+func closedWindow(now time.Time) string {
+	return now.UTC().AddDate(0, 0, 3).Format("Mon") + " 00:00-01:00"
+}
@@ -11,6 +11,44 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// UserSignupUsernameChanged is the type of the condition that describes why the compliant username differs from the requested username
+	UserSignupUsernameChanged toolchainv1alpha1.ConditionType = "UsernameChanged"
+
+	// UserSignupUsernamePolicyAppliedReason is used when the username was changed by the username policy
+	UserSignupUsernamePolicyAppliedReason = "UsernamePolicyApplied"
+
+	// UserSignupApprovalDryRun is the type of the condition that contains the result of the last dry-run approval evaluation
+	UserSignupApprovalDryRun toolchainv1alpha1.ConditionType = "ApprovalDryRun"
+
+	// UserSignupDryRunApprovedReason is used when the user would be approved and provisioned to a member cluster
+	UserSignupDryRunApprovedReason = "WouldBeApproved"
+
+	// UserSignupDryRunNotApprovedReason is used when the user would not be approved or there is no suitable member cluster
+	UserSignupDryRunNotApprovedReason = "WouldNotBeApproved"
+
+	// UserSignupDryRunFailedReason is used when the dry-run approval evaluation failed
+	UserSignupDryRunFailedReason = "DryRunFailed"
+
+	// UserSignupMigratingReason is used when the UserAccount of the user is moved off a drained member cluster
+	UserSignupMigratingReason = "Migrating"
+
+	// UserSignupMigrationFailedReason is used when there is no member cluster the UserAccount of the user could be moved to
+	UserSignupMigrationFailedReason = "MigrationFailed"
+
+	// UserSignupReverificationRequiredReason is used when a reactivated user has to go through the verification again
+	UserSignupReverificationRequiredReason = "ReverificationRequired"
+
+	// UserSignupApprovedReason is used when the user was approved
+	UserSignupApprovedReason = "Approved"
+
+	// UserSignupPlacementFailedReason is used when there is no member cluster the user could be provisioned to
+	UserSignupPlacementFailedReason = "PlacementFailed"
+
+	// UserSignupDeletedReason is used when the UserSignup is deleted
+	UserSignupDeletedReason = "Deleted"
+)
+
 type statusUpdater struct {
 	client client.Client
 }
@@ -63,6 +101,17 @@ func (u *statusUpdater) setStatusInvalidMURState(userSignup *toolchainv1alpha1.U
 		})
 }
 
+func (u *statusUpdater) setStatusUsernameChanged(userSignup *toolchainv1alpha1.UserSignup, message string) error {
+	return u.updateStatusConditions(
+		userSignup,
+		toolchainv1alpha1.Condition{
+			Type:    UserSignupUsernameChanged,
+			Status:  corev1.ConditionTrue,
+			Reason:  UserSignupUsernamePolicyAppliedReason,
+			Message: message,
+		})
+}
+
 func (u *statusUpdater) setStatusFailedToCreateMUR(userSignup *toolchainv1alpha1.UserSignup, message string) error {
 	return u.updateStatusConditions(
 		userSignup,
@@ -257,10 +306,43 @@ func (u *statusUpdater) setStatusDeactivationNotificationCreationFailed(userSign
 		})
 }
 
+var statusApprovalDryRunApproved = func(message string) toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:    UserSignupApprovalDryRun,
+		Status:  corev1.ConditionTrue,
+		Reason:  UserSignupDryRunApprovedReason,
+		Message: message,
+	}
+}
+
+var statusApprovalDryRunNotApproved = func(message string) toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:    UserSignupApprovalDryRun,
+		Status:  corev1.ConditionFalse,
+		Reason:  UserSignupDryRunNotApprovedReason,
+		Message: message,
+	}
+}
+
+var statusApprovalDryRunFailed = func(message string) toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:    UserSignupApprovalDryRun,
+		Status:  corev1.ConditionFalse,
+		Reason:  UserSignupDryRunFailedReason,
+		Message: message,
+	}
+}
+
 func (u *statusUpdater) updateStatus(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup,
 	statusUpdater func(userAcc *toolchainv1alpha1.UserSignup, message string) error) error {
+	return u.updateStatusWithMessage(logger, userSignup, statusUpdater, "")
+}
+
+// updateStatusWithMessage updates the status using the given updater and passes the message to it
+func (u *statusUpdater) updateStatusWithMessage(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup,
+	statusUpdater func(userAcc *toolchainv1alpha1.UserSignup, message string) error, message string) error {
 
-	if err := statusUpdater(userSignup, ""); err != nil {
+	if err := statusUpdater(userSignup, message); err != nil {
 		logger.Error(err, "status update failed")
 		return err
 	}
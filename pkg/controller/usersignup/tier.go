@@ -0,0 +1,46 @@
+package usersignup
+
+import (
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+)
+
+const (
+	approvalAutomatic = "automatic"
+	approvalAdmin     = "admin"
+)
+
+// getInitialTierName returns the name of the NSTemplateTier the given UserSignup should be provisioned with.
+// The tier assignment rules are evaluated in the configured order and the tier of the first matching rule is returned.
+// If no rule matches, then the default tier is returned.
+func getInitialTierName(crtConfig *crtCfg.Config, userSignup *toolchainv1alpha1.UserSignup) string {
+	for _, rule := range crtConfig.GetTierAssignmentRules() {
+		if matchesTierAssignmentRule(rule, userSignup) {
+			return rule.TierName
+		}
+	}
+	return crtConfig.GetDefaultTier()
+}
+
+func matchesTierAssignmentRule(rule crtCfg.TierAssignmentRule, userSignup *toolchainv1alpha1.UserSignup) bool {
+	switch rule.Attribute {
+	case crtCfg.TierAssignmentAttributeEmailDomain:
+		domain := emailDomain(userSignup)
+		return domain != "" && strings.EqualFold(domain, strings.TrimPrefix(rule.Value, "@"))
+	case crtCfg.TierAssignmentAttributeCompany:
+		return userSignup.Spec.Company != "" && strings.EqualFold(userSignup.Spec.Company, rule.Value)
+	case crtCfg.TierAssignmentAttributeLabel:
+		keyAndValue := strings.SplitN(rule.Value, "=", 2)
+		value, found := userSignup.Labels[keyAndValue[0]]
+		return found && (len(keyAndValue) == 1 || value == keyAndValue[1])
+	case crtCfg.TierAssignmentAttributeApproval:
+		if userSignup.Spec.Approved {
+			return rule.Value == approvalAdmin
+		}
+		return rule.Value == approvalAutomatic
+	}
+	log.Info("ignoring tier assignment rule with unknown attribute", "attribute", rule.Attribute)
+	return false
+}
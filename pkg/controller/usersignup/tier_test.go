@@ -0,0 +1,97 @@
+package usersignup
+
+import (
+	"testing"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInitialTierName(t *testing.T) {
+	restore := SetEnvVarsAndRestore(t,
+		Env("HOST_OPERATOR_TIER_DEFAULT", "basic"),
+		Env("HOST_OPERATOR_TIER_ASSIGNMENT_RULES",
+			"emailDomain:@partner.com:team,company:ACME:advanced,label:toolchain.dev.openshift.com/campaign=summit:advanced,approval:admin:test,unknown:foo:bar"))
+	defer restore()
+	config, err := configuration.LoadConfig(NewFakeClient(t))
+	require.NoError(t, err)
+
+	withLabel := func(key, value string) UserSignupModifier {
+		return func(userSignup *v1alpha1.UserSignup) {
+			userSignup.Labels[key] = value
+		}
+	}
+	withCompany := func(company string) UserSignupModifier {
+		return func(userSignup *v1alpha1.UserSignup) {
+			userSignup.Spec.Company = company
+		}
+	}
+
+	tests := map[string]struct {
+		userSignup   *v1alpha1.UserSignup
+		expectedTier string
+	}{
+		"no rule matches": {
+			userSignup:   NewUserSignup(WithEmail("foo@redhat.com")),
+			expectedTier: "basic",
+		},
+		"email domain matches": {
+			userSignup:   NewUserSignup(WithEmail("foo@Partner.com")),
+			expectedTier: "team",
+		},
+		"email subdomain doesn't match": {
+			userSignup:   NewUserSignup(WithEmail("foo@eu.partner.com")),
+			expectedTier: "basic",
+		},
+		"company matches": {
+			userSignup:   NewUserSignup(withCompany("acme")),
+			expectedTier: "advanced",
+		},
+		"label matches": {
+			userSignup:   NewUserSignup(withLabel("toolchain.dev.openshift.com/campaign", "summit")),
+			expectedTier: "advanced",
+		},
+		"label with different value doesn't match": {
+			userSignup:   NewUserSignup(withLabel("toolchain.dev.openshift.com/campaign", "other")),
+			expectedTier: "basic",
+		},
+		"approved by admin matches": {
+			userSignup:   NewUserSignup(Approved()),
+			expectedTier: "test",
+		},
+		"first matching rule wins": {
+			userSignup:   NewUserSignup(Approved(), WithEmail("foo@partner.com"), withCompany("ACME")),
+			expectedTier: "team",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			tierName := getInitialTierName(config, tc.userSignup)
+
+			// then
+			assert.Equal(t, tc.expectedTier, tierName)
+		})
+	}
+
+	t.Run("default configuration", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_TIER_DEFAULT", ""),
+			Env("HOST_OPERATOR_TIER_ASSIGNMENT_RULES", ""))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		tierName := getInitialTierName(config, NewUserSignup(Approved()))
+
+		// then
+		assert.Equal(t, "base", tierName)
+	})
+}
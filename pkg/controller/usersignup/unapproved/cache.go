@@ -22,12 +22,23 @@ type cache struct {
 }
 
 func (c *cache) getOldestPendingApproval(namespace string) *toolchainv1alpha1.UserSignup {
+	oldest := c.getOldestPendingApprovals(namespace, 1)
+	if len(oldest) == 0 {
+		return nil
+	}
+	return oldest[0]
+}
+
+// getOldestPendingApprovals returns up to max oldest UserSignups that are pending approval.
+// The latest pending UserSignups are (re)loaded from the cluster only when all the cached ones were approved or removed,
+// so when there are fewer than max UserSignups pending approval, then the cached ones are returned without listing all of them again.
+func (c *cache) getOldestPendingApprovals(namespace string, max int) []*toolchainv1alpha1.UserSignup {
 	c.Lock()
 	defer c.Unlock()
-	oldest := c.getFirstExisting(namespace)
-	if oldest == nil {
+	oldest := c.getFirstExisting(namespace, max)
+	if len(c.userSignupsByCreation) == 0 {
 		c.loadLatest(namespace)
-		oldest = c.getFirstExisting(namespace)
+		oldest = c.getFirstExisting(namespace, max)
 	}
 	return oldest
 }
@@ -51,24 +62,27 @@ func (c *cache) loadLatest(namespace string) { //nolint:unparam
 	}
 }
 
-func (c *cache) getFirstExisting(namespace string) *toolchainv1alpha1.UserSignup {
-	if len(c.userSignupsByCreation) == 0 {
-		return nil
-	}
-	name := c.userSignupsByCreation[0]
-	userSignup := &toolchainv1alpha1.UserSignup{}
-	if err := c.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, userSignup); err != nil {
-		if apierrors.IsNotFound(err) {
-			c.userSignupsByCreation = c.userSignupsByCreation[1:]
-			return c.getFirstExisting(namespace)
+// getFirstExisting returns up to max first UserSignups from the cache that still exist and are still pending approval.
+// The UserSignups that don't exist or are not pending approval any more are removed from the cache.
+func (c *cache) getFirstExisting(namespace string, max int) []*toolchainv1alpha1.UserSignup {
+	var existing []*toolchainv1alpha1.UserSignup
+	for index := 0; index < len(c.userSignupsByCreation) && len(existing) < max; {
+		name := c.userSignupsByCreation[index]
+		userSignup := &toolchainv1alpha1.UserSignup{}
+		if err := c.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, userSignup); err != nil {
+			if apierrors.IsNotFound(err) {
+				c.userSignupsByCreation = append(c.userSignupsByCreation[:index], c.userSignupsByCreation[index+1:]...)
+				continue
+			}
+			log.Error(err, "could not get the oldest unapproved UserSignup")
+			return existing
 		}
-		log.Error(err, "could not get the oldest unapproved UserSignup")
-		return nil
-	}
-	if userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey] != toolchainv1alpha1.UserSignupStateLabelValuePending {
-		c.userSignupsByCreation = c.userSignupsByCreation[1:]
-		return c.getFirstExisting(namespace)
+		if userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey] != toolchainv1alpha1.UserSignupStateLabelValuePending {
+			c.userSignupsByCreation = append(c.userSignupsByCreation[:index], c.userSignupsByCreation[index+1:]...)
+			continue
+		}
+		existing = append(existing, userSignup)
+		index++
 	}
-
-	return userSignup
+	return existing
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestGetOldestPendingApproval(t *testing.T) {
@@ -133,6 +134,46 @@ func TestGetOldestPendingApprovalWithMultipleUserSignups(t *testing.T) {
 	})
 }
 
+func TestGetOldestPendingApprovalsDoesNotReloadWhenFewerThanMaxArePending(t *testing.T) {
+	// given
+	pending1 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(5*time.Second))
+	pending2 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(3*time.Second))
+	cache, cl := newCache(t, pending2, pending1)
+	listed := 0
+	cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+		listed++
+		return cl.Client.List(ctx, list, opts...)
+	}
+
+	// when
+	for i := 0; i < 3; i++ {
+		foundPending := cache.getOldestPendingApprovals(test.HostOperatorNs, 5)
+
+		// then
+		require.Len(t, foundPending, 2)
+		assert.Equal(t, pending1.Name, foundPending[0].Name)
+		assert.Equal(t, pending2.Name, foundPending[1].Name)
+	}
+	assert.Equal(t, 1, listed)
+
+	t.Run("reloads when all cached ones are approved", func(t *testing.T) {
+		// given
+		approve(t, cl, pending1)
+		approve(t, cl, pending2)
+		pending3 := NewUserSignup(WithStateLabel("pending"))
+		err := cl.Create(context.TODO(), pending3)
+		require.NoError(t, err)
+
+		// when
+		foundPending := cache.getOldestPendingApprovals(test.HostOperatorNs, 5)
+
+		// then
+		require.Len(t, foundPending, 1)
+		assert.Equal(t, pending3.Name, foundPending[0].Name)
+		assert.Equal(t, 2, listed)
+	})
+}
+
 func TestGetOldestPendingApprovalWithMultipleUserSignupsInParallel(t *testing.T) {
 	// given
 	cache, cl := newCache(t)
@@ -1,34 +1,44 @@
 package unapproved
 
 import (
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// UserSignupMapper maps any object to an oldest unapproved UserSignup
+// UserSignupMapper maps any object to a batch of the oldest unapproved UserSignups
 type UserSignupMapper struct {
 	unapprovedCache *cache
+	crtConfig       *crtCfg.Config
 }
 
-// NewUserSignupMapper creates an instance of UserSignupMapper that maps any object to an oldest unapproved UserSignup
-func NewUserSignupMapper(client client.Client) UserSignupMapper {
+// NewUserSignupMapper creates an instance of UserSignupMapper that maps any object to a batch of the oldest unapproved UserSignups.
+// The size of the batch is taken from the given configuration.
+func NewUserSignupMapper(client client.Client, crtConfig *crtCfg.Config) UserSignupMapper {
 	return UserSignupMapper{
 		unapprovedCache: &cache{
 			client: client,
 		},
+		crtConfig: crtConfig,
 	}
 }
 
 var _ handler.Mapper = UserSignupMapper{}
 
 func (b UserSignupMapper) Map(obj handler.MapObject) []reconcile.Request {
-	userSignup := b.unapprovedCache.getOldestPendingApproval(obj.Meta.GetNamespace())
-	if userSignup == nil {
-		return []reconcile.Request{}
+	batchSize := b.crtConfig.GetAutomaticApprovalBatchSize()
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	userSignups := b.unapprovedCache.getOldestPendingApprovals(obj.Meta.GetNamespace(), batchSize)
+	requests := make([]reconcile.Request, len(userSignups))
+	for index, userSignup := range userSignups {
+		requests[index] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: userSignup.Namespace, Name: userSignup.Name},
+		}
 	}
-	return []reconcile.Request{{
-		NamespacedName: types.NamespacedName{Namespace: userSignup.Namespace, Name: userSignup.Name},
-	}}
+	return requests
 }
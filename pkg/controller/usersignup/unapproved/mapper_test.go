@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
 	. "github.com/codeready-toolchain/host-operator/test"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	"github.com/stretchr/testify/assert"
@@ -20,7 +22,7 @@ func TestMapperReturnsOldest(t *testing.T) {
 	approved := NewUserSignup(WithStateLabel("approved"))
 	deactivated := NewUserSignup(WithStateLabel("deactivated"))
 	cl := test.NewFakeClient(t, pending, approved, deactivated)
-	mapper := NewUserSignupMapper(cl)
+	mapper := NewUserSignupMapper(cl, newConfig(t))
 
 	// when
 	requests := mapper.Map(handler.MapObject{
@@ -40,7 +42,7 @@ func TestMapperReturnsEmptyRequestsWhenNoPendingIsFound(t *testing.T) {
 	approved := NewUserSignup(WithStateLabel("approved"))
 	deactivated := NewUserSignup(WithStateLabel("deactivated"))
 	cl := test.NewFakeClient(t, banned, approved, deactivated)
-	mapper := NewUserSignupMapper(cl)
+	mapper := NewUserSignupMapper(cl, newConfig(t))
 
 	// when
 	requests := mapper.Map(handler.MapObject{
@@ -57,7 +59,7 @@ func TestMapperReturnsEmptyRequestsWhenClientReturnsError(t *testing.T) {
 	cl.MockGet = func(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
 		return fmt.Errorf("some error")
 	}
-	mapper := NewUserSignupMapper(cl)
+	mapper := NewUserSignupMapper(cl, newConfig(t))
 
 	// when
 	requests := mapper.Map(handler.MapObject{
@@ -67,3 +69,46 @@ func TestMapperReturnsEmptyRequestsWhenClientReturnsError(t *testing.T) {
 	// then
 	assert.Empty(t, requests)
 }
+
+func TestMapperReturnsBatchOfOldest(t *testing.T) {
+	// given
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_BATCH_SIZE", "2")
+	defer restore()
+	pending1 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(5*time.Second))
+	pending2 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(3*time.Second))
+	pending3 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(2*time.Second))
+	approved := NewUserSignup(WithStateLabel("approved"), CreatedBefore(10*time.Second))
+	cl := test.NewFakeClient(t, pending3, approved, pending2, pending1)
+	mapper := NewUserSignupMapper(cl, newConfig(t))
+
+	// when
+	requests := mapper.Map(handler.MapObject{
+		Meta: NewToolchainStatus().GetObjectMeta(),
+	})
+
+	// then
+	require.Len(t, requests, 2)
+	assert.Equal(t, pending1.Name, requests[0].Name)
+	assert.Equal(t, pending2.Name, requests[1].Name)
+
+	t.Run("returns the rest when the first ones are approved", func(t *testing.T) {
+		// given
+		approve(t, cl, pending1)
+		approve(t, cl, pending2)
+
+		// when
+		requests := mapper.Map(handler.MapObject{
+			Meta: NewToolchainStatus().GetObjectMeta(),
+		})
+
+		// then
+		require.Len(t, requests, 1)
+		assert.Equal(t, pending3.Name, requests[0].Name)
+	})
+}
+
+func newConfig(t *testing.T) *configuration.Config {
+	config, err := configuration.LoadConfig(test.NewFakeClient(t))
+	require.NoError(t, err)
+	return config
+}
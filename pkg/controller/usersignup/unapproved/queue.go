@@ -0,0 +1,102 @@
+package unapproved
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// approvalRateWindow is the period of time the approval throughput is computed for
+const approvalRateWindow = time.Hour
+
+// countApprovalsSince returns the number of UserSignups in the given namespace that were approved (automatically or manually)
+// at or after the given time, according to the last transition time of their Approved condition.
+// The approved UserSignups are listed using the given reader, which is supposed to be backed by the informer cache,
+// so the throughput is available right after the operator (re)starts without querying the API server.
+func countApprovalsSince(cl client.Reader, namespace string, since time.Time) (int, error) {
+	userSignupList := &toolchainv1alpha1.UserSignupList{}
+	if err := cl.List(context.TODO(), userSignupList, client.InNamespace(namespace),
+		client.MatchingLabels{toolchainv1alpha1.UserSignupStateLabelKey: toolchainv1alpha1.UserSignupStateLabelValueApproved}); err != nil {
+		return 0, errors.Wrapf(err, "unable to list approved UserSignup resources")
+	}
+	count := 0
+	for _, userSignup := range userSignupList.Items {
+		approved, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupApproved)
+		if found && approved.Status == corev1.ConditionTrue && !approved.LastTransitionTime.Time.Before(since.Truncate(time.Second)) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ApprovalRate returns the number of approvals per hour during the given period of time before now
+func ApprovalRate(cl client.Reader, namespace string, window time.Duration, now time.Time) (float64, error) {
+	if window <= 0 {
+		return 0, nil
+	}
+	count, err := countApprovalsSince(cl, namespace, now.Add(-window))
+	if err != nil {
+		return 0, err
+	}
+	return float64(count) / window.Hours(), nil
+}
+
+// EstimateWait estimates how long a UserSignup at the given position in the approval queue will wait for its approval,
+// based on the approval throughput during the last hour. If there was no approval during the last hour, then it returns false.
+func EstimateWait(cl client.Reader, namespace string, position int, now time.Time) (time.Duration, bool, error) {
+	count, err := countApprovalsSince(cl, namespace, now.Add(-approvalRateWindow))
+	if err != nil || count == 0 {
+		return 0, false, err
+	}
+	return (approvalRateWindow / time.Duration(count) * time.Duration(position)).Round(time.Second), true, nil
+}
+
+// GetPosition returns the position (starting from 1) of the given UserSignup in the queue of UserSignups pending approval.
+// The queue is ordered by the creation timestamp (and by name for the UserSignups created at the same time).
+// If the UserSignup is not pending approval, then it returns 0.
+// The pending UserSignups are listed using the given reader, which is supposed to be backed by the informer cache
+// (as the client of the manager is), so that the API server is not queried on every call.
+func GetPosition(cl client.Reader, userSignup *toolchainv1alpha1.UserSignup) (int, error) {
+	if userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey] != toolchainv1alpha1.UserSignupStateLabelValuePending {
+		return 0, nil
+	}
+	userSignupList := &toolchainv1alpha1.UserSignupList{}
+	if err := cl.List(context.TODO(), userSignupList, client.InNamespace(userSignup.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.UserSignupStateLabelKey: toolchainv1alpha1.UserSignupStateLabelValuePending}); err != nil {
+		return 0, errors.Wrapf(err, "unable to list UserSignup resources pending approval")
+	}
+	position := 1
+	for _, pending := range userSignupList.Items {
+		if pending.Name == userSignup.Name {
+			continue
+		}
+		if pending.CreationTimestamp.Before(&userSignup.CreationTimestamp) ||
+			(pending.CreationTimestamp.Equal(&userSignup.CreationTimestamp) && pending.Name < userSignup.Name) {
+			position++
+		}
+	}
+	return position, nil
+}
+
+// QueueMessage returns a human readable description of the position of the given UserSignup in the approval queue
+// together with the estimated wait (if it can be estimated)
+func QueueMessage(cl client.Reader, userSignup *toolchainv1alpha1.UserSignup) (string, error) {
+	position, err := GetPosition(cl, userSignup)
+	if err != nil || position == 0 {
+		return "", err
+	}
+	wait, ok, err := EstimateWait(cl, userSignup.Namespace, position, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return fmt.Sprintf("position in the approval queue: %d, estimated wait: %s", position, wait), nil
+	}
+	return fmt.Sprintf("position in the approval queue: %d", position), nil
+}
@@ -0,0 +1,196 @@
+package unapproved
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetPosition(t *testing.T) {
+	// given
+	pending1 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(5*time.Second))
+	pending2 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(3*time.Second))
+	pending3 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(2*time.Second))
+	approved := NewUserSignup(WithStateLabel("approved"), CreatedBefore(10*time.Second))
+	cl := test.NewFakeClient(t, pending3, approved, pending2, pending1)
+
+	t.Run("pending UserSignups are ordered by creation", func(t *testing.T) {
+		for index, userSignup := range []*v1alpha1.UserSignup{pending1, pending2, pending3} {
+			// when
+			position, err := GetPosition(cl, userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, index+1, position)
+		}
+	})
+
+	t.Run("UserSignup that is not pending has no position", func(t *testing.T) {
+		// when
+		position, err := GetPosition(cl, approved)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 0, position)
+	})
+
+	t.Run("fails when list fails", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, pending1)
+		cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			return fmt.Errorf("some error")
+		}
+
+		// when
+		_, err := GetPosition(cl, pending1)
+
+		// then
+		require.EqualError(t, err, "unable to list UserSignup resources pending approval: some error")
+	})
+}
+
+func TestEstimateWait(t *testing.T) {
+	// given
+	now := time.Now()
+
+	t.Run("cannot estimate without any approval", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, NewUserSignup(WithStateLabel("pending")))
+
+		// when
+		_, ok, err := EstimateWait(cl, test.HostOperatorNs, 1, now)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("estimates based on the approvals during the last hour", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, approvedAt(now.Add(-2*time.Hour)), // too old to be taken into account
+			approvedAt(now), approvedAt(now.Add(-time.Minute)), approvedAt(now.Add(-2*time.Minute)), approvedAt(now.Add(-3*time.Minute)))
+
+		// when
+		wait, ok, err := EstimateWait(cl, test.HostOperatorNs, 2, now)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 30*time.Minute, wait)
+	})
+
+	t.Run("fails when list fails", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t)
+		cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			return fmt.Errorf("some error")
+		}
+
+		// when
+		_, _, err := EstimateWait(cl, test.HostOperatorNs, 1, now)
+
+		// then
+		require.EqualError(t, err, "unable to list approved UserSignup resources: some error")
+	})
+}
+
+func TestApprovalRate(t *testing.T) {
+	// given
+	now := time.Now()
+
+	t.Run("no approval", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t)
+
+		// when
+		rate, err := ApprovalRate(cl, test.HostOperatorNs, 24*time.Hour, now)
+
+		// then
+		require.NoError(t, err)
+		assert.Zero(t, rate)
+	})
+
+	t.Run("approvals during the given period", func(t *testing.T) {
+		// given
+		objs := []runtime.Object{approvedAt(now.Add(-30 * time.Hour))} // too old to be taken into account
+		for i := 0; i < 12; i++ {
+			objs = append(objs, approvedAt(now.Add(-time.Duration(i)*time.Hour)))
+		}
+		deactivated := approvedAt(now)
+		deactivated.Labels[v1alpha1.UserSignupStateLabelKey] = v1alpha1.UserSignupStateLabelValueDeactivated // not approved any more
+		objs = append(objs, deactivated)
+		cl := test.NewFakeClient(t, objs...)
+
+		// when
+		rate, err := ApprovalRate(cl, test.HostOperatorNs, 24*time.Hour, now)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 0.5, rate)
+
+		t.Run("the same approvals are counted for the shorter period", func(t *testing.T) {
+			// when
+			rate, err := ApprovalRate(cl, test.HostOperatorNs, 2*time.Hour, now)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, 1.5, rate) // approvals at 0h, -1h and -2h
+		})
+	})
+}
+
+func TestQueueMessage(t *testing.T) {
+	// given
+	pending1 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(5*time.Second))
+	pending2 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(3*time.Second))
+	approved := NewUserSignup(WithStateLabel("approved"))
+
+	t.Run("without estimated wait", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, pending1, pending2, approved)
+
+		// when
+		msg, err := QueueMessage(cl, pending2)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "position in the approval queue: 2", msg)
+	})
+
+	t.Run("with estimated wait", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, pending1, pending2, approved, approvedAt(time.Now()))
+
+		// when
+		msg, err := QueueMessage(cl, pending2)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "position in the approval queue: 2, estimated wait: 2h0m0s", msg)
+	})
+
+	t.Run("no message for UserSignup that is not pending", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, pending1, pending2, approved)
+
+		// when
+		msg, err := QueueMessage(cl, approved)
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, msg)
+	})
+}
+
+func approvedAt(at time.Time) *v1alpha1.UserSignup {
+	return NewUserSignup(ApprovedAutomaticallyAt(at))
+}
@@ -0,0 +1,88 @@
+package unapproved
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// PendingUserSignupsMapper maps any object to all the UserSignups pending approval in the same namespace, ordered by creation,
+// so the position in the approval queue and the estimated wait of all of them are refreshed
+type PendingUserSignupsMapper struct {
+	client client.Reader
+}
+
+// NewPendingUserSignupsMapper creates an instance of PendingUserSignupsMapper. The UserSignups are listed using the given reader,
+// which is supposed to be backed by the informer cache.
+func NewPendingUserSignupsMapper(client client.Reader) PendingUserSignupsMapper {
+	return PendingUserSignupsMapper{
+		client: client,
+	}
+}
+
+var _ handler.Mapper = PendingUserSignupsMapper{}
+
+func (m PendingUserSignupsMapper) Map(obj handler.MapObject) []reconcile.Request {
+	userSignupList := &toolchainv1alpha1.UserSignupList{}
+	if err := m.client.List(context.TODO(), userSignupList, client.InNamespace(obj.Meta.GetNamespace()),
+		client.MatchingLabels{toolchainv1alpha1.UserSignupStateLabelKey: toolchainv1alpha1.UserSignupStateLabelValuePending}); err != nil {
+		log.Error(errors.Wrapf(err, "unable to list UserSignup resources pending approval"), "the positions in the approval queue won't be refreshed")
+		return nil
+	}
+	userSignups := userSignupList.Items
+	sort.Slice(userSignups, func(i, j int) bool {
+		if userSignups[i].CreationTimestamp.Equal(&userSignups[j].CreationTimestamp) {
+			return userSignups[i].Name < userSignups[j].Name
+		}
+		return userSignups[i].CreationTimestamp.Before(&userSignups[j].CreationTimestamp)
+	})
+	requests := make([]reconcile.Request, len(userSignups))
+	for index, userSignup := range userSignups {
+		requests[index] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: userSignup.Namespace, Name: userSignup.Name},
+		}
+	}
+	return requests
+}
+
+// NotifyToRefreshPositions sends an event of the ToolchainStatus to the given channel every refresh period of the approval queue
+// until the given stop channel is closed. The events are supposed to be mapped by PendingUserSignupsMapper.
+func NotifyToRefreshPositions(stop <-chan struct{}, events chan<- event.GenericEvent, crtConfig *crtCfg.Config) {
+	ticker := time.NewTicker(crtConfig.GetAutomaticApprovalQueueRefreshPeriod())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		namespace, err := k8sutil.GetWatchNamespace()
+		if err != nil {
+			log.Error(err, "unable to get the watch namespace, the positions in the approval queue won't be refreshed")
+			continue
+		}
+		status := &toolchainv1alpha1.ToolchainStatus{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      crtConfig.GetToolchainStatusName(),
+			},
+		}
+		select {
+		case <-stop:
+			return
+		case events <- event.GenericEvent{Meta: status, Object: status}:
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package unapproved
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestPendingUserSignupsMapper(t *testing.T) {
+	// given
+	pending1 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(5*time.Second))
+	pending2 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(3*time.Second))
+	pending3 := NewUserSignup(WithStateLabel("pending"), CreatedBefore(2*time.Second))
+	approved := NewUserSignup(WithStateLabel("approved"), CreatedBefore(10*time.Second))
+
+	t.Run("returns all pending UserSignups ordered by creation", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, pending3, approved, pending2, pending1)
+		mapper := NewPendingUserSignupsMapper(cl)
+
+		// when
+		requests := mapper.Map(handler.MapObject{
+			Meta: NewToolchainStatus().GetObjectMeta(),
+		})
+
+		// then
+		require.Len(t, requests, 3)
+		assert.Equal(t, pending1.Name, requests[0].Name)
+		assert.Equal(t, pending2.Name, requests[1].Name)
+		assert.Equal(t, pending3.Name, requests[2].Name)
+		assert.Equal(t, test.HostOperatorNs, requests[0].Namespace)
+	})
+
+	t.Run("returns no request when list fails", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, pending1)
+		cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			return fmt.Errorf("some error")
+		}
+		mapper := NewPendingUserSignupsMapper(cl)
+
+		// when
+		requests := mapper.Map(handler.MapObject{
+			Meta: NewToolchainStatus().GetObjectMeta(),
+		})
+
+		// then
+		assert.Empty(t, requests)
+	})
+}
+
+func TestNotifyToRefreshPositions(t *testing.T) {
+	// given
+	restore := test.SetEnvVarsAndRestore(t,
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_QUEUE_REFRESH_PERIOD", "10ms"),
+		test.Env(k8sutil.WatchNamespaceEnvVar, test.HostOperatorNs))
+	defer restore()
+	stop := make(chan struct{})
+	events := make(chan event.GenericEvent)
+	done := make(chan struct{})
+	go func() {
+		NotifyToRefreshPositions(stop, events, newConfig(t))
+		close(done)
+	}()
+
+	// when
+	var evt event.GenericEvent
+	select {
+	case evt = <-events:
+	case <-time.After(time.Second):
+		require.FailNow(t, "no event received")
+	}
+	close(stop)
+
+	// then
+	require.IsType(t, &toolchainv1alpha1.ToolchainStatus{}, evt.Object)
+	assert.Equal(t, test.HostOperatorNs, evt.Meta.GetNamespace())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "the notifications were not stopped")
+	}
+}
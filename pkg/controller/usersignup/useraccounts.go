@@ -0,0 +1,126 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UserSignupUserAccountsAnnotationKey contains the number of member clusters the user should get a UserAccount on (eg. "2" for a primary and a DR cluster).
+// It takes precedence over the number of UserAccounts configured for the tier of the user.
+const UserSignupUserAccountsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "user-accounts"
+
+// getRequestedNumberOfUserAccounts returns the number of member clusters the user should get a UserAccount on.
+// The number is read from the UserSignup annotation or from the configuration of the initial tier of the user. The default is 1.
+func getRequestedNumberOfUserAccounts(crtConfig *crtCfg.Config, userSignup *toolchainv1alpha1.UserSignup) (int, error) {
+	if value, found := userSignup.Annotations[UserSignupUserAccountsAnnotationKey]; found {
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || number < 1 {
+			return 0, fmt.Errorf("invalid value of the annotation '%s': '%s' is not a positive number", UserSignupUserAccountsAnnotationKey, value)
+		}
+		return number, nil
+	}
+	if number := crtConfig.GetUserAccountsPerTier()[getInitialTierName(crtConfig, userSignup)]; number > 0 {
+		return number, nil
+	}
+	return 1, nil
+}
+
+// selectAdditionalTargetClusters selects the member clusters for the UserAccounts of the user other than the one in the given primary cluster.
+// Every member cluster is selected in the same way as the primary one (except that the TargetCluster set in the UserSignup is ignored),
+// it can't contain any other UserAccount of the user and, if the topology key is configured, it has to have a different value
+// of the topology label than all the other selected member clusters.
+// If there are not enough suitable member clusters, then it returns the ones that were found and the rejections explain why there are no more.
+func selectAdditionalTargetClusters(cl client.Client, crtConfig *crtCfg.Config, config toolchainv1alpha1.HostOperatorConfigSpec, userSignup *toolchainv1alpha1.UserSignup,
+	getMemberClusters cluster.GetMemberClustersFunc, primary string, count int, dryRun bool, checks ...clusterCheck) ([]string, *clusterRejections, error) {
+	if count <= 1 {
+		return nil, newClusterRejections(), nil
+	}
+	topology, err := newTopologySpread(cl, crtConfig, userSignup.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	placement := userSignup.DeepCopy()
+	placement.Spec.TargetCluster = ""
+	selected := []string{primary}
+	for len(selected) < count {
+		clusterChecks := append(append([]clusterCheck{}, checks...), isNotSelected(selected), topology.check(selected))
+		clusterName, rejections, err := selectTargetCluster(cl, crtConfig, config, placement, getMemberClusters, dryRun, clusterChecks...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if clusterName == "" {
+			return selected[1:], rejections, nil
+		}
+		selected = append(selected, clusterName)
+	}
+	return selected[1:], newClusterRejections(), nil
+}
+
+// isNotSelected rejects the member clusters that were already selected for another UserAccount of the user
+func isNotSelected(selected []string) clusterCheck {
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		for _, name := range selected {
+			if cluster.Name == name {
+				return false, "already selected for another UserAccount"
+			}
+		}
+		return true, ""
+	}
+}
+
+// topologySpread contains the values of the topology label of the ToolchainCluster resources (mapped by the cluster names)
+type topologySpread struct {
+	key    string
+	values map[string]string
+}
+
+// newTopologySpread loads the values of the configured topology label from the ToolchainCluster resources in the given namespace.
+// If there is no topology key configured, then nothing is loaded.
+func newTopologySpread(cl client.Client, crtConfig *crtCfg.Config, namespace string) (*topologySpread, error) {
+	spread := &topologySpread{
+		key: crtConfig.GetUserAccountsTopologyKey(),
+	}
+	if spread.key == "" {
+		return spread, nil
+	}
+	toolchainClusters := &toolchainv1alpha1.ToolchainClusterList{}
+	if err := cl.List(context.TODO(), toolchainClusters, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "unable to list the ToolchainCluster resources")
+	}
+	spread.values = map[string]string{}
+	for _, toolchainCluster := range toolchainClusters.Items {
+		if value, found := toolchainCluster.Labels[spread.key]; found {
+			spread.values[toolchainCluster.Name] = value
+		}
+	}
+	return spread, nil
+}
+
+// check rejects the member clusters without the topology label or with the same value of the label as any of the selected member clusters
+func (s *topologySpread) check(selected []string) clusterCheck {
+	return func(cluster *cluster.CachedToolchainCluster) (bool, string) {
+		if s.key == "" {
+			return true, ""
+		}
+		value, found := s.values[cluster.Name]
+		if !found {
+			return false, fmt.Sprintf("no '%s' label", s.key)
+		}
+		for _, name := range selected {
+			if name != cluster.Name && s.values[name] == value {
+				return false, fmt.Sprintf("same '%s' label value as %s", s.key, name)
+			}
+		}
+		return true, ""
+	}
+}
@@ -0,0 +1,204 @@
+package usersignup
+
+import (
+	"testing"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGetRequestedNumberOfUserAccounts(t *testing.T) {
+	t.Run("default is one UserAccount", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		number, err := getRequestedNumberOfUserAccounts(config, NewUserSignup())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 1, number)
+	})
+
+	t.Run("configured for the tier", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_TIER_USER_ACCOUNTS", "base=2,ha=3"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		number, err := getRequestedNumberOfUserAccounts(config, NewUserSignup())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 2, number)
+	})
+
+	t.Run("annotation takes precedence over the tier", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_TIER_USER_ACCOUNTS", "base=2"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "3"
+
+		// when
+		number, err := getRequestedNumberOfUserAccounts(config, signup)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 3, number)
+	})
+
+	t.Run("invalid annotation", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		for _, value := range []string{"0", "-1", "two"} {
+			signup := NewUserSignup()
+			signup.Annotations[UserSignupUserAccountsAnnotationKey] = value
+
+			// when
+			_, err := getRequestedNumberOfUserAccounts(config, signup)
+
+			// then
+			require.EqualError(t, err, "invalid value of the annotation 'toolchain.dev.openshift.com/user-accounts': '"+value+"' is not a positive number")
+		}
+	})
+}
+
+func TestGetClusterIfApprovedWithMultipleUserAccounts(t *testing.T) {
+	// given
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(10)),
+		WithMember("us-1", WithUserAccountCount(5), WithNodeRoleUsage("worker", 50)),
+		WithMember("eu-1", WithUserAccountCount(5), WithNodeRoleUsage("worker", 50)),
+		WithMember("eu-2", WithUserAccountCount(5), WithNodeRoleUsage("worker", 50)))
+	toolchainClusters := []runtime.Object{
+		newToolchainCluster("us-1", map[string]string{"region": "us"}),
+		newToolchainCluster("eu-1", map[string]string{"region": "eu"}),
+		newToolchainCluster("eu-2", map[string]string{"region": "eu"}),
+	}
+	clusters := NewGetMemberClusters(
+		NewMemberCluster(t, "us-1", v1.ConditionTrue),
+		NewMemberCluster(t, "eu-1", v1.ConditionTrue),
+		NewMemberCluster(t, "eu-2", v1.ConditionTrue))
+
+	test := func(t *testing.T, signup *v1alpha1.UserSignup, expectedCluster targetCluster, expectedAdditionalClusters []string, expectedMessage string) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, append(toolchainClusters, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()))...)
+		InitializeCounters(t, toolchainStatus)
+
+		// when
+		approved, clusterName, additionalClusters, msg, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, expectedCluster != notFound, approved) // not approved automatically if there is no suitable member cluster
+		assert.Equal(t, expectedCluster, clusterName)
+		assert.Equal(t, expectedAdditionalClusters, additionalClusters)
+		assert.Equal(t, expectedMessage, msg)
+	}
+
+	t.Run("single UserAccount", func(t *testing.T) {
+		test(t, NewUserSignup(), "us-1", nil, "")
+	})
+
+	t.Run("UserAccounts on two different member clusters", func(t *testing.T) {
+		// given
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "2"
+
+		// then
+		test(t, signup, "us-1", []string{"eu-1"}, "")
+	})
+
+	t.Run("UserAccounts on all member clusters", func(t *testing.T) {
+		// given
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "3"
+
+		// then
+		test(t, signup, "us-1", []string{"eu-1", "eu-2"}, "")
+	})
+
+	t.Run("target cluster set in the UserSignup is the primary one", func(t *testing.T) {
+		// given
+		signup := NewUserSignup(WithTargetCluster("eu-2"))
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "2"
+
+		// then
+		test(t, signup, "eu-2", []string{"us-1"}, "")
+	})
+
+	t.Run("UserAccounts in different regions", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_USER_ACCOUNTS_TOPOLOGY_KEY", "region"))
+		defer restore()
+		signup := NewUserSignup(WithTargetCluster("eu-1"))
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "2"
+
+		// then
+		test(t, signup, "eu-1", []string{"us-1"}, "")
+	})
+
+	t.Run("not enough member clusters", func(t *testing.T) {
+		// given
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "4"
+
+		// then
+		test(t, signup, notFound, nil, "only 3 of 4 requested member clusters found; "+
+			"eu-1 rejected: already selected for another UserAccount; "+
+			"eu-2 rejected: already selected for another UserAccount; "+
+			"us-1 rejected: already selected for another UserAccount")
+	})
+
+	t.Run("not enough regions", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_USER_ACCOUNTS_TOPOLOGY_KEY", "region"))
+		defer restore()
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "3"
+
+		// then
+		test(t, signup, notFound, nil, "only 2 of 3 requested member clusters found; "+
+			"eu-1 rejected: already selected for another UserAccount; "+
+			"eu-2 rejected: same 'region' label value as eu-1; "+
+			"us-1 rejected: already selected for another UserAccount")
+	})
+
+	t.Run("invalid annotation", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		fakeClient := NewFakeClient(t, append(toolchainClusters, toolchainStatus, NewHostOperatorConfigWithReset(t, AutomaticApproval().Enabled()))...)
+		InitializeCounters(t, toolchainStatus)
+		signup := NewUserSignup()
+		signup.Annotations[UserSignupUserAccountsAnnotationKey] = "many"
+
+		// when
+		approved, clusterName, _, _, err := getClusterIfApproved(fakeClient, config, signup, clusters)
+
+		// then
+		require.EqualError(t, err, "invalid value of the annotation 'toolchain.dev.openshift.com/user-accounts': 'many' is not a positive number")
+		assert.False(t, approved)
+		assert.Equal(t, unknown, clusterName)
+	})
+}
@@ -0,0 +1,184 @@
+package usersignup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/usernameregistry"
+	"github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// maxSequentialAttempts is the number of names probed by the sequential collision strategy (the name itself and the suffixes -2 to -100)
+	maxSequentialAttempts = 100
+
+	// maxHashedAttempts is the number of names probed by the hashed collision strategy (the name itself and the hashed suffixes)
+	maxHashedAttempts = 10
+
+	// shortHashLength is the number of hex characters of the hash used for truncated names and hashed suffixes
+	shortHashLength = 5
+)
+
+// generateCompliantUsername generates a username that is compliant with the configured username policy, that is not reserved
+// in the username registry and that is not used by any other MasterUserRecord yet. As the second value it returns a message
+// describing why the username differs from the transformed original username (or an empty string if the policy didn't change it).
+func (r *ReconcileUserSignup) generateCompliantUsername(instance *toolchainv1alpha1.UserSignup) (string, string, error) {
+	replaced, changes, err := applyUsernamePolicy(r.crtConfig, usersignup.TransformUsername(instance.Spec.Username))
+	if err != nil {
+		return "", "", err
+	}
+
+	registry, err := usernameregistry.Load(r.client, instance.Namespace, r.crtConfig.GetReservedUsernamesConfigMapName())
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	candidates := usernameCandidates(r.crtConfig, instance.Name, replaced)
+	for _, transformed := range candidates {
+		// Skip the names that are reserved in the registry
+		if registry.IsReserved(transformed, now) {
+			if transformed == replaced {
+				changes = append(changes, fmt.Sprintf("username '%s' is reserved", replaced))
+			}
+			continue
+		}
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		// Check if a MasterUserRecord exists with the same transformed name
+		namespacedMurName := types.NamespacedName{Namespace: instance.Namespace, Name: transformed}
+		err := r.client.Get(context.TODO(), namespacedMurName, mur)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return "", "", err
+			}
+			// If there was a NotFound error looking up the mur, it means we found an available name
+			return transformed, usernameChangeReason(transformed, changes), nil
+		} else if mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] == instance.Name {
+			// If the found MUR has the same UserID as the UserSignup, then *it* is the correct MUR -
+			// Return an error here and allow the reconcile() function to pick it up on the next loop
+			return "", "", fmt.Errorf(fmt.Sprintf("INFO: could not generate compliant username as MasterUserRecord with the same name [%s] and user id [%s] already exists. The next reconcile loop will pick it up.", mur.Name, instance.Name))
+		}
+		if transformed == replaced {
+			changes = append(changes, fmt.Sprintf("username '%s' is already taken", replaced))
+		}
+	}
+
+	return "", "", fmt.Errorf(fmt.Sprintf("unable to transform username [%s] even after %d attempts", instance.Spec.Username, len(candidates)))
+}
+
+// applyUsernamePolicy adds the compliance prefix or suffix to the transformed username if it is reserved, has a forbidden prefix or suffix,
+// or matches a forbidden pattern, and truncates it if it is longer than the maximum length.
+// Together with the resulting username it returns the list of the applied changes.
+func applyUsernamePolicy(crtConfig *crtCfg.Config, replaced string) (string, []string, error) {
+	var changes []string
+
+	// Check for any reserved names
+	for _, name := range crtConfig.GetForbiddenUsernames() {
+		if replaced == name {
+			replaced = fmt.Sprintf("%s%s", "crt-", replaced)
+			changes = append(changes, fmt.Sprintf("'%s' is a reserved name", name))
+			break
+		}
+	}
+
+	// Check for any forbidden prefixes
+	for _, prefix := range crtConfig.GetForbiddenUsernamePrefixes() {
+		if strings.HasPrefix(replaced, prefix) {
+			replaced = fmt.Sprintf("%s%s", "crt-", replaced)
+			changes = append(changes, fmt.Sprintf("'%s' is a forbidden prefix", prefix))
+			break
+		}
+	}
+
+	// Check for any forbidden suffixes
+	for _, suffix := range crtConfig.GetForbiddenUsernameSuffixes() {
+		if strings.HasSuffix(replaced, suffix) {
+			replaced = fmt.Sprintf("%s%s", replaced, "-crt")
+			changes = append(changes, fmt.Sprintf("'%s' is a forbidden suffix", suffix))
+			break
+		}
+	}
+
+	// Check for any forbidden patterns
+	for _, pattern := range crtConfig.GetForbiddenUsernamePatterns() {
+		if pattern.MatchString(replaced) {
+			prefixed := fmt.Sprintf("%s%s", "crt-", replaced)
+			if pattern.MatchString(prefixed) {
+				return "", nil, fmt.Errorf("transformed username [%s] matches the forbidden pattern [%s]", replaced, pattern)
+			}
+			replaced = prefixed
+			changes = append(changes, fmt.Sprintf("'%s' is a forbidden pattern", pattern))
+			break
+		}
+	}
+
+	if maxLength := crtConfig.GetUsernameMaxLength(); maxLength > 0 && len(replaced) > maxLength {
+		replaced = truncateWithHash(replaced, maxLength)
+		changes = append(changes, fmt.Sprintf("the maximum length is %d characters", maxLength))
+	}
+
+	validationErrors := validation.IsQualifiedName(replaced)
+	if len(validationErrors) > 0 {
+		return "", nil, fmt.Errorf(fmt.Sprintf("transformed username [%s] is invalid", replaced))
+	}
+	return replaced, changes, nil
+}
+
+// usernameCandidates returns the ordered list of names that are probed when looking for a vacant username.
+// The first candidate is always the username itself, the rest is generated by the configured collision strategy.
+func usernameCandidates(crtConfig *crtCfg.Config, userSignupName, username string) []string {
+	maxLength := crtConfig.GetUsernameMaxLength()
+	candidates := []string{username}
+	switch crtConfig.GetUsernameCollisionStrategy() {
+	case crtCfg.UsernameCollisionStrategyHashed:
+		for i := 1; i < maxHashedAttempts; i++ {
+			candidates = append(candidates, withSuffix(username, "-"+shortHash(fmt.Sprintf("%s-%d", userSignupName, i)), maxLength))
+		}
+	default:
+		for i := 2; i <= maxSequentialAttempts; i++ {
+			candidates = append(candidates, withSuffix(username, fmt.Sprintf("-%d", i), maxLength))
+		}
+	}
+	return candidates
+}
+
+// truncateWithHash truncates the username to the given length so it ends with a short hash of the whole username,
+// which keeps the truncated names of different users distinct
+func truncateWithHash(username string, maxLength int) string {
+	return withSuffix(username, "-"+shortHash(username), maxLength)
+}
+
+// withSuffix appends the suffix to the username. If the result would be longer than maxLength (and maxLength is not 0),
+// then the username is shortened first.
+func withSuffix(username, suffix string, maxLength int) string {
+	if maxLength > 0 && len(username)+len(suffix) > maxLength {
+		end := maxLength - len(suffix)
+		if end < 0 {
+			end = 0
+		}
+		username = strings.TrimRight(username[:end], "-")
+	}
+	return username + suffix
+}
+
+func shortHash(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])[:shortHashLength]
+}
+
+func usernameChangeReason(username string, changes []string) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("username changed to '%s': %s", username, strings.Join(changes, "; "))
+}
@@ -0,0 +1,230 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApplyUsernamePolicy(t *testing.T) {
+	// given
+	restore := SetEnvVarsAndRestore(t,
+		Env("HOST_OPERATOR_USERNAME_FORBIDDEN_NAMES", "root,operator"),
+		Env("HOST_OPERATOR_USERNAME_FORBIDDEN_PATTERNS", "^test[0-9]+$,admin"),
+		Env("HOST_OPERATOR_USERNAME_MAX_LENGTH", "20"))
+	defer restore()
+	config, err := configuration.LoadConfig(NewFakeClient(t))
+	require.NoError(t, err)
+
+	t.Run("compliant username is not changed", func(t *testing.T) {
+		// when
+		username, changes, err := applyUsernamePolicy(config, "johnsmith")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "johnsmith", username)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("reserved name", func(t *testing.T) {
+		// when
+		username, changes, err := applyUsernamePolicy(config, "root")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "crt-root", username)
+		assert.Equal(t, []string{"'root' is a reserved name"}, changes)
+	})
+
+	t.Run("reserved names must match exactly", func(t *testing.T) {
+		// when
+		username, changes, err := applyUsernamePolicy(config, "rootless")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "rootless", username)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("forbidden pattern", func(t *testing.T) {
+		// when
+		username, changes, err := applyUsernamePolicy(config, "test123")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "crt-test123", username)
+		assert.Equal(t, []string{"'^test[0-9]+$' is a forbidden pattern"}, changes)
+	})
+
+	t.Run("forbidden pattern that cannot be fixed by the prefix", func(t *testing.T) {
+		// when
+		_, _, err := applyUsernamePolicy(config, "sysadmins")
+
+		// then
+		require.EqualError(t, err, "transformed username [sysadmins] matches the forbidden pattern [admin]")
+	})
+
+	t.Run("forbidden prefix and too long name", func(t *testing.T) {
+		// when
+		username, changes, err := applyUsernamePolicy(config, "openshift-fan-with-a-long-name")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "crt-openshift-"+shortHash("crt-openshift-fan-with-a-long-name"), username)
+		assert.Equal(t, []string{"'openshift' is a forbidden prefix", "the maximum length is 20 characters"}, changes)
+	})
+}
+
+func TestTruncateWithHash(t *testing.T) {
+	t.Run("different names with the same beginning are truncated to different names", func(t *testing.T) {
+		// when
+		first := truncateWithHash("johnsmith-from-the-marketing", 15)
+		second := truncateWithHash("johnsmith-from-the-sales", 15)
+
+		// then
+		assert.Len(t, first, 15)
+		assert.Len(t, second, 15)
+		assert.NotEqual(t, first, second)
+		assert.Equal(t, first, truncateWithHash("johnsmith-from-the-marketing", 15))
+	})
+
+	t.Run("trailing dash of the truncated name is removed", func(t *testing.T) {
+		// when
+		truncated := truncateWithHash("johnsmit-h-from-the-marketing", 15)
+
+		// then
+		assert.Equal(t, "johnsmit-"+shortHash("johnsmit-h-from-the-marketing"), truncated)
+	})
+}
+
+func TestUsernameCandidates(t *testing.T) {
+	t.Run("sequential", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		candidates := usernameCandidates(config, "123", "johnsmith")
+
+		// then
+		require.Len(t, candidates, 100)
+		assert.Equal(t, []string{"johnsmith", "johnsmith-2", "johnsmith-3"}, candidates[:3])
+		assert.Equal(t, "johnsmith-100", candidates[99])
+	})
+
+	t.Run("sequential with max length", func(t *testing.T) {
+		// given
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_USERNAME_MAX_LENGTH", "10")
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		candidates := usernameCandidates(config, "123", "johnsmith")
+
+		// then
+		assert.Equal(t, []string{"johnsmith", "johnsmit-2", "johnsmit-3"}, candidates[:3])
+		assert.Equal(t, "johnsm-100", candidates[99])
+	})
+
+	t.Run("hashed", func(t *testing.T) {
+		// given
+		restore := SetEnvVarAndRestore(t, "HOST_OPERATOR_USERNAME_COLLISION_STRATEGY", configuration.UsernameCollisionStrategyHashed)
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		candidates := usernameCandidates(config, "123", "johnsmith")
+
+		// then
+		require.Len(t, candidates, 10)
+		assert.Equal(t, "johnsmith", candidates[0])
+		assert.Equal(t, "johnsmith-"+shortHash("123-1"), candidates[1])
+		assert.Equal(t, candidates, usernameCandidates(config, "123", "johnsmith"))
+		assert.NotEqual(t, candidates, usernameCandidates(config, "456", "johnsmith"))
+	})
+}
+
+func TestUserSignupWithUsernameChangedByPolicy(t *testing.T) {
+	for _, strategy := range []string{configuration.UsernameCollisionStrategySequential, configuration.UsernameCollisionStrategyHashed} {
+		t.Run(strategy, func(t *testing.T) {
+			// given
+			restore := SetEnvVarsAndRestore(t,
+				Env("HOST_OPERATOR_USERNAME_FORBIDDEN_NAMES", "foo"),
+				Env("HOST_OPERATOR_USERNAME_COLLISION_STRATEGY", strategy))
+			defer restore()
+			userSignup := NewUserSignup(Approved(), WithTargetCluster("east"))
+			takenMur := &v1alpha1.MasterUserRecord{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "crt-foo",
+					Namespace: HostOperatorNs,
+					Labels:    map[string]string{v1alpha1.MasterUserRecordOwnerLabelKey: uuid.NewV4().String()},
+				},
+			}
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, takenMur, baseNSTemplateTier)
+			InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+			expectedName := "crt-foo-2"
+			if strategy == configuration.UsernameCollisionStrategyHashed {
+				expectedName = "crt-foo-" + shortHash(fmt.Sprintf("%s-1", userSignup.Name))
+			}
+
+			// when
+			_, err := r.Reconcile(req)
+
+			// then
+			require.NoError(t, err)
+			mur := &v1alpha1.MasterUserRecord{}
+			require.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: expectedName, Namespace: req.Namespace}, mur))
+			assert.Equal(t, userSignup.Name, mur.Labels[v1alpha1.MasterUserRecordOwnerLabelKey])
+			require.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup))
+			usernameChanged, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupUsernameChanged)
+			require.True(t, found)
+			assert.Equal(t, v1.ConditionTrue, usernameChanged.Status)
+			assert.Equal(t, UserSignupUsernamePolicyAppliedReason, usernameChanged.Reason)
+			assert.Equal(t, fmt.Sprintf("username changed to '%s': 'foo' is a reserved name; username 'crt-foo' is already taken", expectedName), usernameChanged.Message)
+		})
+	}
+}
+
+func TestUserSignupWithReservedUsername(t *testing.T) {
+	// given
+	userSignup := NewUserSignup(Approved(), WithTargetCluster("east"))
+	reserved := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "reserved-usernames",
+			Namespace: HostOperatorNs,
+		},
+		Data: map[string]string{
+			"foo": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		},
+	}
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, reserved, baseNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+	// when
+	_, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	mur := &v1alpha1.MasterUserRecord{}
+	require.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: "foo-2", Namespace: req.Namespace}, mur))
+	require.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup))
+	usernameChanged, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupUsernameChanged)
+	require.True(t, found)
+	assert.Equal(t, "username changed to 'foo-2': username 'foo' is reserved", usernameChanged.Message)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
@@ -15,17 +16,18 @@ import (
 	"github.com/codeready-toolchain/host-operator/pkg/templates/notificationtemplates"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
-	"github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -37,12 +39,10 @@ var log = logf.Log.WithName("controller_usersignup")
 
 type StatusUpdater func(userAcc *toolchainv1alpha1.UserSignup, message string) error
 
-const defaultTierName = "base"
-
 // Add creates a new UserSignup Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, crtConfig *crtCfg.Config) error {
-	return add(mgr, newReconciler(mgr, crtConfig))
+	return add(mgr, newReconciler(mgr, crtConfig), crtConfig)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -52,13 +52,14 @@ func newReconciler(mgr manager.Manager, crtConfig *crtCfg.Config) reconcile.Reco
 			client: mgr.GetClient(),
 		},
 		scheme:            mgr.GetScheme(),
+		recorder:          mgr.GetEventRecorderFor("usersignup-controller"),
 		crtConfig:         crtConfig,
 		getMemberClusters: cluster.GetMemberClusters,
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, crtConfig *crtCfg.Config) error {
 	// Create a new controller
 	c, err := controller.New("usersignup-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -92,10 +93,11 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	}
 
 	mapToOldestUnapproved := &handler.EnqueueRequestsFromMapFunc{
-		ToRequests: unapproved.NewUserSignupMapper(mgr.GetClient()),
+		ToRequests: unapproved.NewUserSignupMapper(mgr.GetClient(), crtConfig),
 	}
 	whenAutomaticApprovalIsEnabled := &OnlyWhenAutomaticApprovalIsEnabled{
-		client: mgr.GetClient(),
+		client:    mgr.GetClient(),
+		crtConfig: crtConfig,
 	}
 
 	// Watch for updates in ToolchainStatus CR to check if there is any member cluster with free capacity
@@ -106,6 +108,41 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Requeue all the unapproved UserSignups periodically, so their positions in the approval queue are refreshed
+	if crtConfig.GetAutomaticApprovalQueueRefreshPeriod() > 0 {
+		queueRefreshes := make(chan event.GenericEvent)
+		if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			unapproved.NotifyToRefreshPositions(stop, queueRefreshes, crtConfig)
+			return nil
+		})); err != nil {
+			return err
+		}
+		if err := c.Watch(
+			&source.Channel{Source: queueRefreshes},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: unapproved.NewPendingUserSignupsMapper(mgr.GetClient()),
+			}); err != nil {
+			return err
+		}
+	}
+
+	// Requeue the oldest unapproved UserSignups every time a window of the automatic approval schedule opens
+	if schedule := newApprovalSchedule(crtConfig); schedule.isDefined() {
+		windowOpenings := make(chan event.GenericEvent)
+		if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			schedule.notifyOnOpenings(stop, windowOpenings, crtConfig)
+			return nil
+		})); err != nil {
+			return err
+		}
+		if err := c.Watch(
+			&source.Channel{Source: windowOpenings},
+			mapToOldestUnapproved,
+			whenAutomaticApprovalIsEnabled); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -115,6 +152,7 @@ var _ reconcile.Reconciler = &ReconcileUserSignup{}
 type ReconcileUserSignup struct {
 	*statusUpdater
 	scheme            *runtime.Scheme
+	recorder          record.EventRecorder
 	crtConfig         *crtCfg.Config
 	getMemberClusters cluster.GetMemberClustersFunc
 }
@@ -143,8 +181,11 @@ func (r *ReconcileUserSignup) Reconcile(request reconcile.Request) (reconcile.Re
 	}
 
 	reqLogger = reqLogger.WithValues("username", instance.Spec.Username)
+	if isDryRunApprovalRequested(instance) {
+		return reconcile.Result{}, r.dryRunApproval(reqLogger, instance)
+	}
 	if instance.Labels[toolchainv1alpha1.UserSignupStateLabelKey] == "" {
-		if err := r.setStateLabel(reqLogger, instance, toolchainv1alpha1.UserSignupStateLabelValueNotReady); err != nil {
+		if err := r.setStateLabel(reqLogger, instance, toolchainv1alpha1.UserSignupStateLabelValueNotReady, StateTransitionActorAutomatic, "new UserSignup"); err != nil {
 			return reconcile.Result{}, err
 		}
 	}
@@ -171,7 +212,7 @@ func (r *ReconcileUserSignup) Reconcile(request reconcile.Request) (reconcile.Re
 	// and return
 	if banned {
 		// if the UserSignup doesn't have the state=banned label set, then update it
-		if err := r.setStateLabel(reqLogger, instance, toolchainv1alpha1.UserSignupStateLabelValueBanned); err != nil {
+		if err := r.setStateLabel(reqLogger, instance, toolchainv1alpha1.UserSignupStateLabelValueBanned, StateTransitionActorBanning, "user is banned"); err != nil {
 			return reconcile.Result{}, err
 		}
 		return reconcile.Result{}, r.updateStatus(reqLogger, instance, r.setStatusBanned)
@@ -181,7 +222,10 @@ func (r *ReconcileUserSignup) Reconcile(request reconcile.Request) (reconcile.Re
 	// send a notification to the user, and return
 	if instance.Spec.Deactivated {
 		// if the UserSignup doesn't have the state=deactivated label set, then update it
-		if err := r.setStateLabel(reqLogger, instance, toolchainv1alpha1.UserSignupStateLabelValueDeactivated); err != nil {
+		if err := r.setStateLabel(reqLogger, instance, toolchainv1alpha1.UserSignupStateLabelValueDeactivated, deactivationActor(instance), "user is deactivated"); err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := r.recordDeactivatedEmailDomain(instance); err != nil {
 			return reconcile.Result{}, err
 		}
 		if condition.IsNotTrue(instance.Status.Conditions, toolchainv1alpha1.UserSignupUserDeactivatedNotificationCreated) {
@@ -200,6 +244,11 @@ func (r *ReconcileUserSignup) Reconcile(request reconcile.Request) (reconcile.Re
 		return reconcile.Result{}, r.updateStatus(reqLogger, instance, r.setStatusDeactivated)
 	}
 
+	// If the UserSignup was reactivated, then the user may need to go through the verification again before the MasterUserRecord is created
+	if required, err := r.requireReverificationIfNeeded(reqLogger, instance); required || err != nil {
+		return reconcile.Result{}, err
+	}
+
 	return reconcile.Result{}, r.ensureNewMurIfApproved(reqLogger, instance)
 }
 
@@ -275,7 +324,7 @@ func (r *ReconcileUserSignup) ensureMurIfAlreadyExists(reqLogger logr.Logger, us
 		// If the user has been banned, then we need to delete the MUR
 		if banned {
 			// set the state label to banned
-			if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueBanned); err != nil {
+			if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueBanned, StateTransitionActorBanning, "user is banned"); err != nil {
 				return true, err
 			}
 			return true, r.DeleteMasterUserRecord(mur, userSignup, reqLogger, r.setStatusBanning, r.setStatusFailedToDeleteMUR)
@@ -284,19 +333,19 @@ func (r *ReconcileUserSignup) ensureMurIfAlreadyExists(reqLogger logr.Logger, us
 		// If the user has been deactivated, then we need to delete the MUR
 		if userSignup.Spec.Deactivated {
 			// set the state label to deactivated
-			if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueDeactivated); err != nil {
+			if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueDeactivated, deactivationActor(userSignup), "user is deactivated"); err != nil {
 				return true, err
 			}
 			return true, r.DeleteMasterUserRecord(mur, userSignup, reqLogger, r.setStatusDeactivating, r.setStatusFailedToDeleteMUR)
 		}
 
 		// if the UserSignup doesn't have the state=approved label set, then update it
-		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueApproved); err != nil {
+		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueApproved, approvalActor(userSignup), "MasterUserRecord already exists"); err != nil {
 			return true, err
 		}
 
-		// look-up the default NSTemplateTier to get the NS templates
-		nstemplateTier, err := getNsTemplateTier(r.client, defaultTierName, userSignup.Namespace)
+		// look-up the initial NSTemplateTier to get the NS templates
+		nstemplateTier, err := getNsTemplateTier(r.client, getInitialTierName(r.crtConfig, userSignup), userSignup.Namespace)
 		if err != nil {
 			return true, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusNoTemplateTierAvailable, err, "")
 		}
@@ -312,6 +361,11 @@ func (r *ReconcileUserSignup) ensureMurIfAlreadyExists(reqLogger logr.Logger, us
 			return true, nil
 		}
 
+		// check if the user should be migrated off a drained member cluster
+		if changed, err := r.migrateOffDrainedClusterIfNecessary(reqLogger, userSignup, mur); err != nil || changed {
+			return true, err
+		}
+
 		// If we successfully found an existing MasterUserRecord then our work here is done, set the status
 		// conditions to complete and set the compliant username and return
 		reqLogger.Info("MasterUserRecord exists, setting UserSignup status to 'Complete'")
@@ -327,18 +381,32 @@ func (r *ReconcileUserSignup) ensureNewMurIfApproved(reqLogger logr.Logger, user
 		return r.updateStatus(reqLogger, userSignup, r.setStatusVerificationRequired)
 	}
 
-	approved, targetCluster, err := getClusterIfApproved(r.client, r.crtConfig, userSignup, r.getMemberClusters)
+	approved, targetCluster, additionalTargetClusters, approvalMessage, err := getClusterIfApproved(r.client, r.crtConfig, userSignup, r.getMemberClusters)
 	// if error was returned or no available cluster found
 	if err != nil || targetCluster == notFound {
 		// set the state label to pending
-		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValuePending); err != nil {
+		reason := joinMessages("no suitable member cluster found", approvalMessage)
+		if err != nil {
+			reason = fmt.Sprintf("getting target clusters failed: %s", err)
+		}
+		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValuePending, StateTransitionActorAutomatic, reason); err != nil {
 			return err
 		}
+		if userSignup.Spec.Approved && err == nil {
+			err = fmt.Errorf("%s", joinMessages("no suitable member cluster found - capacity was reached", approvalMessage))
+		}
+		var message string
+		if err != nil {
+			message = err.Error()
+		} else {
+			message = r.pendingApprovalMessage(reqLogger, userSignup, approvalMessage)
+		}
+		// explain why there is no suitable member cluster, unless it was already explained when the status was set by a previous reconcile
+		if !hasNoClusterAvailableStatus(userSignup, message) {
+			r.recorder.Event(userSignup, corev1.EventTypeWarning, UserSignupPlacementFailedReason, reason)
+		}
 		// if user was approved manually
 		if userSignup.Spec.Approved {
-			if err == nil {
-				err = fmt.Errorf("no suitable member cluster found - capacity was reached")
-			}
 			return r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.set(statusApprovedByAdmin, statusNoClustersAvailable), err, "no target clusters available")
 		}
 
@@ -347,15 +415,16 @@ func (r *ReconcileUserSignup) ensureNewMurIfApproved(reqLogger logr.Logger, user
 			return r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.set(statusPendingApproval, statusNoClustersAvailable), err, "getting target clusters failed")
 		}
 		// in case no error was returned which means that no cluster was found, then just wait for next reconcile triggered by ToolchainStatus update
-		return r.updateStatus(reqLogger, userSignup, r.set(statusPendingApproval, statusNoClustersAvailable))
+		return r.updateStatusWithMessage(reqLogger, userSignup, r.set(statusPendingApproval, statusNoClustersAvailable), message)
 	}
 
 	if !approved {
 		// set the state label to pending
-		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValuePending); err != nil {
+		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValuePending, StateTransitionActorAutomatic, joinMessages("pending approval", approvalMessage)); err != nil {
 			return err
 		}
-		return r.updateStatus(reqLogger, userSignup, r.set(statusPendingApproval, statusIncompletePendingApproval))
+		return r.updateStatusWithMessage(reqLogger, userSignup, r.set(statusPendingApproval, statusIncompletePendingApproval),
+			r.pendingApprovalMessage(reqLogger, userSignup, approvalMessage))
 	}
 
 	if userSignup.Spec.Approved {
@@ -363,39 +432,97 @@ func (r *ReconcileUserSignup) ensureNewMurIfApproved(reqLogger logr.Logger, user
 			return err
 		}
 	} else {
-		if err := r.updateStatus(reqLogger, userSignup, r.setStatusApprovedAutomatically); err != nil {
+		if err := r.updateStatusWithMessage(reqLogger, userSignup, r.setStatusApprovedAutomatically, approvalMessage); err != nil {
 			return err
 		}
 	}
 	// set the state label to approved
-	if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueApproved); err != nil {
+	if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueApproved, approvalActor(userSignup), approvalReason(userSignup, approvalMessage)); err != nil {
 		return err
 	}
 
-	// look-up the `basic` NSTemplateTier to get the NS templates
-	nstemplateTier, err := getNsTemplateTier(r.client, defaultTierName, userSignup.Namespace)
+	// look-up the initial NSTemplateTier to get the NS templates
+	nstemplateTier, err := getNsTemplateTier(r.client, getInitialTierName(r.crtConfig, userSignup), userSignup.Namespace)
 	if err != nil {
 		return r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusNoTemplateTierAvailable, err, "")
 	}
 
 	// Provision the MasterUserRecord
-	return r.provisionMasterUserRecord(userSignup, targetCluster.getClusterName(), nstemplateTier, reqLogger)
+	return r.provisionMasterUserRecord(userSignup, targetCluster.getClusterName(), additionalTargetClusters, nstemplateTier, reqLogger)
+}
+
+// pendingApprovalMessage returns the given message extended with the position of the UserSignup in the approval queue
+// and the estimated wait for the approval
+func (r *ReconcileUserSignup) pendingApprovalMessage(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup, message string) string {
+	queueMessage, err := unapproved.QueueMessage(r.client, userSignup)
+	if err != nil {
+		// the position is only informative, so don't fail the reconcile because of it
+		reqLogger.Error(err, "unable to get the position in the approval queue")
+	}
+	return joinMessages(message, queueMessage)
+}
+
+// hasNoClusterAvailableStatus returns true if the Complete condition of the UserSignup already says that no member cluster
+// is available, with the given message
+func hasNoClusterAvailableStatus(userSignup *toolchainv1alpha1.UserSignup, message string) bool {
+	complete, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete)
+	return found && complete.Reason == toolchainv1alpha1.UserSignupNoClusterAvailableReason && complete.Message == message
+}
+
+// joinMessages joins the non-empty messages using semicolons
+func joinMessages(messages ...string) string {
+	var nonEmpty []string
+	for _, message := range messages {
+		if message != "" {
+			nonEmpty = append(nonEmpty, message)
+		}
+	}
+	return strings.Join(nonEmpty, "; ")
 }
 
-func (r *ReconcileUserSignup) setStateLabel(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup, value string) error {
+// setStateLabel sets the state label of the UserSignup to the given value. If the value is changed, then the transition is recorded
+// in the state history together with the given actor and reason.
+func (r *ReconcileUserSignup) setStateLabel(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup, value, actor, reason string) error {
 	oldValue := userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey]
 	if oldValue != value {
 		userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey] = value
+		if err := recordStateTransition(userSignup, StateTransition{
+			State:          value,
+			PreviousState:  oldValue,
+			TransitionTime: v1.Now(),
+			Actor:          actor,
+			Reason:         reason,
+		}, r.crtConfig.GetUserSignupStateHistoryMaxLength()); err != nil {
+			return err
+		}
+		delete(userSignup.Annotations, UserSignupDeactivatedByAnnotationKey)
 		if err := r.client.Update(context.TODO(), userSignup); err != nil {
 			return r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusFailedToUpdateStateLabel, err,
 				"unable to update state label at UserSignup resource")
 		}
 		updateMetricsByState(oldValue, value)
+		r.recordStateTransitionEvent(userSignup, value, actor, reason)
 		return nil
 	}
 	return nil
 }
 
+// recordStateTransitionEvent emits an event on the UserSignup when it was approved, deactivated or banned
+func (r *ReconcileUserSignup) recordStateTransitionEvent(userSignup *toolchainv1alpha1.UserSignup, state, actor, reason string) {
+	var eventReason string
+	switch state {
+	case toolchainv1alpha1.UserSignupStateLabelValueApproved:
+		eventReason = UserSignupApprovedReason
+	case toolchainv1alpha1.UserSignupStateLabelValueDeactivated:
+		eventReason = toolchainv1alpha1.UserSignupUserDeactivatedReason
+	case toolchainv1alpha1.UserSignupStateLabelValueBanned:
+		eventReason = toolchainv1alpha1.UserSignupUserBannedReason
+	default:
+		return
+	}
+	r.recorder.Eventf(userSignup, corev1.EventTypeNormal, eventReason, "%s (actor: %s)", reason, actor)
+}
+
 func updateMetricsByState(oldState, newState string) {
 	if oldState == "" {
 		metrics.UserSignupUniqueTotal.Inc()
@@ -416,70 +543,27 @@ func getNsTemplateTier(cl client.Client, tierName, namespace string) (*toolchain
 	return nstemplateTier, err
 }
 
-func (r *ReconcileUserSignup) generateCompliantUsername(instance *toolchainv1alpha1.UserSignup) (string, error) {
-	replaced := usersignup.TransformUsername(instance.Spec.Username)
-
-	// Check for any forbidden prefixes
-	for _, prefix := range r.crtConfig.GetForbiddenUsernamePrefixes() {
-		if strings.HasPrefix(replaced, prefix) {
-			replaced = fmt.Sprintf("%s%s", "crt-", replaced)
-			break
-		}
-	}
-
-	// Check for any forbidden suffixes
-	for _, suffix := range r.crtConfig.GetForbiddenUsernameSuffixes() {
-		if strings.HasSuffix(replaced, suffix) {
-			replaced = fmt.Sprintf("%s%s", replaced, "-crt")
-			break
-		}
-	}
-
-	validationErrors := validation.IsQualifiedName(replaced)
-	if len(validationErrors) > 0 {
-		return "", fmt.Errorf(fmt.Sprintf("transformed username [%s] is invalid", replaced))
-	}
-
-	transformed := replaced
-
-	for i := 2; i < 101; i++ { // No more than 100 attempts to find a vacant name
-		mur := &toolchainv1alpha1.MasterUserRecord{}
-		// Check if a MasterUserRecord exists with the same transformed name
-		namespacedMurName := types.NamespacedName{Namespace: instance.Namespace, Name: transformed}
-		err := r.client.Get(context.TODO(), namespacedMurName, mur)
-		if err != nil {
-			if !errors.IsNotFound(err) {
-				return "", err
-			}
-			// If there was a NotFound error looking up the mur, it means we found an available name
-			return transformed, nil
-		} else if mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] == instance.Name {
-			// If the found MUR has the same UserID as the UserSignup, then *it* is the correct MUR -
-			// Return an error here and allow the reconcile() function to pick it up on the next loop
-			return "", fmt.Errorf(fmt.Sprintf("INFO: could not generate compliant username as MasterUserRecord with the same name [%s] and user id [%s] already exists. The next reconcile loop will pick it up.", mur.Name, instance.Name))
-		}
-
-		transformed = fmt.Sprintf("%s-%d", replaced, i)
-	}
-
-	return "", fmt.Errorf(fmt.Sprintf("unable to transform username [%s] even after 100 attempts", instance.Spec.Username))
-}
-
-// provisionMasterUserRecord does the work of provisioning the MasterUserRecord
-func (r *ReconcileUserSignup) provisionMasterUserRecord(userSignup *toolchainv1alpha1.UserSignup, targetCluster string,
+// provisionMasterUserRecord does the work of provisioning the MasterUserRecord with a UserAccount for the target cluster
+// and for each of the additional target clusters
+func (r *ReconcileUserSignup) provisionMasterUserRecord(userSignup *toolchainv1alpha1.UserSignup, targetCluster string, additionalTargetClusters []string,
 	nstemplateTier *toolchainv1alpha1.NSTemplateTier, logger logr.Logger) error {
 
 	// TODO Update the MasterUserRecord with NSTemplateTier values
 	// SEE https://jira.coreos.com/browse/CRT-74
 
-	compliantUsername, err := r.generateCompliantUsername(userSignup)
+	compliantUsername, changeReason, err := r.generateCompliantUsername(userSignup)
 	if err != nil {
 		return r.wrapErrorWithStatusUpdate(logger, userSignup, r.setStatusFailedToCreateMUR, err,
 			"Error generating compliant username for %s", userSignup.Spec.Username)
 	}
+	if changeReason != "" {
+		if err := r.updateStatusWithMessage(logger, userSignup, r.setStatusUsernameChanged, changeReason); err != nil {
+			return err
+		}
+	}
 
 	mur, err := newMasterUserRecord(nstemplateTier, compliantUsername, userSignup.Namespace, targetCluster,
-		userSignup.Name, userSignup.Spec.UserID)
+		userSignup.Name, userSignup.Spec.UserID, additionalTargetClusters...)
 	if err != nil {
 		return r.wrapErrorWithStatusUpdate(logger, userSignup, r.setStatusFailedToCreateMUR, err,
 			"Error creating MasterUserRecord %s", mur.Name)
@@ -498,8 +582,11 @@ func (r *ReconcileUserSignup) provisionMasterUserRecord(userSignup *toolchainv1a
 			"Error creating MasterUserRecord")
 	}
 	counter.IncrementMasterUserRecordCount()
+	if !userSignup.Spec.Approved {
+		consumeApprovalBudget(r.crtConfig, targetCluster, additionalTargetClusters, time.Now())
+	}
 
-	logger.Info("Created MasterUserRecord", "Name", mur.Name, "TargetCluster", targetCluster)
+	logger.Info("Created MasterUserRecord", "Name", mur.Name, "TargetCluster", targetCluster, "AdditionalTargetClusters", additionalTargetClusters)
 	return nil
 }
 
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/approvalbudget"
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
@@ -26,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -98,6 +101,93 @@ func TestUserSignupCreateMUROk(t *testing.T) {
 	AssertThatCounters(t).HaveMasterUserRecords(2)
 }
 
+func TestUserSignupCreateMURWithMultipleUserAccounts(t *testing.T) {
+	// given
+	userSignup := NewUserSignup(Approved())
+	userSignup.Annotations[UserSignupUserAccountsAnnotationKey] = "2"
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(
+		NewMemberCluster(t, "member1", v1.ConditionTrue),
+		NewMemberCluster(t, "member2", v1.ConditionTrue)),
+		userSignup, baseNSTemplateTier, NewHostOperatorConfigWithReset(t))
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+	// when
+	_, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	murs := &v1alpha1.MasterUserRecordList{}
+	err = r.client.List(context.TODO(), murs)
+	require.NoError(t, err)
+	require.Len(t, murs.Items, 1)
+	mur := murs.Items[0]
+	require.Len(t, mur.Spec.UserAccounts, 2)
+	assert.Equal(t, "member1", mur.Spec.UserAccounts[0].TargetCluster)
+	assert.Equal(t, "member2", mur.Spec.UserAccounts[1].TargetCluster)
+	for _, ua := range mur.Spec.UserAccounts {
+		assert.Equal(t, "base", ua.Spec.NSTemplateSet.TierName)
+	}
+	AssertThatCounters(t).HaveMasterUserRecords(2)
+}
+
+func TestUserSignupWithAutoApprovalToMultipleMemberClustersConsumesOverallBudgetOnce(t *testing.T) {
+	// given
+	approvalbudget.Reset()
+	defer approvalbudget.Reset()
+	restore := test.SetEnvVarsAndRestore(t,
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_HOURLY", "10"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_DAILY", "100"),
+		test.Env("HOST_OPERATOR_AUTOMATICAPPROVAL_BUDGET_PERMEMBERCLUSTER", "member1=5,member2=5"))
+	defer restore()
+	userSignup := NewUserSignup()
+	userSignup.Annotations[UserSignupUserAccountsAnnotationKey] = "2"
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(
+		NewMemberCluster(t, "member1", v1.ConditionTrue),
+		NewMemberCluster(t, "member2", v1.ConditionTrue)),
+		userSignup, baseNSTemplateTier, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()))
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+	// when
+	_, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	murs := &v1alpha1.MasterUserRecordList{}
+	err = r.client.List(context.TODO(), murs)
+	require.NoError(t, err)
+	require.Len(t, murs.Items, 1)
+	require.Len(t, murs.Items[0].Spec.UserAccounts, 2)
+	remaining := approvalbudget.GetRemaining(r.crtConfig, time.Now())
+	assert.Equal(t, 9, remaining.Hourly.Remaining)
+	assert.Equal(t, 99, remaining.Daily.Remaining)
+	assert.Equal(t, "hourly: 9/10, daily: 99/100, member1: 4/5, member2: 4/5", remaining.String())
+}
+
+func TestUserSignupCreateMURWithTierFromAssignmentRules(t *testing.T) {
+	// given
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_TIER_ASSIGNMENT_RULES", "emailDomain:partner.com:team")
+	defer restore()
+	userSignup := NewUserSignup(Approved(), WithTargetCluster("east"), WithEmail("foo@partner.com"))
+	teamNSTemplateTier := newNsTemplateTier("team", "dev", "stage")
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, baseNSTemplateTier, teamNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+	// when
+	_, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	murs := &v1alpha1.MasterUserRecordList{}
+	err = r.client.List(context.TODO(), murs)
+	require.NoError(t, err)
+	require.Len(t, murs.Items, 1)
+	mur := murs.Items[0]
+	require.Len(t, mur.Spec.UserAccounts, 1)
+	assert.Equal(t, "team", mur.Spec.UserAccounts[0].Spec.NSTemplateSet.TierName)
+	assert.Equal(t, "team-dev-123abc1", mur.Spec.UserAccounts[0].Spec.NSTemplateSet.Namespaces[0].TemplateRef)
+	AssertThatCounters(t).HaveMasterUserRecords(2)
+}
+
 func TestUserSignupWithAutoApprovalWithoutTargetCluster(t *testing.T) {
 	// given
 	userSignup := NewUserSignup()
@@ -397,14 +487,16 @@ func TestUnapprovedUserSignupWhenNoClusterReady(t *testing.T) {
 	t.Logf("usersignup status: %+v", userSignup.Status)
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupApproved,
-			Status: v1.ConditionFalse,
-			Reason: "PendingApproval",
+			Type:    v1alpha1.UserSignupApproved,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "member1 rejected: number of users 2 >= overall threshold 1, not ready; member2 rejected: number of users 2 >= overall threshold 1, not ready; position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupComplete,
-			Status: v1.ConditionFalse,
-			Reason: "NoClusterAvailable",
+			Type:    v1alpha1.UserSignupComplete,
+			Status:  v1.ConditionFalse,
+			Reason:  "NoClusterAvailable",
+			Message: "member1 rejected: number of users 2 >= overall threshold 1, not ready; member2 rejected: number of users 2 >= overall threshold 1, not ready; position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
 			Type:   v1alpha1.UserSignupUserDeactivatedNotificationCreated,
@@ -441,14 +533,16 @@ func TestUserSignupFailedNoClusterWithCapacityAvailable(t *testing.T) {
 	t.Logf("usersignup status: %+v", userSignup.Status)
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupApproved,
-			Status: v1.ConditionFalse,
-			Reason: "PendingApproval",
+			Type:    v1alpha1.UserSignupApproved,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "member1 rejected: memory 65% >= threshold 60% on master role, memory 68% >= threshold 60% on worker role; member2 rejected: not present in ToolchainStatus; position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupComplete,
-			Status: v1.ConditionFalse,
-			Reason: "NoClusterAvailable",
+			Type:    v1alpha1.UserSignupComplete,
+			Status:  v1.ConditionFalse,
+			Reason:  "NoClusterAvailable",
+			Message: "member1 rejected: memory 65% >= threshold 60% on master role, memory 68% >= threshold 60% on worker role; member2 rejected: not present in ToolchainStatus; position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
 			Type:   v1alpha1.UserSignupUserDeactivatedNotificationCreated,
@@ -461,6 +555,31 @@ func TestUserSignupFailedNoClusterWithCapacityAvailable(t *testing.T) {
 	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
 
 	AssertThatCounters(t).HaveMasterUserRecords(1)
+	events := r.recorder.(*record.FakeRecorder).Events
+	require.Len(t, events, 1)
+	assert.Equal(t, "Warning PlacementFailed no suitable member cluster found; member1 rejected: memory 65% >= threshold 60% on master role, memory 68% >= threshold 60% on worker role; member2 rejected: not present in ToolchainStatus", <-events)
+
+	t.Run("no event when the placement failure has not changed", func(t *testing.T) {
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("event when the placement failure has changed", func(t *testing.T) {
+		// given
+		r.getMemberClusters = NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+
+		// when
+		_, err := r.Reconcile(req)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Warning PlacementFailed no suitable member cluster found; member1 rejected: memory 65% >= threshold 60% on master role, memory 68% >= threshold 60% on worker role", <-events)
+	})
 }
 
 func TestUserSignupWithManualApprovalApproved(t *testing.T) {
@@ -655,14 +774,56 @@ func TestUserSignupWithManualApprovalNotApproved(t *testing.T) {
 
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupApproved,
-			Status: v1.ConditionFalse,
-			Reason: "PendingApproval",
+			Type:    v1alpha1.UserSignupApproved,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupComplete,
+			Type:    v1alpha1.UserSignupComplete,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "position in the approval queue: 1",
+		},
+		v1alpha1.Condition{
+			Type:   v1alpha1.UserSignupUserDeactivatedNotificationCreated,
 			Status: v1.ConditionFalse,
-			Reason: "PendingApproval",
+			Reason: "UserIsActive",
+		})
+	AssertThatCounters(t).HaveMasterUserRecords(1)
+}
+
+func TestUserSignupWithAutoApprovalForDeniedEmailDomain(t *testing.T) {
+	// given
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_DENIED", "*.mailinator.com")
+	defer restore()
+	userSignup := NewUserSignup(WithEmail("foo@eu.mailinator.com"))
+
+	ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+	r, req, _ := prepareReconcile(t, userSignup.Name, ready, userSignup, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Enabled()), baseNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+	// when
+	res, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, reconcile.Result{}, res)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", userSignup.Labels[v1alpha1.UserSignupStateLabelKey])
+	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
+		v1alpha1.Condition{
+			Type:    v1alpha1.UserSignupApproved,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "automatic approval denied: email domain 'eu.mailinator.com' matches the deny rule '*.mailinator.com'; position in the approval queue: 1",
+		},
+		v1alpha1.Condition{
+			Type:    v1alpha1.UserSignupComplete,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "automatic approval denied: email domain 'eu.mailinator.com' matches the deny rule '*.mailinator.com'; position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
 			Type:   v1alpha1.UserSignupUserDeactivatedNotificationCreated,
@@ -672,6 +833,33 @@ func TestUserSignupWithManualApprovalNotApproved(t *testing.T) {
 	AssertThatCounters(t).HaveMasterUserRecords(1)
 }
 
+func TestUserSignupWithAutoApprovalForAllowedEmailDomain(t *testing.T) {
+	// given
+	restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_AUTOMATICAPPROVAL_DOMAINS_ALLOWED", "redhat.com")
+	defer restore()
+	userSignup := NewUserSignup(WithTargetCluster("east"))
+
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, NewHostOperatorConfigWithReset(t, test.AutomaticApproval().Disabled()), baseNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus(WithHost(WithMasterUserRecordCount(1))))
+
+	// when
+	res, err := r.Reconcile(req)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, reconcile.Result{}, res)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
+	require.NoError(t, err)
+	assert.Equal(t, "approved", userSignup.Labels[v1alpha1.UserSignupStateLabelKey])
+	test.AssertContainsCondition(t, userSignup.Status.Conditions, v1alpha1.Condition{
+		Type:    v1alpha1.UserSignupApproved,
+		Status:  v1.ConditionTrue,
+		Reason:  "ApprovedAutomatically",
+		Message: "approved automatically: email domain 'redhat.com' matches the allow rule 'redhat.com'",
+	})
+	AssertThatCounters(t).HaveMasterUserRecords(2)
+}
+
 func TestUserSignupWithAutoApprovalWithTargetCluster(t *testing.T) {
 	// given
 	userSignup := NewUserSignup(WithTargetCluster("east"))
@@ -778,14 +966,16 @@ func TestUserSignupWithMissingApprovalPolicyTreatedAsManual(t *testing.T) {
 
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupApproved,
-			Status: v1.ConditionFalse,
-			Reason: "PendingApproval",
+			Type:    v1alpha1.UserSignupApproved,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
-			Type:   v1alpha1.UserSignupComplete,
-			Status: v1.ConditionFalse,
-			Reason: "PendingApproval",
+			Type:    v1alpha1.UserSignupComplete,
+			Status:  v1.ConditionFalse,
+			Reason:  "PendingApproval",
+			Message: "position in the approval queue: 1",
 		},
 		v1alpha1.Condition{
 			Type:   v1alpha1.UserSignupUserDeactivatedNotificationCreated,
@@ -2097,6 +2287,7 @@ func prepareReconcile(t *testing.T, name string, getMemberClusters cluster.GetMe
 			client: fakeClient,
 		},
 		scheme:            s,
+		recorder:          record.NewFakeRecorder(100),
 		crtConfig:         config,
 		getMemberClusters: getMemberClusters,
 	}
@@ -4,11 +4,15 @@ import (
 	"context"
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/usersignup"
+	"github.com/codeready-toolchain/host-operator/pkg/usernameregistry"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
 	"github.com/go-logr/logr"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -35,6 +39,7 @@ func newReconciler(mgr manager.Manager, crtConfig *crtCfg.Config) reconcile.Reco
 		client:    mgr.GetClient(),
 		scheme:    mgr.GetScheme(),
 		crtConfig: crtConfig,
+		recorder:  mgr.GetEventRecorderFor("usersignup-cleanup-controller"),
 	}
 }
 
@@ -63,6 +68,7 @@ type ReconcileUserSignupCleanup struct {
 	client    client.Client
 	scheme    *runtime.Scheme
 	crtConfig *crtCfg.Config
+	recorder  record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a UserSignup object and makes changes based on the state read
@@ -92,12 +98,18 @@ func (r *ReconcileUserSignupCleanup) Reconcile(request reconcile.Request) (recon
 	if instance.Spec.VerificationRequired && !instance.Spec.Approved {
 
 		createdTime := instance.ObjectMeta.CreationTimestamp
+		// the retention period of a reactivated UserSignup whose user has to go through the verification again starts when it was requested
+		if requested, found := instance.Annotations[usersignup.UserSignupReverificationRequestedAnnotationKey]; found {
+			if requestedTime, err := time.Parse(time.RFC3339, requested); err == nil && requestedTime.After(createdTime.Time) {
+				createdTime = metav1.NewTime(requestedTime)
+			}
+		}
 
 		unverifiedThreshold := time.Now().Add(-time.Duration(r.crtConfig.GetUserSignupUnverifiedRetentionDays()*24) * time.Hour)
 
 		if createdTime.Time.Before(unverifiedThreshold) {
 			reqLogger.Info("Deleting UserSignup due to exceeding unverified retention period")
-			return reconcile.Result{}, r.DeleteUserSignup(instance, reqLogger)
+			return reconcile.Result{}, r.DeleteUserSignup(instance, "unverified retention period exceeded", reqLogger)
 		}
 
 		// Requeue this for reconciliation after the time has passed between the last active time
@@ -127,7 +139,7 @@ func (r *ReconcileUserSignupCleanup) Reconcile(request reconcile.Request) (recon
 
 		if cond.LastTransitionTime.Time.Before(deactivatedThreshold) {
 			reqLogger.Info("Deleting UserSignup due to exceeding deactivated retention period")
-			return reconcile.Result{}, r.DeleteUserSignup(instance, reqLogger)
+			return reconcile.Result{}, r.DeleteUserSignup(instance, "deactivated retention period exceeded", reqLogger)
 		}
 
 		// Requeue this for reconciliation after the time has passed between the last transition time
@@ -144,13 +156,25 @@ func (r *ReconcileUserSignupCleanup) Reconcile(request reconcile.Request) (recon
 	return reconcile.Result{}, nil
 }
 
-// DeleteUserSignup deletes the specified UserSignup
-func (r *ReconcileUserSignupCleanup) DeleteUserSignup(userSignup *toolchainv1alpha1.UserSignup, logger logr.Logger) error {
+// DeleteUserSignup deletes the specified UserSignup. Before that it parks the compliant username of the UserSignup
+// in the username registry for the configured cooldown period, so it cannot be used by a new user in the meantime.
+// The given reason is used in the message of the Deleted event.
+func (r *ReconcileUserSignupCleanup) DeleteUserSignup(userSignup *toolchainv1alpha1.UserSignup, reason string, logger logr.Logger) error {
+
+	if cooldown := r.crtConfig.GetReservedUsernamesCooldown(); cooldown > 0 && userSignup.Status.CompliantUsername != "" {
+		now := time.Now()
+		if err := usernameregistry.Park(r.client, userSignup.Namespace, r.crtConfig.GetReservedUsernamesConfigMapName(),
+			userSignup.Status.CompliantUsername, now.Add(cooldown), now); err != nil {
+			return err
+		}
+		logger.Info("Parked the username of the UserSignup", "CompliantUsername", userSignup.Status.CompliantUsername, "Cooldown", cooldown)
+	}
 
 	err := r.client.Delete(context.TODO(), userSignup)
 	if err != nil {
 		return err
 	}
 	logger.Info("Deleted UserSignup", "Name", userSignup.Name)
+	r.recorder.Event(userSignup, apiv1.EventTypeNormal, usersignup.UserSignupDeletedReason, reason)
 	return nil
 }
@@ -9,13 +9,17 @@ import (
 	"github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
 	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/controller/usersignup"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/host-operator/pkg/usernameregistry"
 	test2 "github.com/codeready-toolchain/host-operator/test"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"k8s.io/client-go/kubernetes/scheme"
@@ -95,6 +99,55 @@ func TestUserCleanup(t *testing.T) {
 		require.True(t, errors.IsNotFound(err))
 		statusErr := err.(*errors.StatusError)
 		require.Equal(t, fmt.Sprintf("usersignups.toolchain.dev.openshift.com \"%s\" not found", key.Name), statusErr.Error())
+		require.Equal(t, "Normal Deleted deactivated retention period exceeded", <-r.recorder.(*record.FakeRecorder).Events)
+	})
+
+	t.Run("test that the username of a deleted UserSignup is parked", func(t *testing.T) {
+
+		restore := test.SetEnvVarAndRestore(t, "HOST_OPERATOR_USERNAME_RESERVED_COOLDOWN", "720h")
+		defer restore()
+		userSignup := test2.NewUserSignup(
+			test2.DeactivatedWithLastTransitionTime(threeYears),
+			test2.CreatedBefore(threeYears),
+			test2.WithStateLabel(v1alpha1.UserSignupStateLabelValueApproved),
+			test2.ApprovedAutomatically(),
+		)
+		userSignup.Status.CompliantUsername = "foo"
+
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+
+		_, err := r.Reconcile(req)
+		require.NoError(t, err)
+
+		// Confirm the UserSignup has been deleted and its username parked for the configured cooldown period
+		err = r.client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+		require.True(t, errors.IsNotFound(err))
+		registry, err := usernameregistry.Load(r.client, test.HostOperatorNs, "reserved-usernames")
+		require.NoError(t, err)
+		require.True(t, registry.IsReserved("foo", time.Now().Add(29*24*time.Hour)))
+		require.False(t, registry.IsReserved("foo", time.Now().Add(31*24*time.Hour)))
+	})
+
+	t.Run("test that the username of a deleted UserSignup is not parked by default", func(t *testing.T) {
+
+		userSignup := test2.NewUserSignup(
+			test2.DeactivatedWithLastTransitionTime(threeYears),
+			test2.CreatedBefore(threeYears),
+			test2.WithStateLabel(v1alpha1.UserSignupStateLabelValueApproved),
+			test2.ApprovedAutomatically(),
+		)
+		userSignup.Status.CompliantUsername = "foo"
+
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+
+		_, err := r.Reconcile(req)
+		require.NoError(t, err)
+
+		// Confirm the UserSignup has been deleted and there is no reserved username
+		err = r.client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+		require.True(t, errors.IsNotFound(err))
+		err = r.client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, "reserved-usernames"), &corev1.ConfigMap{})
+		require.True(t, errors.IsNotFound(err))
 	})
 
 	t.Run("test that an old, unverified UserSignup is deleted", func(t *testing.T) {
@@ -117,6 +170,26 @@ func TestUserCleanup(t *testing.T) {
 		require.IsType(t, &errors.StatusError{}, err)
 		statusErr := err.(*errors.StatusError)
 		require.Equal(t, fmt.Sprintf("usersignups.toolchain.dev.openshift.com \"%s\" not found", key.Name), statusErr.Error())
+		require.Equal(t, "Normal Deleted unverified retention period exceeded", <-r.recorder.(*record.FakeRecorder).Events)
+	})
+
+	t.Run("test that an old UserSignup recently required to be verified again on reactivation is not deleted", func(t *testing.T) {
+
+		userSignup := test2.NewUserSignup(
+			test2.CreatedBefore(threeYears),
+			test2.VerificationRequired(),
+		)
+		userSignup.Annotations[usersignup.UserSignupReverificationRequestedAnnotationKey] = time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+
+		res, err := r.Reconcile(req)
+		require.NoError(t, err)
+
+		// Confirm the UserSignup still exists
+		key := test.NamespacedName(test.HostOperatorNs, userSignup.Name)
+		require.NoError(t, r.client.Get(context.Background(), key, userSignup))
+		require.True(t, res.Requeue)
 	})
 
 	t.Run("test that an old, verified but unapproved UserSignup is not deleted", func(t *testing.T) {
@@ -157,6 +230,7 @@ func prepareReconcile(t *testing.T, name string, initObjs ...runtime.Object) (*R
 		scheme:    s,
 		crtConfig: config,
 		client:    fakeClient,
+		recorder:  record.NewFakeRecorder(100),
 	}
 	return r, newReconcileRequest(name), fakeClient
 }
@@ -20,6 +20,7 @@ var cachedCounts = cache{
 		MasterUserRecordCount:        0,
 		UserAccountsPerClusterCounts: map[string]int{},
 	},
+	additionalWeights: map[string]int{},
 }
 
 // Counts is type that contains number of MURs and number of UserAccounts per member cluster
@@ -28,12 +29,20 @@ type Counts struct {
 	MasterUserRecordCount int
 	// User Accounts per Clusters (indexed by cluster name)
 	UserAccountsPerClusterCounts map[string]int
+	// Sum of the weights of the tiers of the User Accounts per Clusters (indexed by cluster name)
+	WeightedUserAccountsPerClusterCounts map[string]int
 }
 
+// WeightFunc returns the weight of the UserAccounts of the given tier
+type WeightFunc func(tierName string) (int, error)
+
 type cache struct {
 	Counts
 	sync.RWMutex
 	initialized bool
+	// additionalWeights contains the sum of the weights exceeding 1 of the UserAccounts per member cluster (indexed by cluster name),
+	// so the weighted count is the number of UserAccounts plus the additional weight
+	additionalWeights map[string]int
 }
 
 func write(operation func()) {
@@ -53,6 +62,7 @@ func reset() {
 	cachedCounts.Counts = Counts{
 		UserAccountsPerClusterCounts: map[string]int{},
 	}
+	cachedCounts.additionalWeights = map[string]int{}
 	cachedCounts.initialized = false
 	metrics.MasterUserRecordGauge.Set(float64(0))
 	metrics.UserAccountGaugeVec.Reset()
@@ -82,18 +92,22 @@ func DecrementMasterUserRecordCount(log logr.Logger) {
 }
 
 // IncrementUserAccountCount increments the number of UserAccount for the given member cluster in the cached counter
-func IncrementUserAccountCount(clusterName string) {
+// and adds the given weight of the UserAccount to the weighted count
+func IncrementUserAccountCount(clusterName string, weight int) {
 	write(func() {
 		cachedCounts.UserAccountsPerClusterCounts[clusterName]++
+		cachedCounts.additionalWeights[clusterName] += weight - 1
 		metrics.UserAccountGaugeVec.WithLabelValues(clusterName).Set(float64(cachedCounts.UserAccountsPerClusterCounts[clusterName]))
 	})
 }
 
 // DecrementUserAccountCount decreases the number of UserAccount for the given member cluster in the cached counter
-func DecrementUserAccountCount(log logr.Logger, clusterName string) {
+// and subtracts the given weight of the UserAccount from the weighted count
+func DecrementUserAccountCount(log logr.Logger, clusterName string, weight int) {
 	write(func() {
 		if cachedCounts.UserAccountsPerClusterCounts[clusterName] != 0 || !cachedCounts.initialized { // counter can be decreased even if its current value is `0`, but only if the cache has not been initialized yet
 			cachedCounts.UserAccountsPerClusterCounts[clusterName]--
+			cachedCounts.additionalWeights[clusterName] -= weight - 1
 			metrics.UserAccountGaugeVec.WithLabelValues(clusterName).Set(float64(cachedCounts.UserAccountsPerClusterCounts[clusterName]))
 		} else {
 			log.Error(fmt.Errorf("the count of UserAccounts is zero"),
@@ -102,15 +116,28 @@ func DecrementUserAccountCount(log logr.Logger, clusterName string) {
 	})
 }
 
-// GetCounts returns Counts struct containing number of MURs and number of UserAccounts per member cluster.
+// UpdateUserAccountWeight replaces the given old weight of a UserAccount in the given member cluster with the new one in the weighted count,
+// eg. when the tier of the UserAccount was changed. The number of UserAccounts is not changed.
+func UpdateUserAccountWeight(clusterName string, oldWeight, newWeight int) {
+	write(func() {
+		cachedCounts.additionalWeights[clusterName] += newWeight - oldWeight
+	})
+}
+
+// GetCounts returns Counts struct containing number of MURs and number of UserAccounts (as well as the weighted counts) per member cluster.
 // If the counter is not yet initialized, then it returns error
 func GetCounts() (Counts, error) {
 	cachedCounts.RLock()
 	defer cachedCounts.RUnlock()
+	counts := cachedCounts.Counts
+	counts.WeightedUserAccountsPerClusterCounts = map[string]int{}
+	for clusterName, count := range cachedCounts.UserAccountsPerClusterCounts {
+		counts.WeightedUserAccountsPerClusterCounts[clusterName] = count + cachedCounts.additionalWeights[clusterName]
+	}
 	if !cachedCounts.initialized {
-		return cachedCounts.Counts, fmt.Errorf("counter is not initialized")
+		return counts, fmt.Errorf("counter is not initialized")
 	}
-	return cachedCounts.Counts, nil
+	return counts, nil
 }
 
 // Synchronize synchronizes the content of the ToolchainStatus with the cached counter
@@ -123,10 +150,20 @@ func GetCounts() (Counts, error) {
 //
 // If the cached counter is initialized and ToolchainStatus contains already some numbers
 // then it updates the ToolchainStatus numbers with the one taken from the cached counter
-func Synchronize(cl client.Client, toolchainStatus *toolchainv1alpha1.ToolchainStatus) error {
+//
+// When the counter is being initialized and the weight function is given, then it lists all existing MURs
+// to compute the weighted counts of UserAccounts - the ToolchainStatus contains only the plain numbers
+func Synchronize(cl client.Client, toolchainStatus *toolchainv1alpha1.ToolchainStatus, weightOf WeightFunc) error {
 	cachedCounts.Lock()
 	defer cachedCounts.Unlock()
 	log.Info("synchronizing counters", "cachedCounts.initialized", cachedCounts.initialized, "members", toolchainStatus.Status.Members)
+	var additionalWeights map[string]int
+	if !cachedCounts.initialized && weightOf != nil {
+		var err error
+		if additionalWeights, err = loadAdditionalWeights(cl, toolchainStatus.Namespace, weightOf); err != nil {
+			return err
+		}
+	}
 	if shouldLoadCurrentResources(toolchainStatus) {
 		if err := loadCurrentResources(cl, toolchainStatus.Namespace); err != nil {
 			return err
@@ -145,6 +182,11 @@ func Synchronize(cl client.Client, toolchainStatus *toolchainv1alpha1.ToolchainS
 		cachedCounts.initialized = true
 		log.Info("cachedCounts initialized", "useraccounts_per_cluster_counts", cachedCounts.UserAccountsPerClusterCounts)
 	}
+	if additionalWeights != nil {
+		// the weights of all existing UserAccounts were loaded, so they replace the ones collected before the initialization
+		cachedCounts.additionalWeights = additionalWeights
+		log.Info("cachedCounts initialized", "additional_weights_per_cluster", cachedCounts.additionalWeights)
+	}
 	toolchainStatus.Status.HostOperator.MasterUserRecordCount = cachedCounts.MasterUserRecordCount
 	metrics.MasterUserRecordGauge.Set(float64(cachedCounts.MasterUserRecordCount))
 
@@ -196,3 +238,24 @@ func loadCurrentResources(cl client.Client, namespace string) error {
 	cachedCounts.initialized = true
 	return nil
 }
+
+// loadAdditionalWeights lists all existing MURs and returns the sum of the weights exceeding 1 of their UserAccounts per member cluster.
+// If the weight of a tier cannot be determined, then the weight 1 is used.
+func loadAdditionalWeights(cl client.Client, namespace string, weightOf WeightFunc) (map[string]int, error) {
+	murs := &toolchainv1alpha1.MasterUserRecordList{}
+	if err := cl.List(context.TODO(), murs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	additionalWeights := map[string]int{}
+	for _, mur := range murs.Items {
+		for _, ua := range mur.Spec.UserAccounts {
+			weight, err := weightOf(ua.Spec.NSTemplateSet.TierName)
+			if err != nil {
+				log.Error(err, "unable to get the weight of the tier, using 1", "tier", ua.Spec.NSTemplateSet.TierName)
+				continue
+			}
+			additionalWeights[ua.TargetCluster] += weight - 1
+		}
+	}
+	return additionalWeights, nil
+}
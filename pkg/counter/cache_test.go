@@ -2,6 +2,7 @@ package counter_test
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 
@@ -70,7 +71,7 @@ func TestAddUserAccountToCounter(t *testing.T) {
 	defer counter.Reset()
 
 	// when
-	counter.IncrementUserAccountCount("member-1")
+	counter.IncrementUserAccountCount("member-1", 1)
 
 	// then
 	AssertThatCounters(t).HaveMasterUserRecords(1).HaveUserAccountsForCluster("member-1", 1)
@@ -85,12 +86,72 @@ func TestRemoveUserAccountFromCounter(t *testing.T) {
 	defer counter.Reset()
 
 	// when
-	counter.DecrementUserAccountCount(logger, "member-1")
+	counter.DecrementUserAccountCount(logger, "member-1", 1)
 
 	// then
 	AssertThatCounters(t).HaveMasterUserRecords(1).HaveUserAccountsForCluster("member-1", 1)
 }
 
+func TestAddWeightedUserAccountToCounter(t *testing.T) {
+	// given
+	InitializeCounters(t, NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember("member-1", WithUserAccountCount(1))))
+	defer counter.Reset()
+
+	// when
+	counter.IncrementUserAccountCount("member-1", 3)
+
+	// then
+	AssertThatCounters(t).HaveMasterUserRecords(1).
+		HaveUserAccountsForCluster("member-1", 2).
+		HaveWeightedUserAccountsForCluster("member-1", 4)
+}
+
+func TestRemoveWeightedUserAccountFromCounter(t *testing.T) {
+	// given
+	InitializeCounters(t, NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember("member-1", WithUserAccountCount(1))))
+	defer counter.Reset()
+	counter.IncrementUserAccountCount("member-1", 3)
+
+	// when
+	counter.DecrementUserAccountCount(logger, "member-1", 3)
+
+	// then
+	AssertThatCounters(t).HaveMasterUserRecords(1).
+		HaveUserAccountsForCluster("member-1", 1).
+		HaveWeightedUserAccountsForCluster("member-1", 1)
+}
+
+func TestUpdateWeightOfUserAccountInCounter(t *testing.T) {
+	// given
+	InitializeCounters(t, NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(1)),
+		WithMember("member-1", WithUserAccountCount(1))))
+	defer counter.Reset()
+	counter.IncrementUserAccountCount("member-1", 3)
+
+	// when
+	counter.UpdateUserAccountWeight("member-1", 3, 5)
+
+	// then
+	AssertThatCounters(t).HaveMasterUserRecords(1).
+		HaveUserAccountsForCluster("member-1", 2).
+		HaveWeightedUserAccountsForCluster("member-1", 6)
+
+	t.Run("removed with the new weight", func(t *testing.T) {
+		// when
+		counter.DecrementUserAccountCount(logger, "member-1", 5)
+
+		// then
+		AssertThatCounters(t).HaveMasterUserRecords(1).
+			HaveUserAccountsForCluster("member-1", 1).
+			HaveWeightedUserAccountsForCluster("member-1", 1)
+	})
+}
+
 func TestRemoveUserAccountFromCounterWhenIsAlreadyZero(t *testing.T) {
 	// given
 	InitializeCounters(t,
@@ -101,7 +162,7 @@ func TestRemoveUserAccountFromCounterWhenIsAlreadyZero(t *testing.T) {
 	defer counter.Reset()
 
 	// when
-	counter.DecrementUserAccountCount(logger, "member-1")
+	counter.DecrementUserAccountCount(logger, "member-1", 1)
 
 	// then
 	AssertThatCounters(t).HaveMasterUserRecords(2).
@@ -114,7 +175,7 @@ func TestRemoveUserAccountFromCounterWhenIsAlreadyZeroAndNotInitialized(t *testi
 	defer counter.Reset()
 
 	// when
-	counter.DecrementUserAccountCount(logger, "member-1")
+	counter.DecrementUserAccountCount(logger, "member-1", 1)
 
 	// then
 	AssertThatUninitializedCounters(t).HaveMasterUserRecords(0).
@@ -143,7 +204,7 @@ func TestInitializeCounterFromToolchainCluster(t *testing.T) {
 
 func TestInitializeCounterFromToolchainClusterWithNegativeNumbersInCache(t *testing.T) {
 	// given
-	counter.DecrementUserAccountCount(logger, "member-1")
+	counter.DecrementUserAccountCount(logger, "member-1", 1)
 	counter.DecrementMasterUserRecordCount(logger)
 	toolchainStatus := NewToolchainStatus(
 		WithHost(WithMasterUserRecordCount(13)),
@@ -168,7 +229,7 @@ func TestInitializeCounterByLoadingExistingResources(t *testing.T) {
 	logf.SetLogger(zap.Logger(true))
 	//this will be ignored by resetting when initializing counters
 	counter.IncrementMasterUserRecordCount()
-	counter.IncrementUserAccountCount("member-1")
+	counter.IncrementUserAccountCount("member-1", 1)
 
 	murs := CreateMultipleMurs(t, "user-", 10, "member-1")
 	toolchainStatus := NewToolchainStatus(
@@ -186,11 +247,53 @@ func TestInitializeCounterByLoadingExistingResources(t *testing.T) {
 		HasUserAccountCount("member-1", 10)
 }
 
+func TestInitializeWeightedCounterByLoadingExistingResources(t *testing.T) {
+	// given
+	murs := CreateMultipleMurs(t, "user-", 3, "member-1")
+	toolchainStatus := NewToolchainStatus(
+		WithHost(WithMasterUserRecordCount(3)),
+		WithMember("member-1", WithUserAccountCount(3)))
+	counter.Reset()
+	defer counter.Reset()
+
+	t.Run("weights are loaded from existing MasterUserRecords", func(t *testing.T) {
+		// given
+		defer counter.Reset()
+
+		// when
+		err := counter.Synchronize(test.NewFakeClient(t, murs...), toolchainStatus, func(tierName string) (int, error) {
+			return 5, nil
+		})
+
+		// then
+		require.NoError(t, err)
+		AssertThatCounters(t).HaveMasterUserRecords(3).
+			HaveUserAccountsForCluster("member-1", 3).
+			HaveWeightedUserAccountsForCluster("member-1", 15)
+	})
+
+	t.Run("weight of 1 is used when the weight cannot be computed", func(t *testing.T) {
+		// given
+		defer counter.Reset()
+
+		// when
+		err := counter.Synchronize(test.NewFakeClient(t, murs...), toolchainStatus, func(tierName string) (int, error) {
+			return 1, fmt.Errorf("some error")
+		})
+
+		// then
+		require.NoError(t, err)
+		AssertThatCounters(t).HaveMasterUserRecords(3).
+			HaveUserAccountsForCluster("member-1", 3).
+			HaveWeightedUserAccountsForCluster("member-1", 3)
+	})
+}
+
 func TestShouldNotInitializeAgain(t *testing.T) {
 	// given
 	//this will be ignored by resetting when loading existing MURs
 	counter.IncrementMasterUserRecordCount()
-	counter.IncrementUserAccountCount("member-1")
+	counter.IncrementUserAccountCount("member-1", 1)
 
 	murs := CreateMultipleMurs(t, "user-", 10, "member-1")
 	toolchainStatus := NewToolchainStatus(
@@ -202,7 +305,7 @@ func TestShouldNotInitializeAgain(t *testing.T) {
 	require.NoError(t, err)
 
 	// when
-	err = counter.Synchronize(fakeClient, toolchainStatus)
+	err = counter.Synchronize(fakeClient, toolchainStatus, nil)
 
 	// then
 	require.NoError(t, err)
@@ -245,22 +348,22 @@ func TestMultipleExecutionsInParallel(t *testing.T) {
 		go func(index int) {
 			defer waitForFinished.Done()
 			latch.Wait()
-			counter.IncrementUserAccountCount("member-2")
+			counter.IncrementUserAccountCount("member-2", 1)
 			if index < 1000 {
 				go func() {
 					defer waitForFinished.Done()
-					counter.DecrementUserAccountCount(logger, "member-2")
+					counter.DecrementUserAccountCount(logger, "member-2", 1)
 				}()
 			}
 		}(i)
 		go func(index int) {
 			defer waitForFinished.Done()
 			latch.Wait()
-			counter.IncrementUserAccountCount("member-1")
+			counter.IncrementUserAccountCount("member-1", 1)
 			if index < 1000 {
 				go func() {
 					defer waitForFinished.Done()
-					counter.DecrementUserAccountCount(logger, "member-1")
+					counter.DecrementUserAccountCount(logger, "member-1", 1)
 				}()
 			}
 		}(i)
@@ -271,7 +374,7 @@ func TestMultipleExecutionsInParallel(t *testing.T) {
 		go func() {
 			defer waitForFinished.Done()
 			latch.Wait()
-			err := counter.Synchronize(fakeClient, toolchainStatus)
+			err := counter.Synchronize(fakeClient, toolchainStatus, nil)
 			require.NoError(t, err)
 		}()
 	}
@@ -279,7 +382,7 @@ func TestMultipleExecutionsInParallel(t *testing.T) {
 	// when
 	latch.Done()
 	waitForFinished.Wait()
-	err := counter.Synchronize(fakeClient, toolchainStatus)
+	err := counter.Synchronize(fakeClient, toolchainStatus, nil)
 
 	// then
 	require.NoError(t, err)
@@ -26,24 +26,47 @@ var (
 	UserSignupAutoDeactivatedTotal prometheus.Counter
 )
 
+// counter vectors
+var (
+	// UserAccountDriftCounterVec should be incremented each time a UserAccount is found changed or deleted in a member cluster
+	// while its MasterUserRecord was not changed, with a label to partition per member cluster
+	UserAccountDriftCounterVec *prometheus.CounterVec
+
+	// UserAccountOrphanedCounterVec should be incremented each time a UserAccount is orphaned because its member cluster was unavailable
+	// while the MasterUserRecord was being deleted, with a label to partition per member cluster
+	UserAccountOrphanedCounterVec *prometheus.CounterVec
+)
+
 // gauges
 var (
 	// DEPRECATED - See MasterUserRecordGaugeVec
 	// MasterUserRecordGauge should reflect the current number of master user records in the system
 	MasterUserRecordGauge prometheus.Gauge
+
+	// UserSlotsRemainingGauge should reflect the estimated number of users that can still be provisioned to all the member clusters, -1 if it is not limited
+	UserSlotsRemainingGauge prometheus.Gauge
+
+	// CapacityTimeToFullGauge should reflect the estimated number of seconds until all the member clusters are full based on the recent approval rate,
+	// -1 if it cannot be estimated
+	CapacityTimeToFullGauge prometheus.Gauge
 )
 
 // gauge vectors
 var (
 	// MasterUserRecordGauge should reflect the current number of master user records in the system, with a label to partition per member cluster
 	UserAccountGaugeVec *prometheus.GaugeVec
+
+	// UserSlotsRemainingGaugeVec should reflect the estimated number of users that can still be provisioned, with a label to partition per member cluster.
+	// The value is -1 if the number of users is not limited in the member cluster.
+	UserSlotsRemainingGaugeVec *prometheus.GaugeVec
 )
 
 // collections
 var (
-	allCounters  = []prometheus.Counter{}
-	allGauges    = []prometheus.Gauge{}
-	allGaugeVecs = []*prometheus.GaugeVec{}
+	allCounters    = []prometheus.Counter{}
+	allCounterVecs = []*prometheus.CounterVec{}
+	allGauges      = []prometheus.Gauge{}
+	allGaugeVecs   = []*prometheus.GaugeVec{}
 )
 
 func init() {
@@ -60,10 +83,16 @@ func initMetrics() {
 	UserSignupBannedTotal = newCounter("user_signups_banned_total", "Total number of Banned User Signups")
 	UserSignupDeactivatedTotal = newCounter("user_signups_deactivated_total", "Total number of Deactivated User Signups")
 	UserSignupAutoDeactivatedTotal = newCounter("user_signups_auto_deactivated_total", "Total number of Automatically Deactivated User Signups")
+	// CounterVecs
+	UserAccountDriftCounterVec = newCounterVec("user_account_drifts_total", "Total number of UserAccounts found out of sync with their MasterUserRecord (per member cluster)", "cluster_name")
+	UserAccountOrphanedCounterVec = newCounterVec("user_accounts_orphaned_total", "Total number of UserAccounts orphaned in unavailable member clusters (per member cluster)", "cluster_name")
 	// Gauges
 	MasterUserRecordGauge = newGauge("master_user_record_current", "Current number of Master User Records")
+	UserSlotsRemainingGauge = newGauge("user_slots_remaining", "Estimated number of users that can still be provisioned (-1 if not limited)")
+	CapacityTimeToFullGauge = newGauge("capacity_time_to_full_seconds", "Estimated number of seconds until the member clusters are full based on the recent approval rate (-1 if it cannot be estimated)")
 	// GaugeVecs
 	UserAccountGaugeVec = newGaugeVec("user_accounts_current", "Current number of User Accounts (per member cluster)", "cluster_name")
+	UserSlotsRemainingGaugeVec = newGaugeVec("user_slots_remaining_per_cluster", "Estimated number of users that can still be provisioned (per member cluster, -1 if not limited)", "cluster_name")
 	log.Info("custom metrics initialized")
 }
 
@@ -81,6 +110,15 @@ func newCounter(name, help string) prometheus.Counter {
 	return c
 }
 
+func newCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	v := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + name,
+		Help: help,
+	}, labels)
+	allCounterVecs = append(allCounterVecs, v)
+	return v
+}
+
 func newGauge(name, help string) prometheus.Gauge {
 	g := prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: metricsPrefix + name,
@@ -105,6 +143,9 @@ func RegisterCustomMetrics() {
 	for _, c := range allCounters {
 		k8smetrics.Registry.MustRegister(c)
 	}
+	for _, v := range allCounterVecs {
+		k8smetrics.Registry.MustRegister(v)
+	}
 	for _, g := range allGauges {
 		k8smetrics.Registry.MustRegister(g)
 	}
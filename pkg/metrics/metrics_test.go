@@ -20,6 +20,19 @@ func TestInitCounter(t *testing.T) {
 	assert.Equal(t, float64(1), promtestutil.ToFloat64(m))
 }
 
+func TestInitCounterVec(t *testing.T) {
+	// given
+	m := newCounterVec("test_counter_vec", "test counter description", "cluster_name")
+
+	// when
+	m.WithLabelValues("member-1").Inc()
+	m.WithLabelValues("member-2").Add(2)
+
+	// then
+	assert.Equal(t, float64(1), promtestutil.ToFloat64(m.WithLabelValues("member-1")))
+	assert.Equal(t, float64(2), promtestutil.ToFloat64(m.WithLabelValues("member-2")))
+}
+
 func TestInitGauge(t *testing.T) {
 	// given
 	m := newGauge("test_gauge", "test gauge description")
@@ -54,6 +67,10 @@ func TestRegisterCustomMetrics(t *testing.T) {
 		assert.True(t, k8smetrics.Registry.Unregister(m))
 	}
 
+	for _, m := range allCounterVecs {
+		assert.True(t, k8smetrics.Registry.Unregister(m))
+	}
+
 	for _, m := range allGauges {
 		assert.True(t, k8smetrics.Registry.Unregister(m))
 	}
@@ -67,6 +84,7 @@ func TestResetMetrics(t *testing.T) {
 
 	// when
 	UserSignupUniqueTotal.Inc()
+	UserAccountDriftCounterVec.WithLabelValues("member-1").Inc()
 	MasterUserRecordGauge.Set(22)
 	UserAccountGaugeVec.WithLabelValues("member-1").Set(20)
 
@@ -74,6 +92,7 @@ func TestResetMetrics(t *testing.T) {
 
 	// then
 	assert.Equal(t, float64(0), promtestutil.ToFloat64(UserSignupUniqueTotal))
+	assert.Equal(t, float64(0), promtestutil.ToFloat64(UserAccountDriftCounterVec.WithLabelValues("member-1")))
 	assert.Equal(t, float64(0), promtestutil.ToFloat64(MasterUserRecordGauge))
 	assert.Equal(t, float64(0), promtestutil.ToFloat64(UserAccountGaugeVec.WithLabelValues("member-1")))
 }
@@ -0,0 +1,114 @@
+package tierweight
+
+import (
+	"context"
+	"sync"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	crtCfg "github.com/codeready-toolchain/host-operator/pkg/configuration"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// limitsMemory is the resource of the ClusterResourceQuota the weight of a tier is derived from
+const limitsMemory = "limits.memory"
+
+var cachedWeights = cache{
+	weights: map[string]int{},
+}
+
+// cache contains the derived weights mapped by the names of the TierTemplates and the memory unit.
+// The TierTemplates are immutable, so the weights never change.
+type cache struct {
+	sync.RWMutex
+	weights map[string]int
+}
+
+// Reset resets the cached weights - is supposed to be used only in tests
+func Reset() {
+	cachedWeights.Lock()
+	defer cachedWeights.Unlock()
+	cachedWeights.weights = map[string]int{}
+}
+
+// IsConfigured returns true if there is any tier weight configured explicitly or if the weights should be derived from the ClusterResourceQuotas
+func IsConfigured(crtConfig *crtCfg.Config) bool {
+	_, derived := crtConfig.GetTierWeightMemoryUnit()
+	return derived || len(crtConfig.GetTierWeights()) > 0
+}
+
+// Get returns how much of the capacity of a member cluster a UserAccount of the given tier consumes.
+// The weight configured explicitly for the tier takes precedence. Otherwise, if the memory unit is configured, then the weight
+// is the sum of the limits.memory of the ClusterResourceQuotas defined in the cluster resources template of the tier divided
+// by the unit (rounded up). The weight is 1 in all the other cases.
+func Get(cl client.Client, crtConfig *crtCfg.Config, namespace, tierName string) (int, error) {
+	if weight, found := crtConfig.GetTierWeights()[tierName]; found && weight > 0 {
+		return weight, nil
+	}
+	unit, found := crtConfig.GetTierWeightMemoryUnit()
+	if !found || tierName == "" {
+		return 1, nil
+	}
+	tier := &toolchainv1alpha1.NSTemplateTier{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: tierName}, tier); err != nil {
+		return 1, errors.Wrapf(err, "unable to get the NSTemplateTier '%s'", tierName)
+	}
+	if tier.Spec.ClusterResources == nil {
+		return 1, nil
+	}
+	return derive(cl, namespace, tier.Spec.ClusterResources.TemplateRef, unit)
+}
+
+// ForNamespace returns a function that returns the weights of the tiers in the given namespace
+func ForNamespace(cl client.Client, crtConfig *crtCfg.Config, namespace string) func(tierName string) (int, error) {
+	return func(tierName string) (int, error) {
+		return Get(cl, crtConfig, namespace, tierName)
+	}
+}
+
+func derive(cl client.Client, namespace, templateRef string, unit resource.Quantity) (int, error) {
+	key := templateRef + "/" + unit.String()
+	cachedWeights.RLock()
+	weight, found := cachedWeights.weights[key]
+	cachedWeights.RUnlock()
+	if found {
+		return weight, nil
+	}
+
+	tierTemplate := &toolchainv1alpha1.TierTemplate{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: templateRef}, tierTemplate); err != nil {
+		return 1, errors.Wrapf(err, "unable to get the TierTemplate '%s'", templateRef)
+	}
+	total := resource.Quantity{}
+	for _, rawObject := range tierTemplate.Spec.Template.Objects {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(rawObject.Raw); err != nil {
+			return 1, errors.Wrapf(err, "unable to parse an object of the TierTemplate '%s'", templateRef)
+		}
+		if obj.GetKind() != "ClusterResourceQuota" {
+			continue
+		}
+		value, found, err := unstructured.NestedString(obj.Object, "spec", "quota", "hard", limitsMemory)
+		if err != nil || !found {
+			continue
+		}
+		memory, err := resource.ParseQuantity(value)
+		if err != nil {
+			return 1, errors.Wrapf(err, "invalid %s of the ClusterResourceQuota '%s' in the TierTemplate '%s'", limitsMemory, obj.GetName(), templateRef)
+		}
+		total.Add(memory)
+	}
+
+	weight = int((total.Value() + unit.Value() - 1) / unit.Value())
+	if weight < 1 {
+		weight = 1
+	}
+	cachedWeights.Lock()
+	defer cachedWeights.Unlock()
+	cachedWeights.weights[key] = weight
+	return weight, nil
+}
@@ -0,0 +1,200 @@
+package tierweight_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/pkg/apis/toolchain/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/configuration"
+	"github.com/codeready-toolchain/host-operator/pkg/tierweight"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestIsConfigured(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// then
+		assert.False(t, tierweight.IsConfigured(config))
+	})
+
+	t.Run("explicit weights", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t, Env("HOST_OPERATOR_TIER_WEIGHTS", "advanced=3"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, tierweight.IsConfigured(config))
+	})
+
+	t.Run("memory unit", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t, Env("HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "7Gi"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// then
+		assert.True(t, tierweight.IsConfigured(config))
+	})
+}
+
+func TestGet(t *testing.T) {
+	// given
+	tier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+	tierTemplate := newClusterResourcesTierTemplate(t, "basic-clusterresources-123456new", "10Gi", "5Gi")
+
+	t.Run("default weight", func(t *testing.T) {
+		// given
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		weight, err := tierweight.Get(NewFakeClient(t, tier, tierTemplate), config, HostOperatorNs, "basic")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 1, weight)
+	})
+
+	t.Run("explicit weight", func(t *testing.T) {
+		// given
+		restore := SetEnvVarsAndRestore(t,
+			Env("HOST_OPERATOR_TIER_WEIGHTS", "basic=4"),
+			Env("HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "7Gi"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		weight, err := tierweight.Get(NewFakeClient(t), config, HostOperatorNs, "basic")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 4, weight)
+	})
+
+	t.Run("derived from the ClusterResourceQuotas", func(t *testing.T) {
+		// given
+		defer tierweight.Reset()
+		restore := SetEnvVarsAndRestore(t, Env("HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "7Gi"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		cl := NewFakeClient(t, tier, tierTemplate)
+
+		// when
+		weight, err := tierweight.Get(cl, config, HostOperatorNs, "basic")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 3, weight) // 15Gi / 7Gi rounded up
+
+		t.Run("cached weight is used", func(t *testing.T) {
+			// given
+			cl.MockGet = func(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+				if _, ok := obj.(*toolchainv1alpha1.TierTemplate); ok {
+					return fmt.Errorf("mock error")
+				}
+				return cl.Client.Get(ctx, key, obj)
+			}
+
+			// when
+			weight, err := tierweight.Get(cl, config, HostOperatorNs, "basic")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, 3, weight)
+		})
+	})
+
+	t.Run("at least 1 when derived", func(t *testing.T) {
+		// given
+		defer tierweight.Reset()
+		restore := SetEnvVarsAndRestore(t, Env("HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "7Gi"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+		emptyTemplate := newClusterResourcesTierTemplate(t, "basic-clusterresources-123456new")
+
+		// when
+		weight, err := tierweight.Get(NewFakeClient(t, tier, emptyTemplate), config, HostOperatorNs, "basic")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 1, weight)
+	})
+
+	t.Run("missing NSTemplateTier", func(t *testing.T) {
+		// given
+		defer tierweight.Reset()
+		restore := SetEnvVarsAndRestore(t, Env("HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "7Gi"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		weight, err := tierweight.Get(NewFakeClient(t), config, HostOperatorNs, "basic")
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to get the NSTemplateTier 'basic'")
+		assert.Equal(t, 1, weight)
+	})
+
+	t.Run("missing TierTemplate", func(t *testing.T) {
+		// given
+		defer tierweight.Reset()
+		restore := SetEnvVarsAndRestore(t, Env("HOST_OPERATOR_TIER_WEIGHT_MEMORY_UNIT", "7Gi"))
+		defer restore()
+		config, err := configuration.LoadConfig(NewFakeClient(t))
+		require.NoError(t, err)
+
+		// when
+		weight, err := tierweight.Get(NewFakeClient(t, tier), config, HostOperatorNs, "basic")
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to get the TierTemplate 'basic-clusterresources-123456new'")
+		assert.Equal(t, 1, weight)
+	})
+}
+
+func newClusterResourcesTierTemplate(t *testing.T, name string, limitsMemory ...string) *toolchainv1alpha1.TierTemplate {
+	objects := []runtime.RawExtension{
+		{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"${USERNAME}-dev"}}`)},
+	}
+	for i, memory := range limitsMemory {
+		objects = append(objects, runtime.RawExtension{
+			Raw: []byte(fmt.Sprintf(`{"apiVersion":"quota.openshift.io/v1","kind":"ClusterResourceQuota","metadata":{"name":"for-${USERNAME}-%d"},`+
+				`"spec":{"quota":{"hard":{"limits.cpu":"2000m","limits.memory":"%s"}}}}`, i, memory)),
+		})
+	}
+	return &toolchainv1alpha1.TierTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: HostOperatorNs,
+			Name:      name,
+		},
+		Spec: toolchainv1alpha1.TierTemplateSpec{
+			TierName: "basic",
+			Type:     toolchainv1alpha1.ClusterResourcesTemplateType,
+			Revision: "123456new",
+			Template: templatev1.Template{
+				Objects: objects,
+			},
+		},
+	}
+}
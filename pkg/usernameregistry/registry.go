@@ -0,0 +1,146 @@
+package usernameregistry
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("username_registry")
+
+// maxDataSize is the maximum size of the data of the ConfigMap backing the registry. The size of a ConfigMap is limited to 1MiB,
+// so some room is left for the metadata.
+var maxDataSize = 900 * 1024
+
+// Registry contains the usernames that cannot be used by new users.
+//
+// The registry is backed by a ConfigMap where every key is a reserved username and the value is either empty
+// (the username is reserved permanently, eg. names of staff members or products) or a timestamp in RFC3339 format
+// until which the username is reserved (eg. the name of a deleted user that is parked for a cooldown period).
+type Registry struct {
+	entries map[string]string
+}
+
+// Load loads the registry from the ConfigMap with the given name. If the ConfigMap doesn't exist, then the registry is empty.
+func Load(cl client.Client, namespace, name string) (Registry, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return Registry{}, nil
+		}
+		return Registry{}, errors.Wrapf(err, "unable to get the ConfigMap '%s' containing the reserved usernames", name)
+	}
+	return Registry{entries: configMap.Data}, nil
+}
+
+// IsReserved returns true if the given username is reserved at the given time
+func (r Registry) IsReserved(username string, now time.Time) bool {
+	until, found := r.entries[username]
+	if !found {
+		return false
+	}
+	return isActive(username, until, now)
+}
+
+// Park reserves the given username until the given time. If the username is already reserved for a longer period
+// (or permanently), then the reservation is kept. The expired reservations are removed from the registry.
+// If the registry doesn't fit in the ConfigMap, then the parked usernames that expire first are removed; the permanent reservations are always kept.
+func Park(cl client.Client, namespace, name, username string, until time.Time, now time.Time) error {
+	configMap := &corev1.ConfigMap{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "unable to get the ConfigMap '%s' containing the reserved usernames", name)
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+			},
+			Data: map[string]string{
+				username: until.UTC().Format(time.RFC3339),
+			},
+		}
+		if err := cl.Create(context.TODO(), configMap); err != nil {
+			return errors.Wrapf(err, "unable to create the ConfigMap '%s' containing the reserved usernames", name)
+		}
+		return nil
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	for reserved, reservedUntil := range configMap.Data {
+		if !isActive(reserved, reservedUntil, now) {
+			delete(configMap.Data, reserved)
+		}
+	}
+	if !isReservedAt(configMap.Data, username, until) {
+		configMap.Data[username] = until.UTC().Format(time.RFC3339)
+	}
+	evictSoonestExpiring(configMap.Data)
+	if err := cl.Update(context.TODO(), configMap); err != nil {
+		return errors.Wrapf(err, "unable to update the ConfigMap '%s' containing the reserved usernames", name)
+	}
+	return nil
+}
+
+// isReservedAt returns true if the username is already reserved at the given time
+func isReservedAt(entries map[string]string, username string, at time.Time) bool {
+	until, found := entries[username]
+	return found && isActive(username, until, at)
+}
+
+// isActive returns true if the reservation with the given value is active at the given time.
+// Reservations with an invalid value are considered permanent.
+func isActive(username, until string, now time.Time) bool {
+	if until == "" {
+		return true
+	}
+	expiration, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		log.Error(err, "invalid expiration of the reserved username, considering it permanent", "username", username, "value", until)
+		return true
+	}
+	return now.Before(expiration)
+}
+
+// evictSoonestExpiring removes the parked usernames that expire first until the size of the entries doesn't exceed maxDataSize
+func evictSoonestExpiring(entries map[string]string) {
+	size := 0
+	type parked struct {
+		username   string
+		expiration time.Time
+	}
+	var evictable []parked
+	for username, until := range entries {
+		size += len(username) + len(until)
+		if expiration, err := time.Parse(time.RFC3339, until); err == nil {
+			evictable = append(evictable, parked{username: username, expiration: expiration})
+		}
+	}
+	if size <= maxDataSize {
+		return
+	}
+	sort.Slice(evictable, func(i, j int) bool {
+		if evictable[i].expiration.Equal(evictable[j].expiration) {
+			return evictable[i].username < evictable[j].username
+		}
+		return evictable[i].expiration.Before(evictable[j].expiration)
+	})
+	for _, p := range evictable {
+		if size <= maxDataSize {
+			break
+		}
+		log.Info("the registry of the reserved usernames is full, removing the parked username that expires first", "username", p.username, "until", entries[p.username])
+		size -= len(p.username) + len(entries[p.username])
+		delete(entries, p.username)
+	}
+}
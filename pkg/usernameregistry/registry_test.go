@@ -0,0 +1,166 @@
+package usernameregistry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const configMapName = "reserved-usernames"
+
+func TestLoad(t *testing.T) {
+	// given
+	now := time.Now()
+	configMap := newConfigMap(map[string]string{
+		"admin":   "",
+		"johnny":  now.Add(time.Hour).Format(time.RFC3339),
+		"expired": now.Add(-time.Hour).Format(time.RFC3339),
+		"invalid": "tomorrow",
+	})
+
+	t.Run("reserved usernames", func(t *testing.T) {
+		// when
+		registry, err := Load(test.NewFakeClient(t, configMap), test.HostOperatorNs, configMapName)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, registry.IsReserved("admin", now))
+		assert.True(t, registry.IsReserved("johnny", now))
+		assert.False(t, registry.IsReserved("johnny", now.Add(2*time.Hour)))
+		assert.False(t, registry.IsReserved("expired", now))
+		assert.True(t, registry.IsReserved("invalid", now))
+		assert.False(t, registry.IsReserved("unknown", now))
+	})
+
+	t.Run("missing ConfigMap means empty registry", func(t *testing.T) {
+		// when
+		registry, err := Load(test.NewFakeClient(t), test.HostOperatorNs, configMapName)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, registry.IsReserved("admin", now))
+	})
+
+	t.Run("fails when get fails", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t)
+		cl.MockGet = func(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+			return fmt.Errorf("some error")
+		}
+
+		// when
+		_, err := Load(cl, test.HostOperatorNs, configMapName)
+
+		// then
+		require.EqualError(t, err, "unable to get the ConfigMap 'reserved-usernames' containing the reserved usernames: some error")
+	})
+}
+
+func TestPark(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("creates ConfigMap when missing", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t)
+
+		// when
+		err := Park(cl, test.HostOperatorNs, configMapName, "johnny", now.Add(time.Hour), now)
+
+		// then
+		require.NoError(t, err)
+		assertEntries(t, cl, map[string]string{
+			"johnny": now.Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	})
+
+	t.Run("adds the username and removes the expired ones", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, newConfigMap(map[string]string{
+			"admin":   "",
+			"expired": now.Add(-time.Hour).Format(time.RFC3339),
+			"later":   now.Add(10 * time.Hour).UTC().Format(time.RFC3339),
+		}))
+
+		// when
+		err := Park(cl, test.HostOperatorNs, configMapName, "johnny", now.Add(time.Hour), now)
+
+		// then
+		require.NoError(t, err)
+		assertEntries(t, cl, map[string]string{
+			"admin":  "",
+			"later":  now.Add(10 * time.Hour).UTC().Format(time.RFC3339),
+			"johnny": now.Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	})
+
+	t.Run("keeps longer reservations", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, newConfigMap(map[string]string{
+			"admin":  "",
+			"johnny": now.Add(10 * time.Hour).UTC().Format(time.RFC3339),
+		}))
+
+		// when
+		err := Park(cl, test.HostOperatorNs, configMapName, "johnny", now.Add(time.Hour), now)
+		require.NoError(t, err)
+		err = Park(cl, test.HostOperatorNs, configMapName, "admin", now.Add(time.Hour), now)
+		require.NoError(t, err)
+
+		// then
+		assertEntries(t, cl, map[string]string{
+			"admin":  "",
+			"johnny": now.Add(10 * time.Hour).UTC().Format(time.RFC3339),
+		})
+	})
+
+	t.Run("removes the parked usernames that expire first when the registry is full", func(t *testing.T) {
+		// given
+		defer func(size int) {
+			maxDataSize = size
+		}(maxDataSize)
+		maxDataSize = 60
+		cl := test.NewFakeClient(t, newConfigMap(map[string]string{
+			"admin":  "",
+			"sooner": now.Add(30 * time.Minute).UTC().Format(time.RFC3339),
+			"later":  now.Add(10 * time.Hour).UTC().Format(time.RFC3339),
+		}))
+
+		// when
+		err := Park(cl, test.HostOperatorNs, configMapName, "johnny", now.Add(time.Hour), now)
+
+		// then
+		require.NoError(t, err)
+		assertEntries(t, cl, map[string]string{
+			"admin":  "",
+			"later":  now.Add(10 * time.Hour).UTC().Format(time.RFC3339),
+			"johnny": now.Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	})
+}
+
+func newConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: test.HostOperatorNs,
+			Name:      configMapName,
+		},
+		Data: data,
+	}
+}
+
+func assertEntries(t *testing.T, cl client.Client, expected map[string]string) {
+	configMap := &corev1.ConfigMap{}
+	err := cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, configMapName), configMap)
+	require.NoError(t, err)
+	assert.Equal(t, expected, configMap.Data)
+}
@@ -51,6 +51,11 @@ func (a *CounterAssertion) HaveUserAccountsForCluster(clusterName string, number
 	return a
 }
 
+func (a *CounterAssertion) HaveWeightedUserAccountsForCluster(clusterName string, number int) *CounterAssertion {
+	assert.Equal(a.t, number, a.counts.WeightedUserAccountsPerClusterCounts[clusterName])
+	return a
+}
+
 func CreateMultipleMurs(t *testing.T, prefix string, number int, targetCluster string) []runtime.Object {
 	murs := make([]runtime.Object, number)
 	for index := range murs {
@@ -75,7 +80,7 @@ func initializeCounters(t *testing.T, cl *commontest.FakeClient, toolchainStatus
 		metrics.MasterUserRecordGauge.Set(float64(toolchainStatus.Status.HostOperator.MasterUserRecordCount))
 	}
 	t.Logf("toolchainStatus members: %v", toolchainStatus.Status.Members)
-	err := counter.Synchronize(cl, toolchainStatus)
+	err := counter.Synchronize(cl, toolchainStatus, nil)
 	require.NoError(t, err)
 	t.Logf("MasterUserRecordGauge=%.0f", promtestutil.ToFloat64(metrics.MasterUserRecordGauge))
 }
@@ -39,6 +39,19 @@ func ApprovedAutomatically() UserSignupModifier {
 	}
 }
 
+func ApprovedAutomaticallyAt(at time.Time) UserSignupModifier {
+	return func(userSignup *v1alpha1.UserSignup) {
+		userSignup.Labels[v1alpha1.UserSignupStateLabelKey] = v1alpha1.UserSignupStateLabelValueApproved
+		userSignup.Status.Conditions = condition.AddStatusConditions(userSignup.Status.Conditions,
+			toolchainv1alpha1.Condition{
+				Type:               v1alpha1.UserSignupApproved,
+				Status:             v1.ConditionTrue,
+				Reason:             v1alpha1.UserSignupApprovedAutomaticallyReason,
+				LastTransitionTime: metav1.NewTime(at),
+			})
+	}
+}
+
 func Deactivated() UserSignupModifier {
 	return func(userSignup *v1alpha1.UserSignup) {
 		userSignup.Spec.Deactivated = true